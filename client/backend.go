@@ -0,0 +1,164 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	hue "github.com/collinux/GoHue"
+)
+
+// DefaultBackend is the backend that is used when none is configured.
+const DefaultBackend = "hue"
+
+// Capabilities tell which features of the Hue API a gateway supports.
+type Capabilities struct {
+	// Discovery is set when gateways can be found through a portal
+	Discovery bool
+	// LinkButton is set when new users are allowed after pressing the
+	// link button, other gateways need to be unlocked in their app
+	LinkButton bool
+	// FindLights and FindSensors are set when the gateway can search
+	// for new lights and sensors
+	FindLights  bool
+	FindSensors bool
+	// Scenes is set when scenes can be recalled through /scenes
+	Scenes bool
+	// V2 is set when the gateway can serve the CLIP v2 API, older
+	// firmware of a bridge does not
+	V2 bool
+}
+
+// A Backend handles the differences between a Philips Hue bridge and the
+// gateways that speak a Hue compatible dialect, like deCONZ and diyHue.
+type Backend interface {
+	// Name is how the backend is selected in the configuration.
+	Name() string
+	// Capabilities returns the features that the gateway supports.
+	Capabilities() Capabilities
+	// Discover returns the gateways in the local network.
+	Discover() ([]hue.Bridge, error)
+	// Connect returns the gateway at the address, without logging in.
+	Connect(address string) (*hue.Bridge, error)
+	// CreateUser registers a new user for the device on the gateway.
+	CreateUser(bridge *hue.Bridge, device string) (string, error)
+	// SensorKind returns the kind of a sensor type, like "presence" or
+	// "switch", which is the same for all backends.
+	SensorKind(sensorType string) string
+}
+
+var backends = map[string]Backend{}
+
+// registerBackend makes the backend available for LookupBackend.
+func registerBackend(backend Backend) {
+	backends[backend.Name()] = backend
+}
+
+// Backends returns the names of the available backends.
+func Backends() []string {
+	names := []string{}
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// LookupBackend returns the backend with the name, the DefaultBackend is
+// returned when the name is empty.
+func LookupBackend(name string) (Backend, error) {
+	if name == "" {
+		name = DefaultBackend
+	}
+
+	backend, ok := backends[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %s, use one of %s", name, strings.Join(Backends(), ", "))
+	}
+
+	return backend, nil
+}
+
+// An UnsupportedError is returned when the backend of the gateway does not
+// support a feature.
+type UnsupportedError struct {
+	Backend string
+	Feature string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s is not supported by the %s backend", e.Feature, e.Backend)
+}
+
+// sensorKind removes the prefix that tells how a sensor is connected (like
+// ZLL for ZigBee Light Link, or CLIP for sensors that are created through
+// the API) from the type of the sensor.
+func sensorKind(sensorType string, prefixes ...string) string {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(sensorType, prefix) && len(sensorType) > len(prefix) {
+			sensorType = sensorType[len(prefix):]
+			break
+		}
+	}
+
+	return strings.ToLower(sensorType)
+}
+
+// hueBackend is a Philips Hue bridge.
+type hueBackend struct{}
+
+func init() {
+	registerBackend(hueBackend{})
+}
+
+func (hueBackend) Name() string {
+	return "hue"
+}
+
+func (hueBackend) Capabilities() Capabilities {
+	return Capabilities{
+		Discovery:   true,
+		LinkButton:  true,
+		FindLights:  true,
+		FindSensors: true,
+		Scenes:      true,
+		V2:          true,
+	}
+}
+
+func (hueBackend) Discover() ([]hue.Bridge, error) {
+	return hue.FindBridges()
+}
+
+func (hueBackend) Connect(address string) (*hue.Bridge, error) {
+	return hue.NewBridge(address)
+}
+
+func (hueBackend) CreateUser(bridge *hue.Bridge, device string) (string, error) {
+	return bridge.CreateUser(device)
+}
+
+func (hueBackend) SensorKind(sensorType string) string {
+	return sensorKind(sensorType, "ZLL", "ZGP", "CLIP")
+}
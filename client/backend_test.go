@@ -0,0 +1,139 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/huetest"
+)
+
+func TestLookupBackend(t *testing.T) {
+	backend, err := LookupBackend("")
+	if err != nil || backend.Name() != DefaultBackend {
+		t.Errorf("expected the default backend, got %v (%v)", backend, err)
+	}
+
+	backend, err = LookupBackend("deCONZ")
+	if err != nil || backend.Name() != "deconz" {
+		t.Errorf("expected the deconz backend, got %v (%v)", backend, err)
+	}
+
+	_, err = LookupBackend("zigbee2mqtt")
+	if err == nil || !strings.Contains(err.Error(), "deconz, diyhue, hue") {
+		t.Errorf("expected an error with the known backends, got %v", err)
+	}
+}
+
+func TestSensorKind(t *testing.T) {
+	for _, tc := range []struct {
+		backend    string
+		sensorType string
+		kind       string
+	}{
+		{"hue", "ZLLPresence", "presence"},
+		{"hue", "ZGPSwitch", "switch"},
+		{"hue", "Daylight", "daylight"},
+		{"deconz", "ZHAPresence", "presence"},
+		{"deconz", "ZHALightLevel", "lightlevel"},
+		{"deconz", "CLIPGenericFlag", "genericflag"},
+		{"diyhue", "ZLLSwitch", "switch"},
+	} {
+		backend, _ := LookupBackend(tc.backend)
+		if kind := backend.SensorKind(tc.sensorType); kind != tc.kind {
+			t.Errorf("%s: expected kind %s for %s, got %s", tc.backend, tc.kind, tc.sensorType, kind)
+		}
+	}
+}
+
+func TestDeconzDiscover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"00212EFFFF012345","internalipaddress":"192.168.1.10","internalport":80},
+			{"id":"00212EFFFF054321","internalipaddress":"192.168.1.11","internalport":8080}]`))
+	}))
+	defer server.Close()
+
+	url := phosconDiscoveryURL
+	phosconDiscoveryURL = server.URL
+	defer func() {
+		phosconDiscoveryURL = url
+	}()
+
+	bridges, err := Discover("deconz")
+	if err != nil {
+		t.Fatalf("discovery failed: %s", err)
+	}
+	if len(bridges) != 2 || bridges[0].IPAddress != "192.168.1.10" || bridges[1].IPAddress != "192.168.1.11:8080" {
+		t.Errorf("unexpected gateways: %+v", bridges)
+	}
+
+	var unsupported *UnsupportedError
+	_, err = Discover("diyhue")
+	if !errors.As(err, &unsupported) {
+		t.Errorf("expected discovery to be unsupported for diyhue, got %v", err)
+	}
+}
+
+func TestDeconzWithoutDescription(t *testing.T) {
+	state, err := huetest.LoadState("testdata/bridge.yaml")
+	if err != nil {
+		t.Fatalf("failed to load bridge state: %s", err)
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	// a gateway in a container does not always serve the UPnP description
+	bridge := huetest.NewBridge(state)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/description.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		bridge.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	c, err := New(strings.TrimPrefix(server.URL, "http://"), testUser, Options{Backend: "deconz"})
+	if err != nil {
+		t.Fatalf("failed to connect to the gateway: %s", err)
+	}
+	if c.ID() != "001788FFFE23BFC2" {
+		t.Errorf("unexpected ID of the gateway: %s", c.ID())
+	}
+
+	// scenes are not read through /scenes
+	inventory, err := c.RefreshInventory()
+	if err != nil {
+		t.Fatalf("failed to read the inventory: %s", err)
+	}
+	if len(inventory.Lights) != 2 || len(inventory.Scenes) != 0 {
+		t.Errorf("unexpected inventory: %+v", inventory)
+	}
+
+	var unsupported *UnsupportedError
+	_, err = c.RecallScene("relax", "")
+	if !errors.As(err, &unsupported) {
+		t.Errorf("expected recalling scenes to be unsupported, got %v", err)
+	}
+}
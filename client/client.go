@@ -0,0 +1,238 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+// Package client controls the lights, groups, sensors and scenes of a Philips
+// Hue bridge. It contains the logic of the hue-cli commands, so that other
+// programs can use it as well.
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	hue "github.com/collinux/GoHue"
+
+	"github.com/nixpanic/hue-cli/clipv2"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// DefaultCacheTTL is how long the cached inventory of a bridge is used when
+// Options.CacheTTL is not set.
+const DefaultCacheTTL = time.Hour
+
+// Options change the behaviour of a Client.
+type Options struct {
+	// Backend is the name of the backend for the type of gateway, the
+	// DefaultBackend is used when it is not set
+	Backend string
+
+	// Logger reports what the Client does, nothing is reported when it
+	// is not set
+	Logger *utils.Logger
+
+	// CacheTTL is how long the cached inventory of the bridge is used
+	CacheTTL time.Duration
+	// NoCache disables the cache of the inventory
+	NoCache bool
+
+	// V2Address is the <ip-address>:<port> of the CLIP v2 API, when it
+	// is not served on the default HTTPS port of the bridge
+	V2Address string
+	// HTTPClient sends the requests to the CLIP v2 API, see
+	// clipv2.Options
+	HTTPClient *http.Client
+	// StreamHTTPClient receives the event stream of the CLIP v2 API, see
+	// clipv2.Options
+	StreamHTTPClient *http.Client
+
+	// Pin is the trusted certificate of the bridge, and OnPin is called
+	// when it is trusted on first use, see BridgeTransport. They are only
+	// used when HTTPClient is not set.
+	Pin   Pin
+	OnPin func(pin Pin)
+
+	// Aliases and Tags are local names for lights and groups, they are
+	// resolved before the names on the bridge
+	Aliases []utils.Alias
+	Tags    []utils.Tag
+
+	// DryRun is set when the changes are not sent to the bridge, the
+	// Client does not read back what it did not create
+	DryRun bool
+}
+
+// A Client is logged in on a bridge.
+type Client struct {
+	Bridge  *hue.Bridge
+	Backend Backend
+
+	logger   *utils.Logger
+	cacheTTL time.Duration
+	noCache  bool
+
+	v2Address    string
+	httpClient   *http.Client
+	streamClient *http.Client
+	v2           *clipv2.Client
+	v2Err        error
+
+	aliases []utils.Alias
+	tags    []utils.Tag
+
+	dryRun bool
+
+	// inventory is kept after it was read, see ForgetInventory
+	inventory     *utils.Inventory
+	inventoryLock sync.Mutex
+}
+
+// New connects to the bridge at the address, and logs in with the username.
+func New(address, username string, options Options) (*Client, error) {
+	backend, err := LookupBackend(options.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		Backend:  backend,
+		logger:   options.Logger,
+		cacheTTL: options.CacheTTL,
+		noCache:  options.NoCache,
+
+		v2Address:    options.V2Address,
+		httpClient:   options.HTTPClient,
+		streamClient: options.StreamHTTPClient,
+
+		aliases: options.Aliases,
+		tags:    options.Tags,
+
+		dryRun: options.DryRun,
+	}
+	if c.logger == nil {
+		c.logger = &utils.Logger{Level: utils.LevelQuiet}
+	}
+	if c.cacheTTL == 0 {
+		c.cacheTTL = DefaultCacheTTL
+	}
+	if c.httpClient == nil {
+		transport, ok := http.DefaultTransport.(*http.Transport)
+		if !ok {
+			transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+		}
+
+		c.httpClient = &http.Client{Transport: &BridgeTransport{
+			Transport:    transport,
+			Address:      address,
+			HTTPSAddress: options.V2Address,
+			Pin:          options.Pin,
+			OnPin:        options.OnPin,
+		}}
+	}
+
+	c.logger.Verbosef("connecting to bridge %s\n", address)
+	bridge, err := backend.Connect(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to bridge %s (%w)", address, err)
+	}
+	c.logger.Verbosef("connected to bridge %s (%s)\n", bridge.Info.Device.FriendlyName, bridge.Info.Device.ModelNumber)
+
+	err = bridge.Login(username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to login on bridge %s (%w)", address, err)
+	}
+	c.logger.Verbosef("logged in on bridge %s\n", address)
+
+	c.Bridge = bridge
+
+	return c, nil
+}
+
+// NewFromConfig connects to the first bridge in the configuration. The
+// backend, aliases and tags in the configuration are used when they are not
+// set in the options.
+func NewFromConfig(config *utils.ConfigFile, options Options) (*Client, error) {
+	if len(config.Bridges) == 0 {
+		return nil, errors.New("the configuration does not contain a bridge")
+	}
+
+	if options.Backend == "" {
+		options.Backend = config.Bridges[0].Backend
+	}
+	if options.Aliases == nil {
+		options.Aliases = config.Aliases
+	}
+	if options.Tags == nil {
+		options.Tags = config.Tags
+	}
+
+	username, err := config.Bridges[0].Username()
+	if err != nil {
+		return nil, err
+	}
+
+	return New(config.Bridges[0].IPAddress, username, options)
+}
+
+// LoadConfig reads the configuration file, usually hue-cli.yaml.
+func LoadConfig(filename string) (*utils.ConfigFile, error) {
+	return utils.NewConfigFile(filename)
+}
+
+// ID returns the ID that the inventory of the bridge is cached under.
+func (c *Client) ID() string {
+	if c.Bridge.Info.Device.SerialNumber != "" {
+		return c.Bridge.Info.Device.SerialNumber
+	}
+
+	return strings.Replace(c.Bridge.IPAddress, ":", "_", -1)
+}
+
+// Config reads the configuration of the bridge.
+func (c *Client) Config() error {
+	return c.Bridge.GetConfig()
+}
+
+// Discover returns the gateways of the backend in the local network.
+func Discover(backend string) ([]hue.Bridge, error) {
+	b, err := LookupBackend(backend)
+	if err != nil {
+		return nil, err
+	}
+
+	if !b.Capabilities().Discovery {
+		return nil, &UnsupportedError{Backend: b.Name(), Feature: "discovery"}
+	}
+
+	return b.Discover()
+}
+
+// Capabilities returns the features that the gateway supports.
+func (c *Client) Capabilities() Capabilities {
+	return c.Backend.Capabilities()
+}
+
+// CreateUser registers a new user for the device on the gateway.
+func (c *Client) CreateUser(device string) (string, error) {
+	return c.Backend.CreateUser(c.Bridge, device)
+}
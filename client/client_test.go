@@ -0,0 +1,86 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"testing"
+
+	"github.com/nixpanic/hue-cli/huetest"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// testUser is the whitelisted user in testdata/bridge.yaml
+const testUser = "testuser"
+
+// startBridge starts a fake bridge with the state from testdata/bridge.yaml,
+// and returns it together with a Client that is logged in on it. The
+// inventory cache is stored in a temporary directory.
+func startBridge(t *testing.T) (*huetest.Server, *Client) {
+	state, err := huetest.LoadState("testdata/bridge.yaml")
+	if err != nil {
+		t.Fatalf("failed to load bridge state: %s", err)
+	}
+
+	server := huetest.NewServer(state)
+	t.Cleanup(server.Close)
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	c, err := New(server.Address(), testUser, Options{V2Address: server.TLSAddress()})
+	if err != nil {
+		t.Fatalf("failed to connect to the bridge: %s", err)
+	}
+
+	return server, c
+}
+
+func TestNewUnauthorized(t *testing.T) {
+	server, _ := startBridge(t)
+
+	_, err := New(server.Address(), "unknown", Options{})
+	if err == nil {
+		t.Fatal("expected an error for an unknown user")
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	server, _ := startBridge(t)
+
+	config := &utils.ConfigFile{
+		Bridges: []utils.BridgeConfig{{
+			IPAddress: server.Address(),
+			User:      testUser,
+		}},
+	}
+
+	c, err := NewFromConfig(config, Options{NoCache: true})
+	if err != nil {
+		t.Fatalf("failed to connect to the bridge: %s", err)
+	}
+	if c.Bridge.Username != testUser {
+		t.Errorf("logged in as %q instead of %q", c.Bridge.Username, testUser)
+	}
+
+	_, err = NewFromConfig(&utils.ConfigFile{}, Options{})
+	if err == nil {
+		t.Error("expected an error for a configuration without bridges")
+	}
+}
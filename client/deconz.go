@@ -0,0 +1,137 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	hue "github.com/collinux/GoHue"
+)
+
+// phosconDiscoveryURL lists the deCONZ gateways in the local network.
+var phosconDiscoveryURL = "https://phoscon.de/discover"
+
+// deconzBackend is a deCONZ gateway, like the Phoscon gateway or a RaspBee
+// or ConBee stick. Scenes belong to a group in deCONZ, and new users are
+// allowed after unlocking the gateway in the Phoscon app.
+type deconzBackend struct{}
+
+func init() {
+	registerBackend(deconzBackend{})
+}
+
+func (deconzBackend) Name() string {
+	return "deconz"
+}
+
+func (deconzBackend) Capabilities() Capabilities {
+	return Capabilities{
+		Discovery:   true,
+		FindLights:  true,
+		FindSensors: true,
+	}
+}
+
+func (deconzBackend) Discover() ([]hue.Bridge, error) {
+	resp, err := http.Get(phosconDiscoveryURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery through %s failed: %s", phosconDiscoveryURL, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	gateways := []struct {
+		IPAddress string `json:"internalipaddress"`
+		Port      int    `json:"internalport"`
+	}{}
+	err = json.Unmarshal(body, &gateways)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the response of %s: %w", phosconDiscoveryURL, err)
+	}
+
+	bridges := []hue.Bridge{}
+	for _, gw := range gateways {
+		address := gw.IPAddress
+		if gw.Port != 0 && gw.Port != 80 {
+			address = fmt.Sprintf("%s:%d", gw.IPAddress, gw.Port)
+		}
+		bridges = append(bridges, hue.Bridge{IPAddress: address})
+	}
+
+	return bridges, nil
+}
+
+// Connect uses the UPnP description of the gateway when it is available.
+// Not all setups serve it (like deCONZ in a container), the name and ID of
+// the gateway are then taken from the public part of its configuration.
+func (deconzBackend) Connect(address string) (*hue.Bridge, error) {
+	bridge, err := hue.NewBridge(address)
+	if err == nil {
+		return bridge, nil
+	}
+
+	bridge = &hue.Bridge{IPAddress: address}
+	body, _, cerr := bridge.Get("/api/config")
+	if cerr != nil {
+		return nil, err
+	}
+
+	var config struct {
+		Name     string `json:"name"`
+		BridgeID string `json:"bridgeid"`
+		ModelID  string `json:"modelid"`
+	}
+	cerr = json.Unmarshal(body, &config)
+	if cerr != nil {
+		return nil, err
+	}
+
+	bridge.Info.Device.FriendlyName = config.Name
+	bridge.Info.Device.SerialNumber = config.BridgeID
+	bridge.Info.Device.ModelNumber = config.ModelID
+
+	return bridge, nil
+}
+
+func (deconzBackend) CreateUser(bridge *hue.Bridge, device string) (string, error) {
+	user, err := bridge.CreateUser(device)
+	if err != nil && strings.Contains(err.Error(), "Error type 101: ") {
+		return "", fmt.Errorf("%w (unlock the gateway in the Phoscon app first)", err)
+	}
+
+	return user, err
+}
+
+func (deconzBackend) SensorKind(sensorType string) string {
+	return sensorKind(sensorType, "ZHA", "CLIP")
+}
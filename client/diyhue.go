@@ -0,0 +1,53 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	hue "github.com/collinux/GoHue"
+)
+
+// diyhueBackend is a diyHue emulator. It implements the API of a Philips
+// Hue bridge, but it is not registered at the discovery portal, and can
+// not search for new sensors.
+type diyhueBackend struct {
+	hueBackend
+}
+
+func init() {
+	registerBackend(diyhueBackend{})
+}
+
+func (diyhueBackend) Name() string {
+	return "diyhue"
+}
+
+func (diyhueBackend) Capabilities() Capabilities {
+	return Capabilities{
+		LinkButton: true,
+		FindLights: true,
+		Scenes:     true,
+		V2:         true,
+	}
+}
+
+func (b diyhueBackend) Discover() ([]hue.Bridge, error) {
+	return nil, &UnsupportedError{Backend: b.Name(), Feature: "discovery"}
+}
@@ -0,0 +1,156 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client_test
+
+import (
+	"fmt"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/huetest"
+)
+
+// exampleBridge starts a fake bridge with the state of the tests, a real
+// bridge is used the same way.
+func exampleBridge() (*huetest.Server, error) {
+	state, err := huetest.LoadState("testdata/bridge.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	return huetest.NewServer(state), nil
+}
+
+func ExampleNew() {
+	server, err := exampleBridge()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer server.Close()
+
+	// NoCache keeps the inventory of the bridge in memory only
+	c, err := client.New(server.Address(), "testuser", client.Options{NoCache: true})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	lights, err := c.Lights()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, light := range lights {
+		fmt.Printf("%d: %s (on=%t)\n", light.Index, light.Name, light.State.On)
+	}
+	// Output:
+	// 1: Desk Lamp (on=true)
+	// 2: Ceiling (on=false)
+}
+
+func ExampleClient_ToggleGroup() {
+	server, err := exampleBridge()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer server.Close()
+
+	c, err := client.New(server.Address(), "testuser", client.Options{NoCache: true})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// the Desk Lamp in the Office is on, so all lights are switched off
+	group, err := c.ToggleGroup("Office")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s was on: %t\n", group.Name, group.State.AnyOn)
+
+	group, err = c.Group("Office")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s is on: %t\n", group.Name, group.State.AnyOn)
+	// Output:
+	// Office was on: true
+	// Office is on: false
+}
+
+func ExampleClient_SelectLights() {
+	server, err := exampleBridge()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer server.Close()
+
+	c, err := client.New(server.Address(), "testuser", client.Options{NoCache: true})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// lights are selected by name, alias, tag or index
+	lights, err := c.SelectLights("Desk Lamp,2")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, light := range lights {
+		fmt.Println(light.Name)
+	}
+	// Output:
+	// Desk Lamp
+	// Ceiling
+}
+
+func ExampleClient_SetLightState() {
+	server, err := exampleBridge()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer server.Close()
+
+	c, err := client.New(server.Address(), "testuser", client.Options{NoCache: true})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	err = c.SetLightState(2, map[string]interface{}{"on": true, "bri": 127})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	state := server.Bridge.State().Lights["2"].State
+	fmt.Printf("on=%t bri=%d\n", state.On, state.Bri)
+	// Output:
+	// on=true bri=127
+}
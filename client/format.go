@@ -0,0 +1,294 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	hue "github.com/collinux/GoHue"
+
+	"github.com/nixpanic/hue-cli/clipv2"
+)
+
+// A BridgeSummary is the information about a bridge, in the format of the
+// JSON output of hue-cli.
+type BridgeSummary struct {
+	IPAddress        string `json:"ipaddress"`
+	DeviceType       string `json:"devicetype,omitempty"`
+	FriendlyName     string `json:"friendlyname,omitempty"`
+	Manufacturer     string `json:"manufacturer,omitempty"`
+	ModelDescription string `json:"modeldescription,omitempty"`
+	ModelName        string `json:"modelname,omitempty"`
+	ModelNumber      string `json:"modelnumber,omitempty"`
+	SerialNumber     string `json:"serialnumber,omitempty"`
+	UDN              string `json:"udn,omitempty"`
+}
+
+// NewBridgeSummary returns the summary of the bridge.
+func NewBridgeSummary(bridge *hue.Bridge) BridgeSummary {
+	return BridgeSummary{
+		IPAddress:        bridge.IPAddress,
+		DeviceType:       bridge.Info.Device.DeviceType,
+		FriendlyName:     bridge.Info.Device.FriendlyName,
+		Manufacturer:     bridge.Info.Device.Manufacturer,
+		ModelDescription: bridge.Info.Device.ModelDescription,
+		ModelName:        bridge.Info.Device.ModelName,
+		ModelNumber:      bridge.Info.Device.ModelNumber,
+		SerialNumber:     bridge.Info.Device.SerialNumber,
+		UDN:              bridge.Info.Device.UDN,
+	}
+}
+
+// FormatBridge returns the address and device information of the bridge as
+// text.
+func FormatBridge(bridge *hue.Bridge) string {
+	s := fmt.Sprintf("Bridge:\n"+
+		"\tIP-address: %s",
+		bridge.IPAddress)
+
+	if bridge.Info.Device.DeviceType != "" {
+		s += fmt.Sprintf("\n\tDevice Information:\n"+
+			"\t\tDeviceType: %s\n"+
+			"\t\tFriendlyName: %s\n"+
+			"\t\tManufacturer: %s\n"+
+			"\t\tManufacturerURL: %s\n"+
+			"\t\tModelDescription: %s\n"+
+			"\t\tModelName: %s\n"+
+			"\t\tModelNumber: %s\n"+
+			"\t\tModelURL: %s\n"+
+			"\t\tSerialNumber: %s\n"+
+			"\t\tUDN: %s",
+			bridge.Info.Device.DeviceType,
+			bridge.Info.Device.FriendlyName,
+			bridge.Info.Device.Manufacturer,
+			bridge.Info.Device.ManufacturerURL,
+			bridge.Info.Device.ModelDescription,
+			bridge.Info.Device.ModelName,
+			bridge.Info.Device.ModelNumber,
+			bridge.Info.Device.ModelURL,
+			bridge.Info.Device.SerialNumber,
+			bridge.Info.Device.UDN)
+	}
+
+	return s
+}
+
+// A LightSummary is the information about a light, in the format of the
+// JSON output of hue-cli.
+type LightSummary struct {
+	Name      string `json:"name"`
+	Index     int    `json:"index"`
+	Type      string `json:"type"`
+	ModelID   string `json:"modelid"`
+	UniqueID  string `json:"uniqueid"`
+	On        bool   `json:"on"`
+	Bri       int    `json:"bri"`
+	Reachable bool   `json:"reachable"`
+}
+
+// NewLightSummary returns the summary of the light.
+func NewLightSummary(light hue.Light) LightSummary {
+	return LightSummary{
+		Name:      light.Name,
+		Index:     light.Index,
+		Type:      light.Type,
+		ModelID:   light.ModelID,
+		UniqueID:  light.UniqueID,
+		On:        light.State.On,
+		Bri:       int(light.State.Bri),
+		Reachable: light.State.Reachable,
+	}
+}
+
+// FormatLight returns the name, index and model of the light as text.
+func FormatLight(light hue.Light) string {
+	s := fmt.Sprintf("Light: %s\n"+
+		"\tIndex: %d\n"+
+		"\tType: %s\n"+
+		"\tModel: %s\n"+
+		"\tUniqueID: %s",
+		light.Name, light.Index, light.Type, light.ModelID, light.UniqueID)
+
+	return s
+}
+
+// A GroupSummary is the information about a group, in the format of the
+// JSON output of hue-cli.
+type GroupSummary struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	AllOn  bool     `json:"all_on"`
+	AnyOn  bool     `json:"any_on"`
+	Lights []string `json:"lights"`
+}
+
+// NewGroupSummary returns the summary of the group.
+func NewGroupSummary(group hue.Group) GroupSummary {
+	summary := GroupSummary{
+		Name:   group.Name,
+		Type:   group.Type,
+		AllOn:  group.State.AllOn,
+		AnyOn:  group.State.AnyOn,
+		Lights: []string{},
+	}
+
+	for _, light := range group.Lights {
+		summary.Lights = append(summary.Lights, light.Name)
+	}
+
+	return summary
+}
+
+// FormatGroup returns the name, status and lights of the group as text.
+func FormatGroup(group hue.Group) string {
+	status := "lights are off"
+	if group.State.AllOn {
+		status = "lights are on"
+	} else if group.State.AnyOn {
+		status = "some lights are on"
+	}
+
+	s := fmt.Sprintf("Group: %s\n"+
+		"Status: %s\n"+
+		"Type: %s",
+		group.Name, status, group.Type)
+
+	if len(group.Lights) > 0 {
+		s += "\nLights:"
+	}
+	for _, light := range group.Lights {
+		s += fmt.Sprintf("\n\t- %s", light.Name)
+	}
+
+	return s
+}
+
+// A SensorSummary is the information about a sensor, in the format of the
+// JSON output of hue-cli.
+type SensorSummary struct {
+	Name     string `json:"name"`
+	Index    int    `json:"index"`
+	Type     string `json:"type"`
+	Kind     string `json:"kind"`
+	ModelID  string `json:"modelid"`
+	UniqueID string `json:"uniqueid"`
+}
+
+// NewSensorSummary returns the summary of the sensor, the kind is returned
+// by Client.SensorKind.
+func NewSensorSummary(sensor hue.Sensor, kind string) SensorSummary {
+	return SensorSummary{
+		Name:     sensor.Name,
+		Index:    sensor.Index,
+		Type:     sensor.Type,
+		Kind:     kind,
+		ModelID:  sensor.ModelID,
+		UniqueID: sensor.UniqueID,
+	}
+}
+
+// FormatSensor returns the name, index and model of the sensor as text.
+func FormatSensor(sensor hue.Sensor, kind string) string {
+	s := fmt.Sprintf("Sensor: %s\n"+
+		"\tIndex: %d\n"+
+		"\tType: %s\n"+
+		"\tKind: %s\n"+
+		"\tProductName: %s\n"+
+		"\tUniqueID: %s",
+		sensor.Name, sensor.Index, sensor.Type, kind, sensor.ModelID, sensor.UniqueID)
+
+	return s
+}
+
+// A DeviceSummary is the information about a device of the CLIP v2 API, in
+// the format of the JSON output of hue-cli.
+type DeviceSummary struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Room            string   `json:"room,omitempty"`
+	ProductName     string   `json:"productname"`
+	ModelID         string   `json:"modelid"`
+	Manufacturer    string   `json:"manufacturer"`
+	SoftwareVersion string   `json:"softwareversion"`
+	Services        []string `json:"services"`
+}
+
+// NewDeviceSummary returns the summary of the device in the room, which is
+// empty for devices that are not in a room.
+func NewDeviceSummary(device clipv2.Device, room string) DeviceSummary {
+	summary := DeviceSummary{
+		ID:              device.ID,
+		Name:            device.Metadata.Name,
+		Room:            room,
+		ProductName:     device.ProductData.ProductName,
+		ModelID:         device.ProductData.ModelID,
+		Manufacturer:    device.ProductData.ManufacturerName,
+		SoftwareVersion: device.ProductData.SoftwareVersion,
+		Services:        []string{},
+	}
+
+	for _, service := range device.Services {
+		summary.Services = append(summary.Services, service.RType)
+	}
+
+	return summary
+}
+
+// FormatDevice returns the name, product and services of the device as
+// text.
+func FormatDevice(device clipv2.Device, room string) string {
+	summary := NewDeviceSummary(device, room)
+
+	s := fmt.Sprintf("Device: %s\n"+
+		"\tID: %s\n", summary.Name, summary.ID)
+	if room != "" {
+		s += fmt.Sprintf("\tRoom: %s\n", room)
+	}
+	s += fmt.Sprintf("\tProductName: %s (%s)\n"+
+		"\tManufacturer: %s\n"+
+		"\tSoftwareVersion: %s\n"+
+		"\tServices: %s",
+		summary.ProductName, summary.ModelID, summary.Manufacturer,
+		summary.SoftwareVersion, strings.Join(summary.Services, ", "))
+
+	return s
+}
+
+// FormatEvent returns a line with the time, the resource and the changed
+// attributes of the event, sorted by name.
+func FormatEvent(event Event) string {
+	keys := []string{}
+	for key := range event.Changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	changes := []string{}
+	for _, key := range keys {
+		changes = append(changes, fmt.Sprintf("%s=%v", key, event.Changes[key]))
+	}
+
+	return fmt.Sprintf("%s %s %s (%s): %s",
+		event.Time.Format(time.RFC3339), event.Type, event.ID, event.Name, strings.Join(changes, " "))
+}
@@ -0,0 +1,139 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"fmt"
+	"strconv"
+
+	hue "github.com/collinux/GoHue"
+)
+
+// Groups returns all groups of the bridge.
+func (c *Client) Groups() ([]hue.Group, error) {
+	return c.Bridge.GetAllGroups()
+}
+
+// NewGroup creates a group with the lights (by index). The class is the
+// type of the room, like Bedroom or Kitchen.
+func (c *Client) NewGroup(name, class string, lights []int) (hue.Group, error) {
+	members := []hue.Light{}
+	for _, index := range lights {
+		light, err := c.Bridge.GetLightByIndex(index)
+		if err != nil {
+			return hue.Group{}, fmt.Errorf("failed to get light for index %d: %w", index, err)
+		}
+
+		members = append(members, light)
+	}
+
+	if c.dryRun {
+		// the group is looked up by name after it was created, that
+		// fails when it was not
+		return c.newDryRunGroup(name, class, members)
+	}
+
+	group, err := c.Bridge.NewGroup(name, class, members)
+	if err != nil {
+		return group, fmt.Errorf("failed to create group: %w", err)
+	}
+
+	return group, nil
+}
+
+// newDryRunGroup sends the request to create the group like
+// hue.Bridge.NewGroup, and returns the group without reading it back.
+func (c *Client) newDryRunGroup(name, class string, members []hue.Light) (hue.Group, error) {
+	lights := []string{}
+	for _, light := range members {
+		lights = append(lights, strconv.Itoa(light.Index))
+	}
+
+	uri := fmt.Sprintf("/api/%s/groups", c.Bridge.Username)
+	_, _, err := c.Bridge.Post(uri, map[string]interface{}{
+		"name":   name,
+		"class":  class,
+		"type":   "Room",
+		"lights": lights,
+	})
+	if err != nil {
+		return hue.Group{}, fmt.Errorf("failed to create group: %w", err)
+	}
+
+	return hue.Group{Name: name, Class: class, Type: "Room", Lights: members, Bridge: c.Bridge}, nil
+}
+
+// DeleteGroup deletes the group with the name or index.
+func (c *Client) DeleteGroup(name string) error {
+	group, err := c.Group(name)
+	if err != nil {
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+
+	err = group.Delete()
+	if err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	return nil
+}
+
+// ToggleGroup switches the lights of the group off when any of them is on,
+// and on otherwise. The group is returned with the state from before. When
+// the bridge serves the CLIP v2 API, the group is switched through its
+// grouped_light resource.
+func (c *Client) ToggleGroup(name string) (hue.Group, error) {
+	group, err := c.Group(name)
+	if err != nil {
+		return group, fmt.Errorf("could not find group %s: %w", name, err)
+	}
+
+	if v2, err := c.V2(); err == nil {
+		var ok bool
+		group, ok, err = c.toggleGroupedLight(v2, group)
+		if err != nil {
+			return group, fmt.Errorf("failed to toggle light-switch for group %s: %w", name, err)
+		} else if ok {
+			return group, nil
+		}
+	}
+
+	if group.State.AnyOn {
+		err = group.Off()
+	} else {
+		err = group.On()
+	}
+	if err != nil {
+		return group, fmt.Errorf("failed to toggle light-switch for group %s: %w", name, err)
+	}
+
+	return group, nil
+}
+
+// SetGroupState changes the state of all lights in the group with the
+// index, the state contains the parameters of the Hue API, like "on" and
+// "bri".
+func (c *Client) SetGroupState(index int, state map[string]interface{}) error {
+	uri := fmt.Sprintf("/api/%s/groups/%d/action", c.Bridge.Username, index)
+	_, _, err := c.Bridge.Put(uri, state)
+
+	return err
+}
@@ -0,0 +1,67 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"testing"
+)
+
+func TestToggleGroup(t *testing.T) {
+	server, c := startBridge(t)
+
+	// the Desk Lamp in the Office is on, so all lights go off
+	group, err := c.ToggleGroup("office")
+	if err != nil {
+		t.Fatalf("failed to toggle group: %s", err)
+	}
+	if !group.State.AnyOn {
+		t.Error("the state from before the toggle was not returned")
+	}
+
+	for _, index := range []string{"1", "2"} {
+		if server.Bridge.State().Lights[index].State.On {
+			t.Errorf("light %s was not switched off", index)
+		}
+	}
+}
+
+func TestNewAndDeleteGroup(t *testing.T) {
+	server, c := startBridge(t)
+
+	_, err := c.NewGroup("Living room", "Living room", []int{2})
+	if err != nil {
+		t.Fatalf("failed to create group: %s", err)
+	}
+
+	err = c.DeleteGroup("living room")
+	if err != nil {
+		t.Fatalf("failed to delete group: %s", err)
+	}
+
+	if groups := server.Bridge.State().Groups; len(groups) != 1 {
+		t.Errorf("expected only the Office group, got %d groups", len(groups))
+	}
+
+	_, err = c.NewGroup("Hallway", "Hallway", []int{7})
+	if err == nil {
+		t.Error("expected an error for an unknown light")
+	}
+}
@@ -0,0 +1,249 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// A Pin is the certificate of a bridge that is trusted. Bridges have a
+// self-signed certificate, which is issued to the bridge ID (its CN).
+type Pin struct {
+	BridgeID string
+	// Fingerprint is the SHA-256 fingerprint of the certificate, see
+	// Fingerprint
+	Fingerprint string
+}
+
+// A CertificateError is returned when the certificate of a bridge is not
+// issued to the bridge ID, or is not the certificate that was pinned.
+type CertificateError struct {
+	Address     string
+	BridgeID    string
+	CommonName  string
+	Fingerprint string
+	Pinned      string
+}
+
+func (e *CertificateError) Error() string {
+	if e.Pinned != "" && e.Fingerprint != e.Pinned {
+		return fmt.Sprintf("the certificate of bridge %s has changed, its fingerprint is %s instead of %s; "+
+			"remove the fingerprint from the configuration only when the bridge was replaced",
+			e.Address, e.Fingerprint, e.Pinned)
+	}
+
+	return fmt.Sprintf("the certificate of bridge %s is issued to %q, not to bridge ID %s", e.Address, e.CommonName, e.BridgeID)
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the certificate in hex.
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// A BridgeTransport sends the HTTPS requests to a bridge, after checking that
+// its certificate is the pinned one. When the Pin has no fingerprint yet,
+// the certificate is trusted on first use if it is issued to the bridge ID.
+// Other requests are passed to the Transport.
+//
+// GoHue sends the requests of the v1 API with http.DefaultTransport, set it
+// to a BridgeTransport with Upgrade to send them over HTTPS.
+type BridgeTransport struct {
+	// Transport sends the requests, a copy with a TLS configuration for
+	// the certificate of the bridge is used for HTTPS to the bridge
+	Transport *http.Transport
+
+	// Address is the <ip-address>[:<port>] of the v1 API of the bridge
+	Address string
+	// HTTPSAddress is where the bridge serves HTTPS, the default port
+	// of the Address when it is not set
+	HTTPSAddress string
+	// Upgrade sends the HTTP requests to the Address over HTTPS
+	Upgrade bool
+
+	// Pin is the trusted certificate, it is set on first use
+	Pin Pin
+	// OnPin is called when the certificate was trusted on first use, so
+	// that the Pin can be stored
+	OnPin func(pin Pin)
+
+	mu     sync.Mutex
+	bridge *http.Transport
+}
+
+func (bt *BridgeTransport) httpsAddress() string {
+	if bt.HTTPSAddress != "" {
+		return bt.HTTPSAddress
+	}
+
+	if host, _, err := net.SplitHostPort(bt.Address); err == nil {
+		return host
+	}
+
+	return bt.Address
+}
+
+func (bt *BridgeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch {
+	case bt.Upgrade && req.URL.Scheme == "http" && req.URL.Host == bt.Address:
+		transport, err := bt.bridgeTransport(req.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		r := req.Clone(req.Context())
+		r.URL.Scheme = "https"
+		r.URL.Host = bt.httpsAddress()
+		r.Host = ""
+
+		return transport.RoundTrip(r)
+	case req.URL.Scheme == "https" && req.URL.Host == bt.httpsAddress():
+		transport, err := bt.bridgeTransport(req.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		return transport.RoundTrip(req)
+	}
+
+	return bt.Transport.RoundTrip(req)
+}
+
+// bridgeTransport returns the transport that only accepts the pinned
+// certificate, the certificate is pinned first when needed.
+func (bt *BridgeTransport) bridgeTransport(ctx context.Context) (*http.Transport, error) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+
+	if bt.bridge != nil {
+		return bt.bridge, nil
+	}
+
+	if bt.Pin.Fingerprint == "" {
+		pin, err := bt.trustOnFirstUse(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		bt.Pin = pin
+		if bt.OnPin != nil {
+			bt.OnPin(pin)
+		}
+	}
+
+	pin := bt.Pin
+	bt.bridge = bt.Transport.Clone()
+	bt.bridge.TLSClientConfig = &tls.Config{
+		// the certificate is self-signed, it is verified by comparing
+		// it with the pinned one
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return bt.verify(cs, pin)
+		},
+	}
+
+	return bt.bridge, nil
+}
+
+func (bt *BridgeTransport) verify(cs tls.ConnectionState, pin Pin) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("the bridge did not present a certificate")
+	}
+
+	leaf := cs.PeerCertificates[0]
+	e := &CertificateError{
+		Address:     bt.httpsAddress(),
+		BridgeID:    pin.BridgeID,
+		CommonName:  leaf.Subject.CommonName,
+		Fingerprint: Fingerprint(leaf),
+		Pinned:      pin.Fingerprint,
+	}
+	if !strings.EqualFold(e.CommonName, pin.BridgeID) || e.Fingerprint != e.Pinned {
+		return e
+	}
+
+	return nil
+}
+
+// trustOnFirstUse reads the bridge ID from the configuration of the bridge,
+// and returns the pin for the certificate when it is issued to that ID.
+func (bt *BridgeTransport) trustOnFirstUse(ctx context.Context) (Pin, error) {
+	var leaf *x509.Certificate
+	transport := bt.Transport.Clone()
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("the bridge did not present a certificate")
+			}
+			leaf = cs.PeerCertificates[0]
+			return nil
+		},
+	}
+	defer transport.CloseIdleConnections()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+bt.httpsAddress()+"/api/config", nil)
+	if err != nil {
+		return Pin{}, err
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return Pin{}, fmt.Errorf("failed to connect to bridge %s over HTTPS: %w", bt.httpsAddress(), err)
+	}
+	defer resp.Body.Close()
+
+	var config struct {
+		BridgeID string `json:"bridgeid"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&config)
+	if err != nil {
+		return Pin{}, fmt.Errorf("failed to read the configuration of bridge %s: %w", bt.httpsAddress(), err)
+	}
+
+	bridgeID := bt.Pin.BridgeID
+	if bridgeID == "" {
+		bridgeID = config.BridgeID
+	} else if !strings.EqualFold(bridgeID, config.BridgeID) {
+		return Pin{}, fmt.Errorf("bridge %s has bridge ID %s instead of %s", bt.httpsAddress(), config.BridgeID, bridgeID)
+	}
+	if bridgeID == "" {
+		return Pin{}, fmt.Errorf("bridge %s did not report its bridge ID", bt.httpsAddress())
+	}
+
+	if !strings.EqualFold(leaf.Subject.CommonName, bridgeID) {
+		return Pin{}, &CertificateError{Address: bt.httpsAddress(), BridgeID: bridgeID, CommonName: leaf.Subject.CommonName}
+	}
+
+	return Pin{BridgeID: bridgeID, Fingerprint: Fingerprint(leaf)}, nil
+}
@@ -0,0 +1,113 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBridgeTransportTrustOnFirstUse(t *testing.T) {
+	server, _ := startBridge(t)
+
+	var pinned Pin
+	bt := &BridgeTransport{
+		Transport: &http.Transport{},
+		// nothing listens here, the request only succeeds over HTTPS
+		Address:      "127.0.0.1:1",
+		HTTPSAddress: server.TLSAddress(),
+		Upgrade:      true,
+		OnPin: func(pin Pin) {
+			pinned = pin
+		},
+	}
+
+	resp, err := (&http.Client{Transport: bt}).Get("http://127.0.0.1:1/api/config")
+	if err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+	resp.Body.Close()
+
+	expected := Pin{BridgeID: "001788FFFE23BFC2", Fingerprint: Fingerprint(server.TLS.Certificate())}
+	if pinned != expected || bt.Pin != expected {
+		t.Errorf("expected pin %+v, got %+v", expected, pinned)
+	}
+}
+
+func TestBridgeTransportChangedCertificate(t *testing.T) {
+	server, _ := startBridge(t)
+
+	bt := &BridgeTransport{
+		Transport:    &http.Transport{},
+		Address:      server.Address(),
+		HTTPSAddress: server.TLSAddress(),
+		Pin:          Pin{BridgeID: "001788FFFE23BFC2", Fingerprint: strings.Repeat("0", 64)},
+	}
+
+	_, err := (&http.Client{Transport: bt}).Get("https://" + server.TLSAddress() + "/api/config")
+
+	var ce *CertificateError
+	if !errors.As(err, &ce) || ce.Fingerprint != Fingerprint(server.TLS.Certificate()) {
+		t.Fatalf("expected a changed certificate, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "has changed") {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	// requests over HTTP are not affected
+	resp, err := (&http.Client{Transport: bt}).Get("http://" + server.Address() + "/api/config")
+	if err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+	resp.Body.Close()
+}
+
+func TestBridgeTransportOtherBridgeID(t *testing.T) {
+	server, _ := startBridge(t)
+
+	bt := &BridgeTransport{
+		Transport:    &http.Transport{},
+		Address:      server.Address(),
+		HTTPSAddress: server.TLSAddress(),
+		Pin:          Pin{BridgeID: "001788FFFE000000", Fingerprint: Fingerprint(server.TLS.Certificate())},
+	}
+
+	_, err := (&http.Client{Transport: bt}).Get("https://" + server.TLSAddress() + "/api/config")
+
+	var ce *CertificateError
+	if !errors.As(err, &ce) || ce.CommonName != "001788fffe23bfc2" {
+		t.Fatalf("expected a certificate for another bridge, got %v", err)
+	}
+
+	// on first use, the bridge reports its ID
+	bt = &BridgeTransport{
+		Transport:    &http.Transport{},
+		Address:      server.Address(),
+		HTTPSAddress: server.TLSAddress(),
+		Pin:          Pin{BridgeID: "001788FFFE000000"},
+	}
+	_, err = (&http.Client{Transport: bt}).Get("https://" + server.TLSAddress() + "/api/config")
+	if err == nil || !strings.Contains(err.Error(), "instead of 001788FFFE000000") {
+		t.Errorf("expected another bridge ID, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// Inventory returns the cached inventory of the bridge, it is read from the
+// bridge when it is not cached, expired or refresh is set. The returned bool
+// tells whether the inventory came from the cache. The Client keeps the
+// inventory after it was read, until ForgetInventory is called.
+func (c *Client) Inventory(refresh bool) (*utils.Inventory, bool, error) {
+	if !refresh {
+		c.inventoryLock.Lock()
+		inventory := c.inventory
+		c.inventoryLock.Unlock()
+
+		if inventory != nil && !inventory.Expired(c.cacheTTL) {
+			return inventory, true, nil
+		}
+	}
+
+	if !refresh && !c.noCache {
+		inventory, err := utils.LoadInventory(c.ID())
+		if err == nil && !inventory.Expired(c.cacheTTL) {
+			c.setInventory(inventory)
+			return inventory, true, nil
+		}
+	}
+
+	inventory, err := c.RefreshInventory()
+
+	return inventory, false, err
+}
+
+func (c *Client) setInventory(inventory *utils.Inventory) {
+	c.inventoryLock.Lock()
+	defer c.inventoryLock.Unlock()
+
+	c.inventory = inventory
+}
+
+// ForgetInventory drops the inventory that the Client keeps, it is read
+// again when it is needed. This is needed when a light, group, sensor or
+// scene was added, renamed or deleted.
+func (c *Client) ForgetInventory() {
+	c.setInventory(nil)
+}
+
+// RefreshInventory reads the inventory from the bridge, and caches it.
+func (c *Client) RefreshInventory() (*utils.Inventory, error) {
+	c.logger.Verbosef("reading the inventory of bridge %s\n", c.Bridge.IPAddress)
+
+	inventory := &utils.Inventory{
+		Bridge:  c.ID(),
+		Address: c.Bridge.IPAddress,
+		Updated: time.Now(),
+	}
+
+	var err error
+	for _, r := range []struct {
+		resource string
+		items    *[]utils.InventoryItem
+		skip     bool
+	}{
+		{"lights", &inventory.Lights, false},
+		{"groups", &inventory.Groups, false},
+		{"sensors", &inventory.Sensors, false},
+		{"scenes", &inventory.Scenes, !c.Capabilities().Scenes},
+	} {
+		if r.skip {
+			continue
+		}
+
+		*r.items, err = c.namedResources(r.resource)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.setInventory(inventory)
+
+	if !c.noCache {
+		err = inventory.Save()
+		if err != nil {
+			c.logger.Verbosef("failed to cache the inventory: %s\n", err)
+		}
+	}
+
+	return inventory, nil
+}
+
+// namedResources returns the ID and name of the resources of a type, like
+// "groups" or "scenes". GoHue reads more than the names for some types.
+func (c *Client) namedResources(resource string) ([]utils.InventoryItem, error) {
+	body, _, err := c.Bridge.Get(fmt.Sprintf("/api/%s/%s", c.Bridge.Username, resource))
+	if err != nil {
+		return nil, err
+	}
+
+	resources := map[string]struct {
+		Name     string `json:"name"`
+		UniqueID string `json:"uniqueid"`
+	}{}
+	err = json.Unmarshal(body, &resources)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []utils.InventoryItem{}
+	for id, r := range resources {
+		items = append(items, utils.InventoryItem{ID: id, Name: r.Name, UniqueID: r.UniqueID})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+
+	return items, nil
+}
@@ -0,0 +1,50 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"fmt"
+
+	hue "github.com/collinux/GoHue"
+)
+
+// Lights returns all lights of the bridge.
+func (c *Client) Lights() ([]hue.Light, error) {
+	return c.Bridge.GetAllLights()
+}
+
+// SetLightState changes the state of the light with the index, the state
+// contains the parameters of the Hue API, like "on" and "bri".
+func (c *Client) SetLightState(index int, state map[string]interface{}) error {
+	uri := fmt.Sprintf("/api/%s/lights/%d/state", c.Bridge.Username, index)
+	_, _, err := c.Bridge.Put(uri, state)
+
+	return err
+}
+
+// FindNewLights starts the search for new lights on the gateway.
+func (c *Client) FindNewLights() error {
+	if !c.Capabilities().FindLights {
+		return &UnsupportedError{Backend: c.Backend.Name(), Feature: "searching for new lights"}
+	}
+
+	return c.Bridge.FindNewLights()
+}
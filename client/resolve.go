@@ -0,0 +1,356 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	hue "github.com/collinux/GoHue"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// A NameError is returned when a name does not match exactly one light,
+// group, sensor or scene. Err is a *utils.AmbiguousNameError, a
+// *utils.UnknownNameError, a *utils.LocalNameError or a
+// *utils.BridgeNameError.
+type NameError struct {
+	Kind string
+	Name string
+	Err  error
+}
+
+func (e *NameError) Error() string {
+	var local *utils.LocalNameError
+	if errors.As(e.Err, &local) {
+		return fmt.Sprintf("%s: %s", e.Kind, e.Err)
+	}
+
+	var ambiguous *utils.AmbiguousNameError
+	if errors.As(e.Err, &ambiguous) {
+		return fmt.Sprintf("%s: %s", e.Kind, e.Err)
+	}
+
+	var bridge *utils.BridgeNameError
+	if errors.As(e.Err, &bridge) {
+		return fmt.Sprintf("%s: %s", e.Kind, e.Err)
+	}
+
+	var unknown *utils.UnknownNameError
+	if errors.As(e.Err, &unknown) && len(unknown.Suggestions) != 0 {
+		return fmt.Sprintf("no %s matches '%s', did you mean '%s'?", e.Kind, e.Name,
+			strings.Join(unknown.Suggestions, "' or '"))
+	}
+
+	return fmt.Sprintf("no %s matches '%s'", e.Kind, e.Name)
+}
+
+func (e *NameError) Unwrap() error {
+	return e.Err
+}
+
+// Ambiguous returns true when the name matches more than one item.
+func (e *NameError) Ambiguous() bool {
+	var ambiguous *utils.AmbiguousNameError
+	return errors.As(e.Err, &ambiguous)
+}
+
+// OtherBridge returns true when the name is an alias or tag of another
+// bridge.
+func (e *NameError) OtherBridge() bool {
+	var bridge *utils.BridgeNameError
+	return errors.As(e.Err, &bridge)
+}
+
+// IsNotFound returns true when the error tells that a light, group, sensor
+// or scene does not exist.
+func IsNotFound(err error) bool {
+	var ne *NameError
+	if errors.As(err, &ne) {
+		return !ne.Ambiguous() && !ne.OtherBridge()
+	}
+
+	// GoHue reports bridge error type 3 (resource not available), or
+	// that it could not find a light, group, ... by name
+	return strings.Contains(err.Error(), "Error type 3: ") ||
+		strings.Contains(err.Error(), "Unable to find")
+}
+
+// resolve finds the item with the name (or ID) in the inventory, and passes
+// it to get. When get returns false, the cached item is out of date (the
+// resource was renamed or deleted without hue-cli), and the inventory is read
+// from the bridge again. The same is done when the name does not match, or
+// matches more than one item.
+func (c *Client) resolve(kind, name string, items func(*utils.Inventory) []utils.InventoryItem, get func(utils.InventoryItem) (bool, error)) error {
+	refresh := false
+	for {
+		inventory, cached, err := c.Inventory(refresh)
+		if err != nil {
+			return err
+		}
+
+		item, err := c.resolveItem(kind, items(inventory), name)
+		var bridge *utils.BridgeNameError
+		if errors.As(err, &bridge) {
+			// reading the inventory again does not change the bridge
+			return &NameError{Kind: kind, Name: name, Err: err}
+		} else if err == nil {
+			current, err := get(item)
+			if err != nil && !(cached && IsNotFound(err)) {
+				return err
+			} else if current {
+				return nil
+			}
+		}
+
+		if !cached {
+			return &NameError{Kind: kind, Name: name, Err: err}
+		}
+		refresh = true
+	}
+}
+
+// resolveItem returns the item that an alias of the kind refers to, or else
+// the item with the name or ID.
+func (c *Client) resolveItem(kind string, items []utils.InventoryItem, name string) (utils.InventoryItem, error) {
+	item, ok, err := utils.ResolveAlias(c.aliases, c.ID(), kind, items, name)
+	if ok {
+		return item, err
+	}
+
+	return utils.ResolveItem(items, name)
+}
+
+// resolveTag returns the lights and groups of the tag with the name, ok is
+// false when there is no such tag.
+func (c *Client) resolveTag(name string) (lights, groups []utils.InventoryItem, ok bool, err error) {
+	if utils.FindTag(c.tags, name) == nil {
+		return nil, nil, false, nil
+	}
+
+	refresh := false
+	for {
+		inventory, cached, err := c.Inventory(refresh)
+		if err != nil {
+			return nil, nil, true, err
+		}
+
+		lights, groups, _, err = utils.ResolveTag(c.tags, c.ID(), inventory, name)
+		var bridge *utils.BridgeNameError
+		if err == nil {
+			return lights, groups, true, nil
+		} else if errors.As(err, &bridge) {
+			return nil, nil, true, &NameError{Kind: "tag", Name: name, Err: err}
+		} else if !cached {
+			var local *utils.LocalNameError
+			errors.As(err, &local)
+			return nil, nil, true, &NameError{Kind: local.Kind, Name: name, Err: err}
+		}
+		refresh = true
+	}
+}
+
+// Light returns the light with the name, alias or index.
+func (c *Client) Light(name string) (hue.Light, error) {
+	var light hue.Light
+	err := c.resolve("light", name, func(inventory *utils.Inventory) []utils.InventoryItem {
+		return inventory.Lights
+	}, func(item utils.InventoryItem) (bool, error) {
+		index, _ := strconv.Atoi(item.ID)
+
+		var err error
+		light, err = c.Bridge.GetLightByIndex(index)
+		if err != nil {
+			return false, err
+		}
+
+		return light.Name == item.Name && light.UniqueID == item.UniqueID, nil
+	})
+
+	return light, err
+}
+
+// Group returns the group with the name, alias or index.
+func (c *Client) Group(name string) (hue.Group, error) {
+	var group hue.Group
+	err := c.resolve("group", name, func(inventory *utils.Inventory) []utils.InventoryItem {
+		return inventory.Groups
+	}, func(item utils.InventoryItem) (bool, error) {
+		index, _ := strconv.Atoi(item.ID)
+
+		var err error
+		group, err = c.GroupByIndex(index)
+		if err != nil {
+			return false, err
+		}
+
+		return group.Name == item.Name, nil
+	})
+
+	return group, err
+}
+
+// Sensor returns the sensor with the name or index.
+func (c *Client) Sensor(name string) (hue.Sensor, error) {
+	var sensor hue.Sensor
+	err := c.resolve("sensor", name, func(inventory *utils.Inventory) []utils.InventoryItem {
+		return inventory.Sensors
+	}, func(item utils.InventoryItem) (bool, error) {
+		index, _ := strconv.Atoi(item.ID)
+
+		var err error
+		sensor, err = c.Bridge.GetSensorByIndex(index)
+		if err != nil {
+			return false, err
+		}
+
+		return sensor.Name == item.Name, nil
+	})
+
+	return sensor, err
+}
+
+// Scene returns the ID and name of the scene with the name or ID.
+func (c *Client) Scene(name string) (utils.InventoryItem, error) {
+	var scene utils.InventoryItem
+	err := c.resolve("scene", name, func(inventory *utils.Inventory) []utils.InventoryItem {
+		return inventory.Scenes
+	}, func(item utils.InventoryItem) (bool, error) {
+		body, _, err := c.Bridge.Get(fmt.Sprintf("/api/%s/scenes/%s", c.Bridge.Username, item.ID))
+		if err != nil {
+			return false, err
+		}
+
+		var s struct {
+			Name string `json:"name"`
+		}
+		err = json.Unmarshal(body, &s)
+		if err != nil {
+			return false, err
+		}
+
+		scene = item
+		return s.Name == item.Name, nil
+	})
+
+	return scene, err
+}
+
+// SelectLights returns the lights that match the selection, which is a comma
+// separated list of light names, aliases, tags and/or indexes. A tag selects
+// its lights and the lights of its groups. All lights are returned when the
+// selection is empty.
+func (c *Client) SelectLights(selection string) ([]hue.Light, error) {
+	if selection == "" {
+		return c.Bridge.GetAllLights()
+	}
+
+	selected := []hue.Light{}
+	seen := map[int]bool{}
+	add := func(lights ...hue.Light) {
+		for _, light := range lights {
+			if !seen[light.Index] {
+				seen[light.Index] = true
+				selected = append(selected, light)
+			}
+		}
+	}
+
+	for _, item := range strings.Split(selection, ",") {
+		name := strings.TrimSpace(item)
+
+		lights, groups, ok, err := c.resolveTag(name)
+		if err != nil {
+			return nil, err
+		} else if ok {
+			for _, item := range lights {
+				light, err := c.Light(item.ID)
+				if err != nil {
+					return nil, err
+				}
+				add(light)
+			}
+			for _, item := range groups {
+				index, _ := strconv.Atoi(item.ID)
+				group, err := c.GroupByIndex(index)
+				if err != nil {
+					return nil, err
+				}
+				add(group.Lights...)
+			}
+			continue
+		}
+
+		light, err := c.Light(name)
+		if err != nil {
+			return nil, err
+		}
+		add(light)
+	}
+
+	return selected, nil
+}
+
+// GroupByIndex reads a single group, GoHue only reads all groups at once.
+func (c *Client) GroupByIndex(index int) (hue.Group, error) {
+	group := hue.Group{}
+
+	body, _, err := c.Bridge.Get(fmt.Sprintf("/api/%s/groups/%d", c.Bridge.Username, index))
+	if err != nil {
+		return group, err
+	}
+
+	var g struct {
+		Name   string   `json:"name"`
+		Type   string   `json:"type"`
+		Lights []string `json:"lights"`
+		State  struct {
+			AllOn bool `json:"all_on"`
+			AnyOn bool `json:"any_on"`
+		} `json:"state"`
+	}
+	err = json.Unmarshal(body, &g)
+	if err != nil {
+		return group, err
+	}
+
+	group.Name = g.Name
+	group.Type = g.Type
+	group.Index = index
+	group.Bridge = c.Bridge
+	group.State.AllOn = g.State.AllOn
+	group.State.AnyOn = g.State.AnyOn
+
+	for _, id := range g.Lights {
+		i, _ := strconv.Atoi(id)
+		light, err := c.Bridge.GetLightByIndex(i)
+		if err != nil {
+			return group, err
+		}
+		group.Lights = append(group.Lights, light)
+	}
+
+	return group, nil
+}
@@ -0,0 +1,145 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+func TestLightCaseInsensitive(t *testing.T) {
+	_, c := startBridge(t)
+
+	light, err := c.Light("desk  lamp")
+	if err != nil {
+		t.Fatalf("failed to resolve light: %s", err)
+	}
+	if light.Index != 1 {
+		t.Errorf("resolved to light %d instead of 1", light.Index)
+	}
+}
+
+func TestGroupSuggestion(t *testing.T) {
+	_, c := startBridge(t)
+
+	_, err := c.Group("Ofice")
+
+	var ne *NameError
+	if !errors.As(err, &ne) || ne.Ambiguous() {
+		t.Fatalf("expected an unknown name, got %v", err)
+	}
+	if !IsNotFound(err) {
+		t.Errorf("%v is not reported as not found", err)
+	}
+	if err.Error() != "no group matches 'Ofice', did you mean 'Office'?" {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestLightRenamed(t *testing.T) {
+	_, c := startBridge(t)
+
+	// fill the cache before the light is renamed behind its back
+	_, err := c.Light("Ceiling")
+	if err != nil {
+		t.Fatalf("failed to resolve light: %s", err)
+	}
+
+	_, _, err = c.Bridge.Put("/api/"+testUser+"/lights/2", map[string]interface{}{"name": "Ceiling Lamp"})
+	if err != nil {
+		t.Fatalf("failed to rename light: %s", err)
+	}
+
+	light, err := c.Light("Ceiling Lamp")
+	if err != nil {
+		t.Fatalf("renamed light was not found: %s", err)
+	}
+	if light.Index != 2 {
+		t.Errorf("resolved to light %d instead of 2", light.Index)
+	}
+}
+
+func TestSelectLights(t *testing.T) {
+	_, c := startBridge(t)
+
+	lights, err := c.SelectLights("2, desk lamp")
+	if err != nil {
+		t.Fatalf("failed to select lights: %s", err)
+	}
+	if len(lights) != 2 || lights[0].Index != 2 || lights[1].Index != 1 {
+		t.Errorf("unexpected selection: %+v", lights)
+	}
+
+	_, err = c.SelectLights("Desk Lamp,Kitchen")
+	if !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestLightAlias(t *testing.T) {
+	_, c := startBridge(t)
+	c.aliases = []utils.Alias{
+		{Name: "standing-desk", Light: "00:17:88:01:00:00:00:01-0b"},
+		{Name: "work", Group: "1"},
+		{Name: "gone", Light: "00:17:88:01:00:00:00:99-0b"},
+	}
+
+	light, err := c.Light("Standing-Desk")
+	if err != nil {
+		t.Fatalf("failed to resolve the alias: %s", err)
+	}
+	if light.Index != 1 {
+		t.Errorf("resolved to light %d instead of 1", light.Index)
+	}
+
+	group, err := c.Group("work")
+	if err != nil || group.Name != "Office" {
+		t.Errorf("failed to resolve the group alias: %+v (%v)", group, err)
+	}
+
+	// the alias of a group is not a light
+	_, err = c.Light("work")
+	if !IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+
+	_, err = c.Light("gone")
+	if !IsNotFound(err) || err.Error() != "light: 'gone' refers to light 00:17:88:01:00:00:00:99-0b, which is not on the bridge" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSelectTag(t *testing.T) {
+	_, c := startBridge(t)
+	c.tags = []utils.Tag{
+		{Name: "demo-area", Lights: []string{"00:17:88:01:00:00:00:02-0b"}, Groups: []string{"1"}},
+	}
+
+	lights, err := c.SelectLights("demo-area, desk lamp")
+	if err != nil {
+		t.Fatalf("failed to select lights: %s", err)
+	}
+	if len(lights) != 2 || lights[0].Index != 2 || lights[1].Index != 1 {
+		t.Errorf("unexpected selection: %+v", lights)
+	}
+}
@@ -0,0 +1,58 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"fmt"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// RecallScene puts the lights of the group in the state of the scene. All
+// lights are used when the group is empty.
+func (c *Client) RecallScene(scene, group string) (utils.InventoryItem, error) {
+	if !c.Capabilities().Scenes {
+		return utils.InventoryItem{}, &UnsupportedError{Backend: c.Backend.Name(), Feature: "recalling scenes"}
+	}
+
+	item, err := c.Scene(scene)
+	if err != nil {
+		return item, err
+	}
+
+	// group 0 contains all lights
+	index := 0
+	if group != "" {
+		g, err := c.Group(group)
+		if err != nil {
+			return item, err
+		}
+		index = g.Index
+	}
+
+	uri := fmt.Sprintf("/api/%s/groups/%d/action", c.Bridge.Username, index)
+	_, _, err = c.Bridge.Put(uri, map[string]interface{}{"scene": item.ID})
+	if err != nil {
+		return item, fmt.Errorf("failed to recall scene %s: %w", item.Name, err)
+	}
+
+	return item, nil
+}
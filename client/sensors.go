@@ -0,0 +1,64 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	hue "github.com/collinux/GoHue"
+)
+
+// Sensors returns all sensors of the bridge.
+func (c *Client) Sensors() ([]hue.Sensor, error) {
+	return c.Bridge.GetAllSensors()
+}
+
+// RenameSensor sets the name of the sensor with the name or index.
+func (c *Client) RenameSensor(name, newName string) error {
+	sensor, err := c.Sensor(name)
+	if err != nil {
+		return err
+	}
+
+	return sensor.SetName(newName)
+}
+
+// FindNewSensors starts the search for new sensors on the gateway.
+func (c *Client) FindNewSensors() error {
+	if !c.Capabilities().FindSensors {
+		return &UnsupportedError{Backend: c.Backend.Name(), Feature: "searching for new sensors"}
+	}
+
+	return c.Bridge.FindNewSensors()
+}
+
+// NewSensors returns the sensors that were found by the last search.
+func (c *Client) NewSensors() ([]hue.Sensor, error) {
+	if !c.Capabilities().FindSensors {
+		return nil, &UnsupportedError{Backend: c.Backend.Name(), Feature: "searching for new sensors"}
+	}
+
+	return c.Bridge.GetNewSensors()
+}
+
+// SensorKind returns the kind of the sensor, like "presence" or "switch",
+// which does not depend on the type of gateway.
+func (c *Client) SensorKind(sensor hue.Sensor) string {
+	return c.Backend.SensorKind(sensor.Type)
+}
@@ -0,0 +1,179 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	hue "github.com/collinux/GoHue"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// Snapshot returns the on/off state, brightness and color of the lights.
+func (c *Client) Snapshot(lights []hue.Light) *utils.Snapshot {
+	snapshot := &utils.Snapshot{
+		Bridge:   c.Bridge.IPAddress,
+		BridgeID: c.ID(),
+	}
+
+	for _, light := range lights {
+		ls := utils.LightSnapshot{
+			Index:     light.Index,
+			UniqueID:  light.UniqueID,
+			Name:      light.Name,
+			On:        light.State.On,
+			Bri:       int(light.State.Bri),
+			Effect:    light.State.Effect,
+			ColorMode: light.State.ColorMode,
+		}
+
+		switch light.State.ColorMode {
+		case "xy":
+			// the bridge uses 4 decimals for xy coordinates
+			ls.XY = []float64{
+				math.Round(float64(light.State.XY[0])*10000) / 10000,
+				math.Round(float64(light.State.XY[1])*10000) / 10000,
+			}
+		case "ct":
+			ls.CT = int(light.State.CT)
+		case "hs":
+			ls.Hue = int(light.State.Hue)
+			ls.Sat = int(light.State.Saturation)
+		}
+
+		snapshot.Lights = append(snapshot.Lights, ls)
+	}
+
+	return snapshot
+}
+
+// snapshotLightState returns the body for a PUT on the state of a light so
+// that the light gets the state from the snapshot. Lights that were off are
+// only switched off, setting the color of a light that is off is not
+// possible.
+func snapshotLightState(ls utils.LightSnapshot, transition int) map[string]interface{} {
+	state := map[string]interface{}{
+		"on": ls.On,
+	}
+
+	if transition >= 0 {
+		state["transitiontime"] = transition
+	}
+
+	if !ls.On {
+		return state
+	}
+
+	if ls.Bri > 0 {
+		state["bri"] = ls.Bri
+	}
+
+	// lights without support for effects do not report one
+	if ls.Effect != "" {
+		state["effect"] = ls.Effect
+	}
+
+	switch ls.ColorMode {
+	case "xy":
+		if len(ls.XY) == 2 {
+			state["xy"] = ls.XY
+		}
+	case "ct":
+		state["ct"] = ls.CT
+	case "hs":
+		state["hue"] = ls.Hue
+		state["sat"] = ls.Sat
+	}
+
+	return state
+}
+
+// A SnapshotBridgeError is returned when a snapshot of another bridge is
+// restored.
+type SnapshotBridgeError struct {
+	Snapshot string
+	Bridge   string
+}
+
+func (e *SnapshotBridgeError) Error() string {
+	return fmt.Sprintf("the snapshot was taken of bridge %s, not of bridge %s", e.Snapshot, e.Bridge)
+}
+
+// checkSnapshotBridge returns a SnapshotBridgeError when the snapshot is of
+// another bridge. Snapshots without a BridgeID are compared by address.
+func (c *Client) checkSnapshotBridge(snapshot *utils.Snapshot) error {
+	if snapshot.BridgeID != "" && snapshot.BridgeID != c.ID() {
+		return &SnapshotBridgeError{Snapshot: snapshot.BridgeID, Bridge: c.ID()}
+	} else if snapshot.BridgeID == "" && snapshot.Bridge != "" && snapshot.Bridge != c.Bridge.IPAddress {
+		return &SnapshotBridgeError{Snapshot: snapshot.Bridge, Bridge: c.Bridge.IPAddress}
+	}
+
+	return nil
+}
+
+// RestoreSnapshot puts the lights in the state of the snapshot, with the
+// transition time in multiples of 100ms (-1 for the default of the bridge).
+// The states that were sent are returned by the index of the light. A
+// snapshot of another bridge is refused with a *SnapshotBridgeError, clear
+// its Bridge and BridgeID to restore it on this bridge anyway.
+func (c *Client) RestoreSnapshot(snapshot *utils.Snapshot, transition int) (map[int]map[string]interface{}, error) {
+	err := c.checkSnapshotBridge(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	lights, err := c.Bridge.GetAllLights()
+	if err != nil {
+		return nil, err
+	}
+
+	states := map[int]map[string]interface{}{}
+	for _, ls := range snapshot.Lights {
+		// the index of a light can change when lights are removed and
+		// added again, the UniqueID does not
+		index := -1
+		for _, light := range lights {
+			if ls.UniqueID != "" && light.UniqueID == ls.UniqueID {
+				index = light.Index
+				break
+			} else if ls.UniqueID == "" && light.Index == ls.Index {
+				index = light.Index
+				break
+			}
+		}
+
+		if index == -1 {
+			return states, errors.New(fmt.Sprintf("light %s (%s) is not available on the bridge", ls.Name, ls.UniqueID))
+		}
+
+		state := snapshotLightState(ls, transition)
+		err = c.SetLightState(index, state)
+		if err != nil {
+			return states, fmt.Errorf("failed to restore the state of light %s: %w", ls.Name, err)
+		}
+		states[index] = state
+	}
+
+	return states, nil
+}
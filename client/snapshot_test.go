@@ -0,0 +1,83 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	server, c := startBridge(t)
+
+	lights, err := c.SelectLights("")
+	if err != nil {
+		t.Fatalf("failed to read lights: %s", err)
+	}
+	snapshot := c.Snapshot(lights)
+
+	_, err = c.RecallScene("relax", "")
+	if err != nil {
+		t.Fatalf("failed to recall scene: %s", err)
+	}
+
+	states, err := c.RestoreSnapshot(snapshot, 0)
+	if err != nil {
+		t.Fatalf("failed to restore snapshot: %s", err)
+	}
+	if len(states) != 2 {
+		t.Errorf("expected the state of 2 lights, got %v", states)
+	}
+
+	// the Ceiling was off, only the Desk Lamp gets its color back
+	state := server.Bridge.State()
+	if desk := state.Lights["1"].State; !desk.On || desk.Bri != 200 || desk.XY != [2]float64{0.4573, 0.41} {
+		t.Errorf("Desk Lamp was not restored: %+v", desk)
+	}
+	if state.Lights["2"].State.On {
+		t.Error("Ceiling was not switched off")
+	}
+}
+
+func TestSnapshotOtherBridge(t *testing.T) {
+	_, c := startBridge(t)
+
+	lights, err := c.SelectLights("")
+	if err != nil {
+		t.Fatalf("failed to read lights: %s", err)
+	}
+	snapshot := c.Snapshot(lights)
+	snapshot.BridgeID = "001788FFFE000000"
+
+	_, err = c.RestoreSnapshot(snapshot, 0)
+	var bridgeErr *SnapshotBridgeError
+	if !errors.As(err, &bridgeErr) {
+		t.Errorf("expected a SnapshotBridgeError, got %v", err)
+	}
+
+	// without the bridge, the lights are matched on this bridge
+	snapshot.Bridge = ""
+	snapshot.BridgeID = ""
+	_, err = c.RestoreSnapshot(snapshot, 0)
+	if err != nil {
+		t.Errorf("failed to restore snapshot: %s", err)
+	}
+}
@@ -0,0 +1,496 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	hue "github.com/collinux/GoHue"
+
+	"github.com/nixpanic/hue-cli/clipv2"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// V2 returns the client for the CLIP v2 API of the bridge. The bridge is
+// asked once whether it serves the v2 API, an *UnsupportedError is returned
+// when it does not.
+func (c *Client) V2() (*clipv2.Client, error) {
+	if c.v2 != nil || c.v2Err != nil {
+		return c.v2, c.v2Err
+	}
+
+	unsupported := &UnsupportedError{Backend: c.Backend.Name(), Feature: "the CLIP v2 API"}
+	if !c.Capabilities().V2 {
+		c.v2Err = unsupported
+		return nil, c.v2Err
+	}
+
+	address := c.v2Address
+	if address == "" {
+		// the v2 API is served over HTTPS on the default port
+		address = c.Bridge.IPAddress
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			address = host
+		}
+	}
+
+	v2 := clipv2.New(address, c.Bridge.Username, clipv2.Options{HTTPClient: c.httpClient, StreamClient: c.streamClient})
+	_, err := v2.Bridge()
+
+	var ce *clipv2.Error
+	var certificate *CertificateError
+	switch {
+	case err == nil:
+		c.v2 = v2
+	case errors.As(err, &ce) && (ce.StatusCode == http.StatusUnauthorized || ce.StatusCode == http.StatusForbidden):
+		c.v2Err = err
+	case errors.As(err, &certificate):
+		c.v2Err = err
+	default:
+		c.logger.Verbosef("the CLIP v2 API is not available at %s: %s\n", address, err)
+		c.v2Err = unsupported
+	}
+
+	return c.v2, c.v2Err
+}
+
+// V2Lights returns the lights of the v2 API that match the selection, which
+// is a comma separated list of light names and/or indexes like for
+// SelectLights.
+func (c *Client) V2Lights(selection string) ([]clipv2.Light, error) {
+	v2, err := c.V2()
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := c.SelectLights(selection)
+	if err != nil {
+		return nil, err
+	}
+
+	lights, err := v2.Lights()
+	if err != nil {
+		return nil, err
+	}
+
+	byIndex := map[int]clipv2.Light{}
+	for _, light := range lights {
+		byIndex[light.V1Index()] = light
+	}
+
+	result := []clipv2.Light{}
+	for _, light := range selected {
+		l, ok := byIndex[light.Index]
+		if !ok {
+			return nil, fmt.Errorf("light %s is not available in the CLIP v2 API", light.Name)
+		}
+		result = append(result, l)
+	}
+
+	return result, nil
+}
+
+// V2GroupLights returns the lights of the room or zone that is the group
+// with the name or index in the v1 API.
+func (c *Client) V2GroupLights(name string) ([]clipv2.Light, error) {
+	v2, err := c.V2()
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := c.Group(name)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := v2.Groups()
+	if err != nil {
+		return nil, err
+	}
+
+	var children []clipv2.ResourceIdentifier
+	found := false
+	for _, g := range groups {
+		if g.IDv1 == fmt.Sprintf("/groups/%d", group.Index) {
+			children = g.Children
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("group %s is not a room or zone in the CLIP v2 API", group.Name)
+	}
+
+	lights, err := v2.Lights()
+	if err != nil {
+		return nil, err
+	}
+
+	return childLights(children, lights), nil
+}
+
+// childLights returns the lights that are children of a room or zone. The
+// children of a room are devices, a device owns its lights.
+func childLights(children []clipv2.ResourceIdentifier, lights []clipv2.Light) []clipv2.Light {
+	result := []clipv2.Light{}
+	for _, light := range lights {
+		for _, child := range children {
+			if (child.RType == "light" && child.RID == light.ID) ||
+				(child.RType == "device" && child.RID == light.Owner.RID) {
+				result = append(result, light)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// ListLights returns all lights of the bridge. When the bridge serves the
+// CLIP v2 API, they are read from its light, device and zigbee_connectivity
+// resources, the v1 API is only used for bridges that do not. The v2 API
+// does not have the unique IDs of the lights, they come from the inventory.
+func (c *Client) ListLights() ([]hue.Light, error) {
+	v2, err := c.V2()
+	if err != nil {
+		c.logger.Verbosef("listing the lights with the v1 API: %s\n", err)
+		return c.Lights()
+	}
+
+	lights, err := v2.Lights()
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := v2.Devices()
+	if err != nil {
+		return nil, err
+	}
+
+	connectivity, err := v2.ZigbeeConnectivity()
+	if err != nil {
+		return nil, err
+	}
+
+	inventory, _, err := c.Inventory(false)
+	if err != nil {
+		return nil, err
+	}
+
+	products := map[string]clipv2.ProductData{}
+	for _, device := range devices {
+		products[device.ID] = device.ProductData
+	}
+
+	connected := map[string]bool{}
+	for _, z := range connectivity {
+		connected[z.Owner.RID] = z.Connected()
+	}
+
+	uniqueIDs := map[string]string{}
+	for _, item := range inventory.Lights {
+		uniqueIDs[item.ID] = item.UniqueID
+	}
+
+	result := []hue.Light{}
+	for _, light := range lights {
+		l := hue.Light{
+			Name:     light.Metadata.Name,
+			Index:    light.V1Index(),
+			Type:     products[light.Owner.RID].ProductName,
+			ModelID:  products[light.Owner.RID].ModelID,
+			UniqueID: uniqueIDs[strconv.Itoa(light.V1Index())],
+			Bridge:   c.Bridge,
+		}
+		l.State.On = light.On.On
+		if light.Dimming != nil {
+			l.State.Bri = uint8(math.Round(light.Dimming.Brightness * 254 / 100))
+		}
+		// devices that do not use zigbee are reachable
+		reachable, ok := connected[light.Owner.RID]
+		l.State.Reachable = reachable || !ok
+
+		result = append(result, l)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Index < result[j].Index
+	})
+
+	return result, nil
+}
+
+// ListGroups returns all groups of the bridge. When the bridge serves the
+// CLIP v2 API, they are read from its room, zone, grouped_light and light
+// resources, the v1 API is only used for bridges that do not. Groups that
+// are not a room or zone are only listed by the v1 API.
+func (c *Client) ListGroups() ([]hue.Group, error) {
+	v2, err := c.V2()
+	if err != nil {
+		c.logger.Verbosef("listing the groups with the v1 API: %s\n", err)
+		return c.Groups()
+	}
+
+	groups, err := v2.Groups()
+	if err != nil {
+		return nil, err
+	}
+
+	groupedLights, err := v2.GroupedLights()
+	if err != nil {
+		return nil, err
+	}
+
+	lights, err := v2.Lights()
+	if err != nil {
+		return nil, err
+	}
+
+	on := map[string]bool{}
+	for _, groupedLight := range groupedLights {
+		on[groupedLight.ID] = groupedLight.On.On
+	}
+
+	result := []hue.Group{}
+	for _, group := range groups {
+		g := hue.Group{
+			Name:   group.Metadata.Name,
+			Type:   strings.ToUpper(group.Type[:1]) + group.Type[1:],
+			Index:  group.V1Index(),
+			Lights: []hue.Light{},
+			Bridge: c.Bridge,
+		}
+		for _, service := range group.Services {
+			if service.RType == "grouped_light" {
+				g.State.AnyOn = on[service.RID]
+			}
+		}
+
+		members := childLights(group.Children, lights)
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].V1Index() < members[j].V1Index()
+		})
+		g.State.AllOn = len(members) != 0
+		for _, light := range members {
+			g.Lights = append(g.Lights, hue.Light{Name: light.Metadata.Name, Index: light.V1Index(), Bridge: c.Bridge})
+			g.State.AllOn = g.State.AllOn && light.On.On
+		}
+
+		result = append(result, g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Index < result[j].Index
+	})
+
+	return result, nil
+}
+
+// toggleGroupedLight switches the lights of the group through its
+// grouped_light resource, and returns the group with the state from before.
+// ok is false when the group has no grouped_light.
+func (c *Client) toggleGroupedLight(v2 *clipv2.Client, group hue.Group) (hue.Group, bool, error) {
+	groupedLights, err := v2.GroupedLights()
+	if err != nil {
+		return group, true, err
+	}
+
+	for _, groupedLight := range groupedLights {
+		if groupedLight.IDv1 != fmt.Sprintf("/groups/%d", group.Index) {
+			continue
+		}
+
+		group.State.AnyOn = groupedLight.On.On
+		err = v2.UpdateGroupedLight(groupedLight.ID, clipv2.LightUpdate{
+			On: &clipv2.On{On: !groupedLight.On.On},
+		})
+
+		return group, true, err
+	}
+
+	return group, false, nil
+}
+
+// SetLightOn switches the light on or off.
+func (c *Client) SetLightOn(light clipv2.Light, on bool) error {
+	v2, err := c.V2()
+	if err != nil {
+		return err
+	}
+
+	return v2.UpdateLight(light.ID, clipv2.LightUpdate{On: &clipv2.On{On: on}})
+}
+
+// SetEffect starts the effect (like "candle") on the light, "no_effect"
+// stops it.
+func (c *Client) SetEffect(light clipv2.Light, effect string) error {
+	v2, err := c.V2()
+	if err != nil {
+		return err
+	}
+
+	return v2.UpdateLight(light.ID, clipv2.LightUpdate{
+		On:      &clipv2.On{On: true},
+		Effects: &clipv2.Effects{Effect: effect},
+	})
+}
+
+// SetGradient sets the colors of a gradient lightstrip.
+func (c *Client) SetGradient(light clipv2.Light, colors []clipv2.XY) error {
+	v2, err := c.V2()
+	if err != nil {
+		return err
+	}
+
+	gradient := &clipv2.Gradient{}
+	for _, xy := range colors {
+		gradient.Points = append(gradient.Points, clipv2.GradientPoint{Color: clipv2.Color{XY: xy}})
+	}
+
+	return v2.UpdateLight(light.ID, clipv2.LightUpdate{
+		On:       &clipv2.On{On: true},
+		Gradient: gradient,
+	})
+}
+
+// RecallDynamicScene recalls the scene with the name or ID, and lets the
+// lights cycle through the colors of its palette.
+func (c *Client) RecallDynamicScene(name string) (utils.InventoryItem, error) {
+	v2, err := c.V2()
+	if err != nil {
+		return utils.InventoryItem{}, err
+	}
+
+	item, err := c.Scene(name)
+	if err != nil {
+		return item, err
+	}
+
+	scenes, err := v2.Scenes()
+	if err != nil {
+		return item, err
+	}
+
+	for _, scene := range scenes {
+		if scene.IDv1 != "/scenes/"+item.ID {
+			continue
+		}
+
+		if !scene.Dynamic() {
+			return item, fmt.Errorf("scene %s has no palette, it can not be recalled dynamically", item.Name)
+		}
+
+		err = v2.RecallScene(scene.ID, "dynamic_palette")
+		if err != nil {
+			return item, fmt.Errorf("failed to recall scene %s: %w", item.Name, err)
+		}
+
+		return item, nil
+	}
+
+	return item, fmt.Errorf("scene %s is not available in the CLIP v2 API", item.Name)
+}
+
+// SmartScene returns the smart scene with the name or ID. Smart scenes are
+// not in the inventory, they only exist in the v2 API.
+func (c *Client) SmartScene(name string) (clipv2.SmartScene, error) {
+	v2, err := c.V2()
+	if err != nil {
+		return clipv2.SmartScene{}, err
+	}
+
+	smartScenes, err := v2.SmartScenes()
+	if err != nil {
+		return clipv2.SmartScene{}, err
+	}
+
+	items := []utils.InventoryItem{}
+	for _, s := range smartScenes {
+		items = append(items, utils.InventoryItem{ID: s.ID, Name: s.Metadata.Name})
+	}
+
+	item, err := utils.ResolveItem(items, name)
+	if err != nil {
+		return clipv2.SmartScene{}, &NameError{Kind: "smart scene", Name: name, Err: err}
+	}
+
+	for _, s := range smartScenes {
+		if s.ID == item.ID {
+			return s, nil
+		}
+	}
+
+	return clipv2.SmartScene{}, &NameError{Kind: "smart scene", Name: name}
+}
+
+// RecallSmartScene activates the smart scene, or deactivates it when
+// activate is false.
+func (c *Client) RecallSmartScene(smartScene clipv2.SmartScene, activate bool) error {
+	v2, err := c.V2()
+	if err != nil {
+		return err
+	}
+
+	action := "activate"
+	if !activate {
+		action = "deactivate"
+	}
+
+	return v2.RecallSmartScene(smartScene.ID, action)
+}
+
+// Devices returns the devices of the v2 API, together with the names of
+// the rooms they are in by the ID of the device.
+func (c *Client) Devices() ([]clipv2.Device, map[string]string, error) {
+	v2, err := c.V2()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	devices, err := v2.Devices()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups, err := v2.Groups()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rooms := map[string]string{}
+	for _, group := range groups {
+		if group.Type != "room" {
+			continue
+		}
+		for _, child := range group.Children {
+			if child.RType == "device" {
+				rooms[child.RID] = group.Metadata.Name
+			}
+		}
+	}
+
+	return devices, rooms, nil
+}
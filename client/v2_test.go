@@ -0,0 +1,195 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/clipv2"
+	"github.com/nixpanic/hue-cli/huetest"
+)
+
+func TestV2Lights(t *testing.T) {
+	_, c := startBridge(t)
+
+	lights, err := c.V2Lights("desk lamp,2")
+	if err != nil {
+		t.Fatalf("failed to select lights: %s", err)
+	}
+
+	if len(lights) != 2 || lights[0].ID != huetest.V2ID("light", "1") || lights[1].V1Index() != 2 {
+		t.Errorf("unexpected lights: %+v", lights)
+	}
+}
+
+func TestV2GroupLights(t *testing.T) {
+	_, c := startBridge(t)
+
+	// the children of the Office room are the devices of the lights
+	lights, err := c.V2GroupLights("office")
+	if err != nil {
+		t.Fatalf("failed to get the lights of the group: %s", err)
+	}
+
+	if len(lights) != 2 {
+		t.Errorf("expected 2 lights, got %+v", lights)
+	}
+}
+
+func TestListLights(t *testing.T) {
+	server, c := startBridge(t)
+
+	lights, err := c.ListLights()
+	if err != nil {
+		t.Fatalf("failed to list the lights: %s", err)
+	}
+
+	if len(lights) != 2 {
+		t.Fatalf("expected 2 lights, got %+v", lights)
+	}
+	light := lights[1]
+	if light.Name != "Ceiling" || light.Index != 2 || light.Type != "Color temperature light" ||
+		light.ModelID != "LTW001" || light.UniqueID != "00:17:88:01:00:00:00:02-0b" ||
+		light.State.On || light.State.Bri != 100 || !light.State.Reachable {
+		t.Errorf("unexpected light: %+v", light)
+	}
+
+	// without the v2 API the lights are read with the v1 API
+	c, err = New(server.Address(), testUser, Options{V2Address: server.Address()})
+	if err != nil {
+		t.Fatalf("failed to connect to the bridge: %s", err)
+	}
+
+	lights, err = c.ListLights()
+	if err != nil {
+		t.Fatalf("failed to list the lights: %s", err)
+	}
+	if len(lights) != 2 || lights[1].Name != "Ceiling" {
+		t.Errorf("unexpected lights: %+v", lights)
+	}
+}
+
+func TestListGroups(t *testing.T) {
+	_, c := startBridge(t)
+
+	groups, err := c.ListGroups()
+	if err != nil {
+		t.Fatalf("failed to list the groups: %s", err)
+	}
+
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %+v", groups)
+	}
+	group := groups[0]
+	if group.Name != "Office" || group.Index != 1 || group.Type != "Room" ||
+		!group.State.AnyOn || group.State.AllOn || len(group.Lights) != 2 || group.Lights[1].Name != "Ceiling" {
+		t.Errorf("unexpected group: %+v", group)
+	}
+}
+
+func TestSetEffect(t *testing.T) {
+	server, c := startBridge(t)
+
+	lights, err := c.V2Lights("Desk Lamp")
+	if err != nil {
+		t.Fatalf("failed to select lights: %s", err)
+	}
+	if !lights[0].SupportsEffect("candle") || lights[0].SupportsEffect("prism") {
+		t.Errorf("unexpected effects: %+v", lights[0].Effects)
+	}
+
+	err = c.SetEffect(lights[0], "candle")
+	if err != nil {
+		t.Fatalf("failed to set the effect: %s", err)
+	}
+	if effect := server.Bridge.State().V2.Lights["1"].Effect; effect != "candle" {
+		t.Errorf("expected the candle effect, got %q", effect)
+	}
+
+	var ce *clipv2.Error
+	err = c.SetEffect(lights[0], "prism")
+	if !errors.As(err, &ce) || ce.StatusCode != 400 {
+		t.Errorf("expected the bridge to reject the effect, got %v", err)
+	}
+}
+
+func TestRecallDynamicScene(t *testing.T) {
+	server, c := startBridge(t)
+
+	scene, err := c.RecallDynamicScene("relax")
+	if err != nil {
+		t.Fatalf("failed to recall the scene: %s", err)
+	}
+
+	if scene.Name != "Relax" || server.Bridge.State().Lights["2"].State.Bri != 144 {
+		t.Errorf("scene %s was not recalled", scene.Name)
+	}
+}
+
+func TestSmartScene(t *testing.T) {
+	server, c := startBridge(t)
+
+	smartScene, err := c.SmartScene("natural light")
+	if err != nil {
+		t.Fatalf("failed to find the smart scene: %s", err)
+	}
+
+	err = c.RecallSmartScene(smartScene, true)
+	if err != nil {
+		t.Fatalf("failed to activate the smart scene: %s", err)
+	}
+	if state := server.Bridge.State().V2.SmartScenes["natural"].State; state != "active" {
+		t.Errorf("smart scene is %s", state)
+	}
+
+	_, err = c.SmartScene("sunset")
+	if !IsNotFound(err) {
+		t.Errorf("expected an unknown smart scene, got %v", err)
+	}
+}
+
+func TestV2Unsupported(t *testing.T) {
+	server, _ := startBridge(t)
+
+	// the HTTP server does not speak TLS, like a bridge with old firmware
+	// that does not serve the v2 API
+	c, err := New(server.Address(), testUser, Options{V2Address: server.Address()})
+	if err != nil {
+		t.Fatalf("failed to connect to the bridge: %s", err)
+	}
+
+	var unsupported *UnsupportedError
+	_, err = c.V2()
+	if !errors.As(err, &unsupported) {
+		t.Errorf("expected the v2 API to be unsupported, got %v", err)
+	}
+
+	c, err = New(server.Address(), testUser, Options{Backend: "deconz", V2Address: server.TLSAddress()})
+	if err != nil {
+		t.Fatalf("failed to connect to the bridge: %s", err)
+	}
+
+	_, err = c.V2Lights("Desk Lamp")
+	if !errors.As(err, &unsupported) {
+		t.Errorf("expected the v2 API to be unsupported for deconz, got %v", err)
+	}
+}
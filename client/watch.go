@@ -0,0 +1,417 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nixpanic/hue-cli/clipv2"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// DefaultWatchInterval is how often the bridge is polled by Watch when
+// WatchOptions.Interval is not set.
+const DefaultWatchInterval = time.Second
+
+// WatchTypes are the types of resources that can be watched.
+var WatchTypes = []string{"light", "group", "sensor"}
+
+// An Event is a change of the state of a light, group or sensor. The
+// changed attributes have the names of the v1 API, like "on", "bri" or
+// "presence".
+type Event struct {
+	Time    time.Time              `json:"time"`
+	Type    string                 `json:"type"`
+	ID      string                 `json:"id"`
+	Name    string                 `json:"name"`
+	Changes map[string]interface{} `json:"changes"`
+}
+
+// WatchOptions select the changes that Watch reports.
+type WatchOptions struct {
+	// Types of the resources (see WatchTypes), all when empty
+	Types []string
+	// Selection is a comma separated list of names and/or indexes of the
+	// resources, all when empty
+	Selection string
+	// Interval is how often the bridge is polled when it does not have
+	// the event stream of the CLIP v2 API
+	Interval time.Duration
+}
+
+// watchFilter tells whether a resource (by type and ID) is watched.
+type watchFilter map[string]map[string]bool
+
+func (f watchFilter) match(rtype, id string) bool {
+	ids, ok := f[rtype]
+	return ok && (ids == nil || ids[id])
+}
+
+// Watch calls fn for every change of the lights, groups and sensors until
+// ctx is done, or fn returns an error. The changes are received from the
+// event stream of the CLIP v2 API, bridges without it are polled.
+func (c *Client) Watch(ctx context.Context, options WatchOptions, fn func(Event) error) error {
+	filter, err := c.newWatchFilter(options)
+	if err != nil {
+		return err
+	}
+
+	v2, err := c.V2()
+	var unsupported *UnsupportedError
+	if errors.As(err, &unsupported) {
+		interval := options.Interval
+		if interval == 0 {
+			interval = DefaultWatchInterval
+		}
+		c.logger.Verbosef("the bridge has no event stream, polling every %s\n", interval)
+
+		return c.poll(ctx, filter, interval, fn)
+	} else if err != nil {
+		return err
+	}
+
+	inventory, _, err := c.Inventory(false)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Verbosef("watching the event stream of bridge %s\n", c.Bridge.IPAddress)
+	for {
+		err = v2.Events(ctx, func(e clipv2.Event) error {
+			for _, data := range e.Data {
+				event, ok := v1Event(e.CreationTime, data)
+				if !ok || !filter.match(event.Type, event.ID) {
+					continue
+				}
+				event.Name = inventoryName(inventory, event.Type, event.ID)
+
+				err := fn(event)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil || ctx.Err() != nil {
+			return err
+		}
+
+		// the bridge closed the stream, reconnect after a moment
+		c.logger.Verbosef("the event stream was closed, reconnecting\n")
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// newWatchFilter resolves the selection of the options for each of the
+// types. A name only needs to match a resource of one of the types.
+func (c *Client) newWatchFilter(options WatchOptions) (watchFilter, error) {
+	filter := watchFilter{}
+	for _, rtype := range options.Types {
+		if !isWatchType(rtype) {
+			return nil, fmt.Errorf("unknown type %q, use one of %s", rtype, strings.Join(WatchTypes, ", "))
+		}
+		filter[rtype] = nil
+	}
+	if len(filter) == 0 {
+		for _, rtype := range WatchTypes {
+			filter[rtype] = nil
+		}
+	}
+
+	if options.Selection == "" {
+		return filter, nil
+	}
+
+	selected := watchFilter{}
+	add := func(rtype string, item utils.InventoryItem) {
+		if selected[rtype] == nil {
+			selected[rtype] = map[string]bool{}
+		}
+		selected[rtype][item.ID] = true
+	}
+
+	for _, name := range strings.Split(options.Selection, ",") {
+		name = strings.TrimSpace(name)
+
+		lights, groups, ok, err := c.resolveTag(name)
+		if err != nil {
+			return nil, err
+		} else if ok {
+			for rtype, items := range map[string][]utils.InventoryItem{"light": lights, "group": groups} {
+				if _, watched := filter[rtype]; watched {
+					for _, item := range items {
+						add(rtype, item)
+					}
+				}
+			}
+			continue
+		}
+
+		refresh := false
+		for {
+			inventory, cached, err := c.Inventory(refresh)
+			if err != nil {
+				return nil, err
+			}
+
+			matched := false
+			var nameErr *NameError
+			for _, rtype := range WatchTypes {
+				if _, ok := filter[rtype]; !ok {
+					continue
+				}
+
+				item, err := c.resolveItem(rtype, inventoryItems(inventory, rtype), name)
+				if err != nil {
+					ne := &NameError{Kind: rtype, Name: name, Err: err}
+					if ne.Ambiguous() {
+						return nil, ne
+					} else if nameErr == nil {
+						nameErr = ne
+					}
+					continue
+				}
+
+				add(rtype, item)
+				matched = true
+			}
+
+			if matched {
+				break
+			} else if !cached {
+				return nil, nameErr
+			}
+			refresh = true
+		}
+	}
+
+	return selected, nil
+}
+
+func isWatchType(rtype string) bool {
+	for _, t := range WatchTypes {
+		if t == rtype {
+			return true
+		}
+	}
+
+	return false
+}
+
+func inventoryItems(inventory *utils.Inventory, rtype string) []utils.InventoryItem {
+	switch rtype {
+	case "light":
+		return inventory.Lights
+	case "group":
+		return inventory.Groups
+	case "sensor":
+		return inventory.Sensors
+	}
+
+	return nil
+}
+
+func inventoryName(inventory *utils.Inventory, rtype, id string) string {
+	for _, item := range inventoryItems(inventory, rtype) {
+		if item.ID == id {
+			return item.Name
+		}
+	}
+
+	return ""
+}
+
+// v1Event converts the data of an event of the CLIP v2 API to an Event for
+// the v1 resource, it returns false for resources that do not exist in the
+// v1 API.
+func v1Event(t time.Time, data clipv2.EventData) (Event, bool) {
+	parts := strings.Split(strings.Trim(data.IDV1, "/"), "/")
+	if len(parts) != 2 {
+		return Event{}, false
+	}
+
+	rtype := strings.TrimSuffix(parts[0], "s")
+	if !isWatchType(rtype) {
+		return Event{}, false
+	}
+
+	changes := map[string]interface{}{}
+	for key, value := range data.Attributes {
+		attributes, _ := value.(map[string]interface{})
+		switch {
+		case key == "on" && attributes != nil:
+			changes["on"] = attributes["on"]
+		case key == "dimming" && attributes != nil:
+			if brightness, ok := attributes["brightness"].(float64); ok {
+				changes["bri"] = int(math.Round(brightness * 254 / 100))
+			}
+		case key == "color" && attributes != nil:
+			if xy, ok := attributes["xy"].(map[string]interface{}); ok {
+				changes["xy"] = []interface{}{xy["x"], xy["y"]}
+			}
+		case key == "color_temperature" && attributes != nil:
+			if attributes["mirek"] != nil {
+				changes["ct"] = attributes["mirek"]
+			}
+		case key == "motion" && attributes != nil:
+			changes["presence"] = attributes["motion"]
+		case key == "temperature" && attributes != nil:
+			if temperature, ok := attributes["temperature"].(float64); ok {
+				changes["temperature"] = int(math.Round(temperature * 100))
+			}
+		case key == "light" && attributes != nil:
+			changes["lightlevel"] = attributes["light_level"]
+		default:
+			flatten(changes, key, value)
+		}
+	}
+
+	if len(changes) == 0 {
+		return Event{}, false
+	}
+
+	return Event{Time: t, Type: rtype, ID: parts[1], Changes: changes}, true
+}
+
+// flatten adds the value to the changes, the attributes of objects are added
+// with their name prefixed by the key and a ".".
+func flatten(changes map[string]interface{}, key string, value interface{}) {
+	attributes, ok := value.(map[string]interface{})
+	if !ok {
+		changes[key] = value
+		return
+	}
+
+	for k, v := range attributes {
+		flatten(changes, key+"."+k, v)
+	}
+}
+
+// poll reads the state of the lights, groups and sensors every interval,
+// and reports the differences with the previous state.
+func (c *Client) poll(ctx context.Context, filter watchFilter, interval time.Duration, fn func(Event) error) error {
+	previous := map[string]map[string]map[string]interface{}{}
+	for {
+		for _, rtype := range WatchTypes {
+			if _, ok := filter[rtype]; !ok {
+				continue
+			}
+
+			states, names, err := c.pollStates(rtype)
+			if err != nil {
+				return err
+			}
+
+			if previous[rtype] != nil {
+				ids := []string{}
+				for id := range states {
+					ids = append(ids, id)
+				}
+				sort.Strings(ids)
+
+				for _, id := range ids {
+					old, ok := previous[rtype][id]
+					if !ok || !filter.match(rtype, id) {
+						continue
+					}
+
+					changes := stateChanges(old, states[id])
+					if len(changes) == 0 {
+						continue
+					}
+
+					err = fn(Event{Time: time.Now(), Type: rtype, ID: id, Name: names[id], Changes: changes})
+					if err != nil {
+						return err
+					}
+				}
+			}
+			previous[rtype] = states
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// pollStates reads the state of all resources of the type, by ID. The state
+// of a group has "on" when any of its lights is on, like in the CLIP v2 API.
+func (c *Client) pollStates(rtype string) (map[string]map[string]interface{}, map[string]string, error) {
+	body, _, err := c.Bridge.Get(fmt.Sprintf("/api/%s/%ss", c.Bridge.Username, rtype))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resources := map[string]struct {
+		Name  string                 `json:"name"`
+		State map[string]interface{} `json:"state"`
+	}{}
+	err = json.Unmarshal(body, &resources)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	states := map[string]map[string]interface{}{}
+	names := map[string]string{}
+	for id, r := range resources {
+		state := r.State
+		if rtype == "group" {
+			state = map[string]interface{}{"on": r.State["any_on"]}
+		}
+		states[id] = state
+		names[id] = r.Name
+	}
+
+	return states, names, nil
+}
+
+// stateChanges returns the attributes of the state that differ. A button
+// that is pressed again only changes "lastupdated", the buttonevent is
+// reported for it.
+func stateChanges(old, state map[string]interface{}) map[string]interface{} {
+	changes := map[string]interface{}{}
+	for key, value := range state {
+		if key != "lastupdated" && !reflect.DeepEqual(old[key], value) {
+			changes[key] = value
+		}
+	}
+
+	if len(changes) == 0 && state["buttonevent"] != nil && !reflect.DeepEqual(old["lastupdated"], state["lastupdated"]) {
+		changes["buttonevent"] = state["buttonevent"]
+	}
+
+	return changes
+}
@@ -0,0 +1,146 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nixpanic/hue-cli/clipv2"
+	"github.com/nixpanic/hue-cli/huetest"
+)
+
+// watchOne watches the bridge until the first event, change is called
+// repeatedly as the watch does not start immediately.
+func watchOne(t *testing.T, c *Client, options WatchOptions, change func(i int)) Event {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan Event, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Watch(ctx, options, func(event Event) error {
+			events <- event
+			return nil
+		})
+	}()
+
+	timeout := time.After(5 * time.Second)
+	for i := 0; ; i++ {
+		change(i)
+
+		select {
+		case event := <-events:
+			return event
+		case err := <-done:
+			t.Fatalf("watch failed: %v", err)
+		case <-time.After(50 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("no event was reported")
+		}
+	}
+}
+
+func TestWatchEventStream(t *testing.T) {
+	_, c := startBridge(t)
+
+	event := watchOne(t, c, WatchOptions{Selection: "desk lamp"}, func(i int) {
+		// the Ceiling light is not selected
+		for _, light := range []int{2, 1} {
+			_, _, err := c.Bridge.Put(fmt.Sprintf("/api/%s/lights/%d/state", testUser, light), map[string]interface{}{"on": i%2 == 0})
+			if err != nil {
+				t.Fatalf("failed to change light %d: %s", light, err)
+			}
+		}
+	})
+
+	if event.Type != "light" || event.ID != "1" || event.Name != "Desk Lamp" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if _, ok := event.Changes["on"]; !ok {
+		t.Errorf("unexpected changes: %v", event.Changes)
+	}
+}
+
+func TestWatchPolling(t *testing.T) {
+	state, err := huetest.LoadState("testdata/bridge.yaml")
+	if err != nil {
+		t.Fatalf("failed to load bridge state: %s", err)
+	}
+	state.V2 = nil
+
+	server := huetest.NewServer(state)
+	t.Cleanup(server.Close)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	c, err := New(server.Address(), testUser, Options{V2Address: server.TLSAddress()})
+	if err != nil {
+		t.Fatalf("failed to connect to the bridge: %s", err)
+	}
+
+	options := WatchOptions{Types: []string{"sensor"}, Interval: 10 * time.Millisecond}
+	event := watchOne(t, c, options, func(i int) {
+		server.Bridge.SetSensorState("1", map[string]interface{}{"daylight": i%2 == 0})
+	})
+
+	if event.Type != "sensor" || event.ID != "1" || event.Name != "Daylight" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if _, ok := event.Changes["daylight"]; !ok || len(event.Changes) != 1 {
+		t.Errorf("unexpected changes: %v", event.Changes)
+	}
+}
+
+func TestWatchUnknownName(t *testing.T) {
+	_, c := startBridge(t)
+
+	err := c.Watch(context.Background(), WatchOptions{Types: []string{"group"}, Selection: "desk lamp"}, func(Event) error {
+		return nil
+	})
+	if !IsNotFound(err) {
+		t.Errorf("expected the group to be not found, got %v", err)
+	}
+}
+
+func TestV1Event(t *testing.T) {
+	data := clipv2.EventData{
+		IDV1: "/sensors/3",
+		Type: "motion",
+		Attributes: map[string]interface{}{
+			"motion": map[string]interface{}{"motion": true, "motion_valid": true},
+		},
+	}
+
+	event, ok := v1Event(time.Now(), data)
+	if !ok || event.Type != "sensor" || event.ID != "3" || event.Changes["presence"] != true || len(event.Changes) != 1 {
+		t.Errorf("unexpected event: %+v", event)
+	}
+
+	// the bridge device has no v1 resource
+	_, ok = v1Event(time.Now(), clipv2.EventData{Type: "device"})
+	if ok {
+		t.Error("expected no event for a resource without v1 ID")
+	}
+}
@@ -0,0 +1,157 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+// Package clipv2 is a client for version 2 of the Hue API (CLIP v2). The v2
+// API is served over HTTPS, and exposes resources that the v1 API does not
+// have, like effects, gradients, dynamic and smart scenes, and the devices
+// with their services.
+package clipv2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Options change the behaviour of a Client.
+type Options struct {
+	// HTTPClient sends the requests. The certificate of the bridge is
+	// self-signed, the client should trust it (client.BridgeTransport
+	// pins it). The http.DefaultClient is used when it is not set.
+	HTTPClient *http.Client
+	// StreamClient receives the event stream, it should not time out or
+	// buffer the response. The HTTPClient is used when it is not set.
+	StreamClient *http.Client
+}
+
+// A Client sends requests to the CLIP v2 API of a bridge.
+type Client struct {
+	address string
+	key     string
+	http    *http.Client
+	stream  *http.Client
+}
+
+// New returns a client for the bridge at the address (<ip-address> or
+// <ip-address>:<port>), the key is the username of the v1 API.
+func New(address, key string, options Options) *Client {
+	client := options.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	stream := options.StreamClient
+	if stream == nil {
+		stream = client
+	}
+
+	return &Client{
+		address: address,
+		key:     key,
+		http:    client,
+		stream:  stream,
+	}
+}
+
+// An Error is returned when the bridge did not accept a request.
+type Error struct {
+	StatusCode   int
+	Descriptions []string
+}
+
+func (e *Error) Error() string {
+	if len(e.Descriptions) == 0 {
+		return fmt.Sprintf("request failed with status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, strings.Join(e.Descriptions, ", "))
+}
+
+// response is the envelope of all responses of the v2 API.
+type response struct {
+	Errors []struct {
+		Description string `json:"description"`
+	} `json:"errors"`
+	Data json.RawMessage `json:"data"`
+}
+
+// do sends the request with the body (when not nil) to the resource path
+// (like "light" or "light/<id>") and decodes the data of the response.
+func (c *Client) do(method, path string, body, data interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, "https://"+c.address+"/clip/v2/resource/"+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("hue-application-key", c.key)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var r response
+	err = json.Unmarshal(respBody, &r)
+	if err != nil && resp.StatusCode == http.StatusOK {
+		return fmt.Errorf("failed to parse the response for %s: %w", path, err)
+	}
+
+	if resp.StatusCode != http.StatusOK || len(r.Errors) != 0 {
+		e := &Error{StatusCode: resp.StatusCode}
+		for _, re := range r.Errors {
+			e.Descriptions = append(e.Descriptions, re.Description)
+		}
+		return e
+	}
+
+	if data == nil {
+		return nil
+	}
+
+	return json.Unmarshal(r.Data, data)
+}
+
+func (c *Client) get(path string, data interface{}) error {
+	return c.do(http.MethodGet, path, nil, data)
+}
+
+func (c *Client) put(path string, body interface{}) error {
+	return c.do(http.MethodPut, path, body, nil)
+}
@@ -0,0 +1,114 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package clipv2
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/huetest"
+)
+
+// startBridge starts a fake bridge with the state from testdata/bridge.yaml,
+// and returns a Client for its v2 API with the key.
+func startBridge(t *testing.T, key string) (*huetest.Server, *Client) {
+	state, err := huetest.LoadState("testdata/bridge.yaml")
+	if err != nil {
+		t.Fatalf("failed to load bridge state: %s", err)
+	}
+
+	server := huetest.NewServer(state)
+	t.Cleanup(server.Close)
+
+	// the client of the HTTPS server trusts its certificate
+	return server, New(server.TLSAddress(), key, Options{HTTPClient: server.TLS.Client()})
+}
+
+func TestResources(t *testing.T) {
+	_, c := startBridge(t, "testuser")
+
+	lights, err := c.Lights()
+	if err != nil {
+		t.Fatalf("failed to get the lights: %s", err)
+	}
+	if len(lights) != 3 {
+		t.Errorf("expected 3 lights, got %d", len(lights))
+	}
+
+	groups, err := c.Groups()
+	if err != nil {
+		t.Fatalf("failed to get the rooms and zones: %s", err)
+	}
+	if len(groups) != 2 || groups[0].Type != "room" || groups[1].Type != "zone" {
+		t.Errorf("unexpected rooms and zones: %+v", groups)
+	}
+
+	// the bridge is a device as well
+	devices, err := c.Devices()
+	if err != nil {
+		t.Fatalf("failed to get the devices: %s", err)
+	}
+	if len(devices) != 4 {
+		t.Errorf("expected 4 devices, got %d", len(devices))
+	}
+}
+
+func TestUpdateLight(t *testing.T) {
+	server, c := startBridge(t, "testuser")
+
+	update := LightUpdate{
+		On:      &On{On: true},
+		Dimming: &Dimming{Brightness: 50},
+		Gradient: &Gradient{Points: []GradientPoint{
+			{Color: Color{XY: XY{X: 0.6915, Y: 0.3083}}},
+			{Color: Color{XY: XY{X: 0.1532, Y: 0.0475}}},
+		}},
+	}
+	err := c.UpdateLight(huetest.V2ID("light", "2"), update)
+	if err != nil {
+		t.Fatalf("failed to update the light: %s", err)
+	}
+
+	state := server.Bridge.State()
+	if light := state.Lights["2"].State; !light.On || light.Bri != 127 {
+		t.Errorf("unexpected state of the light: %+v", light)
+	}
+	if gradient := state.V2.Lights["2"].Gradient; len(gradient) != 2 {
+		t.Errorf("unexpected gradient: %v", gradient)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	_, c := startBridge(t, "unknown")
+
+	var e *Error
+	_, err := c.Lights()
+	if !errors.As(err, &e) || e.StatusCode != http.StatusForbidden || e.Descriptions[0] != "unauthorized user" {
+		t.Errorf("expected an unauthorized user, got %v", err)
+	}
+
+	_, c = startBridge(t, "testuser")
+	err = c.RecallScene("unknown", "active")
+	if !errors.As(err, &e) || e.StatusCode != http.StatusNotFound {
+		t.Errorf("expected the scene to be not found, got %v", err)
+	}
+}
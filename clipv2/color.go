@@ -0,0 +1,78 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package clipv2
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseColor converts a color in the form #rrggbb, or x,y, to a color in the
+// CIE color space.
+func ParseColor(s string) (XY, error) {
+	if parts := strings.Split(s, ","); len(parts) == 2 {
+		x, errX := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		y, errY := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if errX != nil || errY != nil || x < 0 || x > 1 || y < 0 || y > 1 {
+			return XY{}, fmt.Errorf("invalid color %s, x and y should be between 0 and 1", s)
+		}
+
+		return XY{X: x, Y: y}, nil
+	}
+
+	hex := strings.TrimPrefix(s, "#")
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return XY{}, errors.New(fmt.Sprintf("invalid color %s, use #rrggbb or x,y", s))
+	}
+
+	return rgbToXY(float64(rgb>>16&0xff)/255, float64(rgb>>8&0xff)/255, float64(rgb&0xff)/255), nil
+}
+
+// rgbToXY converts sRGB values (between 0 and 1) with the wide gamut
+// conversion that Philips documents for Hue lights.
+func rgbToXY(r, g, b float64) XY {
+	gamma := func(c float64) float64 {
+		if c > 0.04045 {
+			return math.Pow((c+0.055)/1.055, 2.4)
+		}
+		return c / 12.92
+	}
+	r, g, b = gamma(r), gamma(g), gamma(b)
+
+	x := r*0.664511 + g*0.154324 + b*0.162028
+	y := r*0.283881 + g*0.668433 + b*0.047685
+	z := r*0.000088 + g*0.072310 + b*0.986039
+
+	sum := x + y + z
+	if sum == 0 {
+		// black has no color, use the white point
+		return XY{X: 0.3227, Y: 0.329}
+	}
+
+	return XY{
+		X: math.Round(x/sum*10000) / 10000,
+		Y: math.Round(y/sum*10000) / 10000,
+	}
+}
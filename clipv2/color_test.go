@@ -0,0 +1,48 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package clipv2
+
+import (
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	for _, tc := range []struct {
+		color string
+		xy    XY
+		ok    bool
+	}{
+		{"#ff0000", XY{X: 0.7006, Y: 0.2993}, true},
+		{"0000FF", XY{X: 0.1355, Y: 0.0399}, true},
+		{"#000000", XY{X: 0.3227, Y: 0.329}, true},
+		{"0.5, 0.4", XY{X: 0.5, Y: 0.4}, true},
+		{"#fff", XY{}, false},
+		{"red", XY{}, false},
+		{"1.5,0.2", XY{}, false},
+	} {
+		xy, err := ParseColor(tc.color)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: unexpected error %v", tc.color, err)
+		} else if xy != tc.xy {
+			t.Errorf("%s: expected %+v, got %+v", tc.color, tc.xy, xy)
+		}
+	}
+}
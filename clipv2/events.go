@@ -0,0 +1,151 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package clipv2
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// An Event is reported by the bridge in the event stream when resources
+// were added, updated or deleted.
+type Event struct {
+	CreationTime time.Time   `json:"creationtime"`
+	ID           string      `json:"id"`
+	Type         string      `json:"type"`
+	Data         []EventData `json:"data"`
+}
+
+// EventData is a resource in an Event, with the attributes that changed.
+type EventData struct {
+	ID    string
+	IDV1  string
+	Type  string
+	Owner *ResourceIdentifier
+
+	// Attributes are the other attributes of the resource, as they were
+	// decoded from JSON
+	Attributes map[string]interface{}
+}
+
+func (d *EventData) UnmarshalJSON(data []byte) error {
+	var identity struct {
+		ID    string              `json:"id"`
+		IDV1  string              `json:"id_v1"`
+		Type  string              `json:"type"`
+		Owner *ResourceIdentifier `json:"owner"`
+	}
+	err := json.Unmarshal(data, &identity)
+	if err != nil {
+		return err
+	}
+
+	attributes := map[string]interface{}{}
+	err = json.Unmarshal(data, &attributes)
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{"id", "id_v1", "type", "owner"} {
+		delete(attributes, key)
+	}
+
+	*d = EventData{
+		ID:         identity.ID,
+		IDV1:       identity.IDV1,
+		Type:       identity.Type,
+		Owner:      identity.Owner,
+		Attributes: attributes,
+	}
+
+	return nil
+}
+
+// Events subscribes to the event stream of the bridge, and calls fn for
+// every event. It returns nil when ctx is done or the bridge closed the
+// stream, and the error of fn when it returned one.
+func (c *Client) Events(ctx context.Context, fn func(Event) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+c.address+"/eventstream/clip/v2", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("hue-application-key", c.key)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.stream.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		e := &Error{StatusCode: resp.StatusCode}
+		var r response
+		body, _ := ioutil.ReadAll(resp.Body)
+		if json.Unmarshal(body, &r) == nil {
+			for _, re := range r.Errors {
+				e.Descriptions = append(e.Descriptions, re.Description)
+			}
+		}
+		return e
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	var data strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF || ctx.Err() != nil {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		case line == "" && data.Len() != 0:
+			// an empty line ends the message, which contains a
+			// list of events
+			var events []Event
+			err = json.Unmarshal([]byte(data.String()), &events)
+			data.Reset()
+			if err != nil {
+				return err
+			}
+
+			for _, event := range events {
+				err = fn(event)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package clipv2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nixpanic/hue-cli/huetest"
+)
+
+func TestEvents(t *testing.T) {
+	_, c := startBridge(t, "testuser")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan Event, 16)
+	done := make(chan error)
+	go func() {
+		done <- c.Events(ctx, func(event Event) error {
+			events <- event
+			return nil
+		})
+	}()
+
+	// the subscription is not immediately active, repeat the change until
+	// it gets reported
+	var event Event
+	timeout := time.After(5 * time.Second)
+	for on := true; event.ID == ""; on = !on {
+		err := c.UpdateLight(huetest.V2ID("light", "1"), LightUpdate{On: &On{On: on}})
+		if err != nil {
+			t.Fatalf("failed to update the light: %s", err)
+		}
+
+		select {
+		case event = <-events:
+		case <-time.After(100 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("no event was reported")
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("the event stream failed: %s", err)
+	}
+
+	if event.Type != "update" || len(event.Data) == 0 {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	data := event.Data[0]
+	if data.Type != "light" || data.IDV1 != "/lights/1" || data.Attributes["on"] == nil {
+		t.Errorf("unexpected data: %+v", data)
+	}
+}
+
+func TestEventsUnauthorized(t *testing.T) {
+	_, c := startBridge(t, "unknown")
+
+	var e *Error
+	err := c.Events(context.Background(), func(Event) error { return nil })
+	if !errors.As(err, &e) || e.StatusCode != http.StatusForbidden {
+		t.Errorf("expected an unauthorized user, got %v", err)
+	}
+}
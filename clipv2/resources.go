@@ -0,0 +1,314 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package clipv2
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// KnownEffects are the effects that lights can support, which ones a light
+// supports is in its Effects.EffectValues.
+var KnownEffects = []string{
+	"candle", "fire", "prism", "sparkle", "opal", "glisten", "underwater",
+	"cosmos", "sunbeam", "enchant", "no_effect",
+}
+
+// A ResourceIdentifier references another resource.
+type ResourceIdentifier struct {
+	RID   string `json:"rid"`
+	RType string `json:"rtype"`
+}
+
+type Metadata struct {
+	Name      string `json:"name"`
+	Archetype string `json:"archetype,omitempty"`
+}
+
+type ProductData struct {
+	ModelID          string `json:"model_id"`
+	ManufacturerName string `json:"manufacturer_name"`
+	ProductName      string `json:"product_name"`
+	SoftwareVersion  string `json:"software_version"`
+}
+
+// A Device is a physical device, its functions are services like a light or
+// a button.
+type Device struct {
+	ID          string               `json:"id"`
+	IDv1        string               `json:"id_v1,omitempty"`
+	Metadata    Metadata             `json:"metadata"`
+	ProductData ProductData          `json:"product_data"`
+	Services    []ResourceIdentifier `json:"services"`
+}
+
+// A ZigbeeConnectivity is the connection of a device with the bridge.
+type ZigbeeConnectivity struct {
+	ID         string             `json:"id"`
+	Owner      ResourceIdentifier `json:"owner"`
+	Status     string             `json:"status"`
+	MACAddress string             `json:"mac_address,omitempty"`
+}
+
+// Connected returns true when the bridge can reach the device.
+func (z ZigbeeConnectivity) Connected() bool {
+	return z.Status == "connected"
+}
+
+type On struct {
+	On bool `json:"on"`
+}
+
+type Dimming struct {
+	Brightness float64 `json:"brightness"`
+}
+
+// XY is a color in the CIE color space.
+type XY struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type Color struct {
+	XY XY `json:"xy"`
+}
+
+type Effects struct {
+	Effect       string   `json:"effect"`
+	EffectValues []string `json:"effect_values,omitempty"`
+	Status       string   `json:"status,omitempty"`
+}
+
+type GradientPoint struct {
+	Color Color `json:"color"`
+}
+
+type Gradient struct {
+	Points        []GradientPoint `json:"points"`
+	PointsCapable int             `json:"points_capable,omitempty"`
+}
+
+// A Light is the light service of a device. Effects and Gradient are only
+// set for lights that support them.
+type Light struct {
+	ID       string             `json:"id"`
+	IDv1     string             `json:"id_v1,omitempty"`
+	Owner    ResourceIdentifier `json:"owner"`
+	Metadata Metadata           `json:"metadata"`
+	On       On                 `json:"on"`
+	Dimming  *Dimming           `json:"dimming,omitempty"`
+	Color    *Color             `json:"color,omitempty"`
+	Effects  *Effects           `json:"effects,omitempty"`
+	Gradient *Gradient          `json:"gradient,omitempty"`
+}
+
+// V1Index returns the index of the light in the v1 API, or 0 when the light
+// is not available there.
+func (l Light) V1Index() int {
+	index, _ := strconv.Atoi(strings.TrimPrefix(l.IDv1, "/lights/"))
+
+	return index
+}
+
+// SupportsEffect returns true when the light supports the effect.
+func (l Light) SupportsEffect(effect string) bool {
+	if l.Effects == nil {
+		return false
+	}
+
+	for _, e := range l.Effects.EffectValues {
+		if e == effect {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GradientPoints returns the number of colors of a gradient the light
+// supports, 0 for lights without gradient.
+func (l Light) GradientPoints() int {
+	if l.Gradient == nil {
+		return 0
+	}
+
+	return l.Gradient.PointsCapable
+}
+
+// A LightUpdate changes the attributes of a light that are set.
+type LightUpdate struct {
+	On       *On       `json:"on,omitempty"`
+	Dimming  *Dimming  `json:"dimming,omitempty"`
+	Color    *Color    `json:"color,omitempty"`
+	Effects  *Effects  `json:"effects,omitempty"`
+	Gradient *Gradient `json:"gradient,omitempty"`
+}
+
+// A Group is a room or a zone. The children of a room are devices, those of
+// a zone are lights.
+type Group struct {
+	ID       string               `json:"id"`
+	IDv1     string               `json:"id_v1,omitempty"`
+	Type     string               `json:"type"`
+	Metadata Metadata             `json:"metadata"`
+	Children []ResourceIdentifier `json:"children"`
+	Services []ResourceIdentifier `json:"services"`
+}
+
+// V1Index returns the index of the group in the v1 API, or 0 when the group
+// is not available there.
+func (g Group) V1Index() int {
+	index, _ := strconv.Atoi(strings.TrimPrefix(g.IDv1, "/groups/"))
+
+	return index
+}
+
+// A GroupedLight controls all lights of a room or zone at once.
+type GroupedLight struct {
+	ID   string `json:"id"`
+	IDv1 string `json:"id_v1,omitempty"`
+	On   On     `json:"on"`
+}
+
+type Scene struct {
+	ID       string             `json:"id"`
+	IDv1     string             `json:"id_v1,omitempty"`
+	Metadata Metadata           `json:"metadata"`
+	Group    ResourceIdentifier `json:"group"`
+	Palette  json.RawMessage    `json:"palette,omitempty"`
+}
+
+// Dynamic returns true when the scene has a palette, and can be recalled
+// dynamically.
+func (s Scene) Dynamic() bool {
+	return len(s.Palette) != 0
+}
+
+// A SmartScene recalls scenes depending on the time of the day.
+type SmartScene struct {
+	ID       string             `json:"id"`
+	Metadata Metadata           `json:"metadata"`
+	Group    ResourceIdentifier `json:"group"`
+	State    string             `json:"state"`
+}
+
+type Bridge struct {
+	ID       string `json:"id"`
+	BridgeID string `json:"bridge_id"`
+}
+
+// Bridge returns the bridge resource, it is used to check whether the v2
+// API is available.
+func (c *Client) Bridge() (Bridge, error) {
+	var bridges []Bridge
+	err := c.get("bridge", &bridges)
+	if err != nil {
+		return Bridge{}, err
+	} else if len(bridges) == 0 {
+		return Bridge{}, errors.New("the bridge resource is missing")
+	}
+
+	return bridges[0], nil
+}
+
+func (c *Client) Devices() ([]Device, error) {
+	var devices []Device
+	err := c.get("device", &devices)
+
+	return devices, err
+}
+
+func (c *Client) ZigbeeConnectivity() ([]ZigbeeConnectivity, error) {
+	var connectivity []ZigbeeConnectivity
+	err := c.get("zigbee_connectivity", &connectivity)
+
+	return connectivity, err
+}
+
+func (c *Client) Lights() ([]Light, error) {
+	var lights []Light
+	err := c.get("light", &lights)
+
+	return lights, err
+}
+
+// Groups returns the rooms and the zones.
+func (c *Client) Groups() ([]Group, error) {
+	var rooms, zones []Group
+	err := c.get("room", &rooms)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.get("zone", &zones)
+
+	return append(rooms, zones...), err
+}
+
+func (c *Client) GroupedLights() ([]GroupedLight, error) {
+	var groupedLights []GroupedLight
+	err := c.get("grouped_light", &groupedLights)
+
+	return groupedLights, err
+}
+
+func (c *Client) Scenes() ([]Scene, error) {
+	var scenes []Scene
+	err := c.get("scene", &scenes)
+
+	return scenes, err
+}
+
+func (c *Client) SmartScenes() ([]SmartScene, error) {
+	var smartScenes []SmartScene
+	err := c.get("smart_scene", &smartScenes)
+
+	return smartScenes, err
+}
+
+// UpdateLight changes the attributes of the light that are set in update.
+func (c *Client) UpdateLight(id string, update LightUpdate) error {
+	return c.put("light/"+id, update)
+}
+
+// UpdateGroupedLight changes the attributes that are set in update of all
+// lights of the room or zone.
+func (c *Client) UpdateGroupedLight(id string, update LightUpdate) error {
+	return c.put("grouped_light/"+id, update)
+}
+
+// RecallScene recalls the scene, the action is "active", "static" or
+// "dynamic_palette".
+func (c *Client) RecallScene(id, action string) error {
+	return c.put("scene/"+id, map[string]interface{}{
+		"recall": map[string]string{"action": action},
+	})
+}
+
+// RecallSmartScene changes the state of the smart scene, the action is
+// "activate" or "deactivate".
+func (c *Client) RecallSmartScene(id, action string) error {
+	return c.put("smart_scene/"+id, map[string]interface{}{
+		"recall": map[string]string{"action": action},
+	})
+}
@@ -0,0 +1,384 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+type AliasOptions struct {
+	group bool
+	tag   bool
+}
+
+// AliasSummary is an alias or tag with the lights and groups it refers to.
+type AliasSummary struct {
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	Bridge string        `json:"bridge,omitempty"`
+	Lights []AliasTarget `json:"lights,omitempty"`
+	Groups []AliasTarget `json:"groups,omitempty"`
+}
+
+// AliasTarget is a light (by unique ID) or group (by ID), with its current
+// name on the bridge when it is known.
+type AliasTarget struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+func initAlias(app *App, cmd *cobra.Command) {
+	// hue-cli alias
+	cmdAlias := &cobra.Command{
+		Use:   "alias",
+		Short: "manage local names for lights and groups",
+		Long: "manage aliases (local names for a light or group) and tags (local names for a set of " +
+			"lights and groups) in the configuration file. They can be used instead of the names on the bridge.",
+	}
+	cmd.AddCommand(cmdAlias)
+
+	// hue-cli alias add <alias> <light>
+	cmdAlias.AddCommand(newAliasAddCommand(app))
+	// hue-cli alias list
+	cmdAlias.AddCommand(newAliasListCommand(app))
+	// hue-cli alias remove <alias>
+	cmdAlias.AddCommand(newAliasRemoveCommand(app))
+}
+
+// loadAliasConfig reads the configuration file for changing the aliases, a
+// missing file is started empty.
+func (app *App) loadAliasConfig() (*utils.ConfigFile, error) {
+	config, err := client.LoadConfig(app.bridge.config)
+	if os.IsNotExist(err) {
+		return &utils.ConfigFile{}, nil
+	} else if err != nil {
+		return nil, invalidInputError("failed to load %s: %s", app.bridge.config, err)
+	}
+
+	return config, nil
+}
+
+// saveAliasConfig validates and writes the changed configuration.
+func (app *App) saveAliasConfig(config *utils.ConfigFile) error {
+	err := config.Validate()
+	if err != nil {
+		return invalidInputError("%s", err)
+	}
+
+	return app.saveConfig(config)
+}
+
+// aliasTarget resolves the name of the light or group on the bridge to the
+// unique ID of the light, or ID of the group.
+func aliasTarget(c *client.Client, name string, group bool) (string, error) {
+	if group {
+		g, err := c.Group(name)
+		if err != nil {
+			return "", err
+		}
+
+		return strconv.Itoa(g.Index), nil
+	}
+
+	light, err := c.Light(name)
+	if err != nil {
+		return "", err
+	} else if light.UniqueID == "" {
+		return "", fmt.Errorf("light '%s' does not have a unique ID", light.Name)
+	}
+
+	return light.UniqueID, nil
+}
+
+func newAliasAddCommand(app *App) *cobra.Command {
+	var aliasOptions AliasOptions
+
+	cmd := &cobra.Command{
+		Use:   "add <alias> <light>",
+		Short: "add an alias or tag",
+		Long: "add an alias for the light (or group with --group). With --tag, the lights (or groups) " +
+			"are added to the tag, which is created when it does not exist. An existing alias is replaced.",
+		Args:         cobra.MinimumNArgs(2),
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !aliasOptions.tag && len(args) != 2 {
+				return invalidInputError("an alias refers to a single light or group, use --tag for more")
+			}
+
+			config, err := app.loadAliasConfig()
+			if err != nil {
+				return err
+			}
+
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			targets := []string{}
+			for _, name := range args[1:] {
+				target, err := aliasTarget(c, name, aliasOptions.group)
+				if err != nil {
+					return err
+				}
+				targets = append(targets, target)
+			}
+
+			name := args[0]
+			if aliasOptions.tag {
+				if utils.FindAlias(config.Aliases, name) != nil {
+					return invalidInputError("'%s' is an alias, not a tag", name)
+				}
+
+				tag := utils.FindTag(config.Tags, name)
+				if tag == nil {
+					config.Tags = append(config.Tags, utils.Tag{Name: name})
+					tag = &config.Tags[len(config.Tags)-1]
+				} else if tag.Bridge != "" && tag.Bridge != c.ID() {
+					return invalidInputError("tag '%s' refers to bridge %s, not to bridge %s",
+						tag.Name, tag.Bridge, c.ID())
+				}
+				tag.Bridge = c.ID()
+
+				members := &tag.Lights
+				if aliasOptions.group {
+					members = &tag.Groups
+				}
+			next:
+				for _, target := range targets {
+					for _, member := range *members {
+						if member == target {
+							continue next
+						}
+					}
+					*members = append(*members, target)
+				}
+			} else {
+				if utils.FindTag(config.Tags, name) != nil {
+					return invalidInputError("'%s' is a tag, use --tag to add to it", name)
+				}
+
+				alias := utils.Alias{Name: name, Bridge: c.ID(), Light: targets[0]}
+				if aliasOptions.group {
+					alias = utils.Alias{Name: name, Bridge: c.ID(), Group: targets[0]}
+				}
+
+				if existing := utils.FindAlias(config.Aliases, name); existing != nil {
+					*existing = alias
+				} else {
+					config.Aliases = append(config.Aliases, alias)
+				}
+			}
+
+			return app.saveAliasConfig(config)
+		},
+	}
+
+	// hue-cli alias add --group <alias> <group>
+	cmd.Flags().BoolVar(&aliasOptions.group, "group", false,
+		"the alias refers to a group instead of a light")
+	// hue-cli alias add --tag <tag> <light>...
+	cmd.Flags().BoolVar(&aliasOptions.tag, "tag", false,
+		"add the lights (or groups) to a tag instead")
+
+	return cmd
+}
+
+func newAliasListCommand(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:          "list",
+		Short:        "list the aliases and tags",
+		Long:         "list the aliases and tags, with the current names on the bridge when it is configured",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := app.loadAliasConfig()
+			if err != nil {
+				return err
+			}
+
+			// the names are only shown when the bridge can be reached
+			var inventory *utils.Inventory
+			bridge := ""
+			c, err := app.getClient()
+			if err == nil {
+				bridge = c.ID()
+				inventory, _, err = c.Inventory(false)
+			}
+			if err != nil {
+				app.logger.Verbosef("not showing the names on the bridge: %s\n", err)
+				inventory = &utils.Inventory{}
+			}
+
+			// the names of lights and groups of other bridges are not known
+			inventoryOf := func(id string) *utils.Inventory {
+				if id != "" && id != bridge {
+					return &utils.Inventory{}
+				}
+				return inventory
+			}
+
+			summaries := []AliasSummary{}
+			for _, alias := range config.Aliases {
+				summary := AliasSummary{Name: alias.Name, Type: "alias", Bridge: alias.Bridge}
+				if alias.Kind() == "group" {
+					summary.Groups = aliasTargets(inventoryOf(alias.Bridge).Groups, "group", alias.Group)
+				} else {
+					summary.Lights = aliasTargets(inventoryOf(alias.Bridge).Lights, "light", alias.Light)
+				}
+				summaries = append(summaries, summary)
+			}
+			for _, tag := range config.Tags {
+				summaries = append(summaries, AliasSummary{
+					Name:   tag.Name,
+					Type:   "tag",
+					Bridge: tag.Bridge,
+					Lights: aliasTargets(inventoryOf(tag.Bridge).Lights, "light", tag.Lights...),
+					Groups: aliasTargets(inventoryOf(tag.Bridge).Groups, "group", tag.Groups...),
+				})
+			}
+
+			if app.output.format == "json" {
+				return printJSON(summaries)
+			}
+
+			for _, summary := range summaries {
+				fmt.Println(formatAlias(summary, bridge))
+			}
+
+			return nil
+		},
+	}
+}
+
+// aliasTargets looks up the names of the lights or groups in the items.
+func aliasTargets(items []utils.InventoryItem, kind string, targets ...string) []AliasTarget {
+	result := []AliasTarget{}
+	for _, target := range targets {
+		alias := utils.Alias{Light: target}
+		if kind == "group" {
+			alias = utils.Alias{Group: target}
+		}
+
+		name := ""
+		for _, item := range items {
+			if alias.Matches(kind, item) {
+				name = item.Name
+				break
+			}
+		}
+		result = append(result, AliasTarget{ID: target, Name: name})
+	}
+
+	return result
+}
+
+// formatAlias returns the line for the summary, the bridge is mentioned when
+// it is not the bridge in use.
+func formatAlias(summary AliasSummary, bridge string) string {
+	targets := []string{}
+	add := func(kind string, items []AliasTarget) {
+		for _, target := range items {
+			if target.Name != "" {
+				targets = append(targets, fmt.Sprintf("%s '%s' (%s)", kind, target.Name, target.ID))
+			} else {
+				targets = append(targets, fmt.Sprintf("%s %s", kind, target.ID))
+			}
+		}
+	}
+	add("light", summary.Lights)
+	add("group", summary.Groups)
+
+	kind := summary.Type
+	if summary.Bridge != "" && summary.Bridge != bridge {
+		kind += " on bridge " + summary.Bridge
+	}
+
+	return fmt.Sprintf("%s (%s): %s", summary.Name, kind, strings.Join(targets, ", "))
+}
+
+func newAliasRemoveCommand(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:          "remove <alias|tag>...",
+		Short:        "remove aliases and tags",
+		Long:         "remove aliases and tags from the configuration file",
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			config, err := app.loadAliasConfig()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			names := []string{}
+			for _, alias := range config.Aliases {
+				names = append(names, alias.Name+"\talias")
+			}
+			for _, tag := range config.Tags {
+				names = append(names, tag.Name+"\ttag")
+			}
+
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := app.loadAliasConfig()
+			if err != nil {
+				return err
+			}
+
+			for _, name := range args {
+				removed := false
+				for i, alias := range config.Aliases {
+					if utils.NormalizeName(alias.Name) == utils.NormalizeName(name) {
+						config.Aliases = append(config.Aliases[:i], config.Aliases[i+1:]...)
+						removed = true
+						break
+					}
+				}
+				for i, tag := range config.Tags {
+					if !removed && utils.NormalizeName(tag.Name) == utils.NormalizeName(name) {
+						config.Tags = append(config.Tags[:i], config.Tags[i+1:]...)
+						removed = true
+						break
+					}
+				}
+
+				if !removed {
+					return &Error{Code: ExitNotFound, Err: fmt.Errorf("there is no alias or tag '%s'", name)}
+				}
+			}
+
+			return app.saveAliasConfig(config)
+		},
+	}
+}
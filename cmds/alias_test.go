@@ -0,0 +1,159 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAlias(t *testing.T) {
+	server := startBridge(t)
+
+	config := filepath.Join(t.TempDir(), "hue-cli.yaml")
+	err := os.WriteFile(config, []byte("bridges:\n"+
+		"- ipaddress: "+server.Address()+"\n"+
+		"  user: "+testUser+"\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", config, err)
+	}
+
+	for _, args := range [][]string{
+		{"alias", "add", "standing-desk", "Desk Lamp"},
+		{"alias", "add", "--group", "work", "Office"},
+		{"alias", "add", "--tag", "demo-area", "Ceiling", "Hallway"},
+	} {
+		_, err = execute(t, append(args, "--config="+config)...)
+		if err != nil {
+			t.Fatalf("%s failed: %s", strings.Join(args, " "), err)
+		}
+	}
+
+	out, err := execute(t, "alias", "list", "--config="+config)
+	if err != nil {
+		t.Fatalf("alias list failed: %s", err)
+	}
+	expected := "standing-desk (alias): light 'Desk Lamp' (00:17:88:01:00:00:00:01-0b)\n" +
+		"work (alias): group 'Office' (1)\n" +
+		"demo-area (tag): light 'Ceiling' (00:17:88:01:00:00:00:02-0b), light 'Hallway' (00:17:88:01:00:00:00:03-0b)\n"
+	if out != expected {
+		t.Errorf("unexpected aliases:\n%s", out)
+	}
+
+	// the alias and tag select the lights
+	before := server.Bridge.State().Lights
+	_, err = execute(t, "lights", "--light=standing-desk,demo-area", "--toggle", "--config="+config)
+	if err != nil {
+		t.Fatalf("toggling the lights failed: %s", err)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		if server.Bridge.State().Lights[id].State.On == before[id].State.On {
+			t.Errorf("light %s was not toggled", id)
+		}
+	}
+
+	_, err = execute(t, "alias", "remove", "standing-desk", "demo-area", "--config="+config)
+	if err != nil {
+		t.Fatalf("alias remove failed: %s", err)
+	}
+
+	_, err = execute(t, "alias", "remove", "standing-desk", "--config="+config)
+	if code := ExitCode(err); code != ExitNotFound {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitNotFound, code, err)
+	}
+}
+
+func TestAliasInvalid(t *testing.T) {
+	server := startBridge(t)
+
+	config := filepath.Join(t.TempDir(), "hue-cli.yaml")
+	err := os.WriteFile(config, []byte("bridges:\n"+
+		"- ipaddress: "+server.Address()+"\n"+
+		"  user: "+testUser+"\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", config, err)
+	}
+
+	// an alias refers to a single light
+	_, err = execute(t, "alias", "add", "desk", "Desk Lamp", "Ceiling", "--config="+config)
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+
+	// a number is an index
+	_, err = execute(t, "alias", "add", "7", "Desk Lamp", "--config="+config)
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+}
+
+func TestAliasOtherBridge(t *testing.T) {
+	server := startBridge(t)
+
+	// the alias and tag were added while using another bridge
+	config := filepath.Join(t.TempDir(), "hue-cli.yaml")
+	err := os.WriteFile(config, []byte("bridges:\n"+
+		"- ipaddress: "+server.Address()+"\n"+
+		"  user: "+testUser+"\n"+
+		"aliases:\n"+
+		"- name: standing-desk\n"+
+		"  bridge: 001788fffe000000\n"+
+		"  light: 00:17:88:01:00:00:00:01-0b\n"+
+		"tags:\n"+
+		"- name: demo-area\n"+
+		"  bridge: 001788fffe000000\n"+
+		"  lights:\n"+
+		"  - 00:17:88:01:00:00:00:02-0b\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", config, err)
+	}
+
+	before := server.Bridge.State().Lights
+	for _, name := range []string{"standing-desk", "demo-area"} {
+		_, err = execute(t, "lights", "--light="+name, "--toggle", "--config="+config)
+		if code := ExitCode(err); code != ExitInvalidInput {
+			t.Errorf("%s: expected exit code %d, got %d (%v)", name, ExitInvalidInput, code, err)
+		}
+	}
+	for _, id := range []string{"1", "2"} {
+		if server.Bridge.State().Lights[id].State.On != before[id].State.On {
+			t.Errorf("light %s was toggled", id)
+		}
+	}
+
+	// lights of this bridge can not be added to the tag
+	_, err = execute(t, "alias", "add", "--tag", "demo-area", "Hallway", "--config="+config)
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+
+	out, err := execute(t, "alias", "list", "--config="+config)
+	if err != nil {
+		t.Fatalf("alias list failed: %s", err)
+	}
+	expected := "standing-desk (alias on bridge 001788fffe000000): light 00:17:88:01:00:00:00:01-0b\n" +
+		"demo-area (tag on bridge 001788fffe000000): light 00:17:88:01:00:00:00:02-0b\n"
+	if out != expected {
+		t.Errorf("unexpected aliases:\n%s", out)
+	}
+}
@@ -0,0 +1,256 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type BatchOptions struct {
+	file            string
+	continueOnError bool
+}
+
+func initBatch(app *App, cmd *cobra.Command) {
+	// hue-cli batch -f <file>
+	cmd.AddCommand(newBatchCommand(app))
+}
+
+func newBatchCommand(app *App) *cobra.Command {
+	var batchOptions BatchOptions
+
+	cmd := &cobra.Command{
+		Use:   "batch [-f <file>]",
+		Short: "run the hue-cli commands from a file",
+		Long: "run the hue-cli commands from a file (or stdin), one command per line without \"hue-cli\". " +
+			"Empty lines and lines starting with # are skipped, \"sleep <duration>\" waits. The commands " +
+			"share the configuration, the rate limit and the connection to the bridge and its inventory. " +
+			"The options that are passed to batch apply to all commands.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if app.record.record != "" || app.record.replay != "" {
+				return invalidInputError("--record and --replay can not be used with batch")
+			}
+
+			in := cmd.InOrStdin()
+			if batchOptions.file != "" && batchOptions.file != "-" {
+				f, err := os.Open(batchOptions.file)
+				if err != nil {
+					return invalidInputError("failed to open %s: %s", batchOptions.file, err)
+				}
+				defer f.Close()
+				in = f
+			}
+
+			// the options of batch are passed on to the commands
+			options := []string{}
+			cmd.InheritedFlags().VisitAll(func(flag *pflag.Flag) {
+				if flag.Changed {
+					options = append(options, "--"+flag.Name+"="+flag.Value.String())
+				}
+			})
+
+			err := app.runBatch(in, options, batchOptions.continueOnError)
+
+			// the commands reported what the rate limiter did already
+			app.schedulerStats = app.scheduler.Stats()
+
+			return err
+		},
+	}
+
+	// hue-cli batch -f <file>
+	cmd.Flags().StringVarP(&batchOptions.file, "file", "f", "",
+		"file with the commands (default stdin)")
+	// hue-cli batch --continue-on-error
+	cmd.Flags().BoolVar(&batchOptions.continueOnError, "continue-on-error", false,
+		"run the next commands when a command fails")
+
+	return cmd
+}
+
+// runBatch runs the commands that are read from in, each with a new App that
+// shares the configuration, the rate limiters and the connections to the
+// bridges.
+func (app *App) runBatch(in io.Reader, options []string, continueOnError bool) error {
+	scanner := bufio.NewScanner(in)
+	line := 0
+	commands := 0
+	failed := 0
+	var lastErr error
+
+	for scanner.Scan() {
+		line++
+
+		args, err := splitCommandLine(scanner.Text())
+		if err != nil {
+			return invalidInputError("line %d: %s", line, err)
+		} else if len(args) == 0 {
+			continue
+		}
+		commands++
+
+		err = app.batchCommand(args, options)
+		if err == nil {
+			continue
+		}
+
+		e := classifyError(err, nil)
+		err = &Error{Code: e.Code, Err: fmt.Errorf("line %d: %w", line, err), Bridge: e.Bridge}
+		if !continueOnError {
+			return err
+		}
+
+		app.logger.Warnf("Error: %s\n", err)
+		failed++
+		lastErr = err
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if failed == 0 {
+		return nil
+	} else if failed == commands {
+		return lastErr
+	}
+
+	return &Error{Code: ExitPartialFailure, Err: fmt.Errorf("%d of %d commands failed", failed, commands)}
+}
+
+// batchCommand runs a single command of a batch.
+func (app *App) batchCommand(args []string, options []string) error {
+	switch args[0] {
+	case "sleep":
+		if len(args) != 2 {
+			return invalidInputError("sleep needs a duration, like sleep 2s")
+		}
+
+		delay, err := parseSleep(args[1])
+		if err != nil {
+			return err
+		}
+
+		app.logger.Verbosef("sleeping %s\n", delay)
+		if !app.dryRun.dryRun {
+			time.Sleep(delay)
+		}
+		return nil
+
+	case "batch":
+		return invalidInputError("batch can not be nested")
+	}
+
+	batch := NewApp()
+	batch.clients = app.clients
+	batch.configs = app.configs
+	batch.transports = app.transports
+	batch.root.SetArgs(append(options, args...))
+
+	err := batch.root.Execute()
+	if err != nil {
+		// the errors of the bridge are only known to the App of the line
+		return batch.classifyError(err)
+	}
+
+	return nil
+}
+
+// parseSleep reads a duration like 500ms, or a number of seconds.
+func parseSleep(value string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	delay, err := time.ParseDuration(value)
+	if err != nil || delay < 0 {
+		return 0, invalidInputError("%q is not a duration, like 2s or 500ms", value)
+	}
+
+	return delay, nil
+}
+
+// splitCommandLine splits the line in words like a shell does, with single
+// and double quotes and backslashes. A line that starts with # is a comment.
+func splitCommandLine(line string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range strings.TrimSpace(line) {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '#' && !inWord:
+			return words, nil
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("the quote is not closed")
+	} else if escaped {
+		return nil, errors.New("the line ends with a backslash")
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
@@ -0,0 +1,156 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeBatch writes the commands to a file for batch.
+func writeBatch(t *testing.T, commands string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "commands.txt")
+	err := os.WriteFile(file, []byte(commands), 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", file, err)
+	}
+
+	return file
+}
+
+func TestBatch(t *testing.T) {
+	server := startBridge(t)
+	file := writeBatch(t, "# switch the desk lamp twice\n"+
+		"lights --light=\"desk lamp\" --toggle\n"+
+		"\n"+
+		"sleep 10ms\n"+
+		"lights --light='Desk Lamp' --toggle  # back again\n"+
+		"lights --light=Hallway --toggle\n")
+
+	before := server.Bridge.State().Lights
+	app := NewApp()
+	_, err := executeApp(t, app, "batch", "-f", file,
+		"--bridge="+server.Address(), "--username="+testUser)
+	if err != nil {
+		t.Fatalf("batch failed: %s", err)
+	}
+
+	after := server.Bridge.State().Lights
+	if after["1"].State.On != before["1"].State.On || after["3"].State.On == before["3"].State.On {
+		t.Errorf("unexpected state of the lights: %+v", after)
+	}
+
+	// the commands share the connection
+	if len(app.clients) != 1 {
+		t.Errorf("expected a single connection, got %d", len(app.clients))
+	}
+}
+
+func TestBatchShared(t *testing.T) {
+	server := startBridge(t)
+
+	config := filepath.Join(t.TempDir(), "hue-cli.yaml")
+	err := os.WriteFile(config, []byte("bridges:\n"+
+		"- ipaddress: "+server.Address()+"\n"+
+		"  user: "+testUser+"\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", config, err)
+	}
+
+	file := writeBatch(t, ""+
+		"lights --light=Hallway --toggle\n"+
+		"alias add standing-desk 'Desk Lamp'\n"+
+		"lights --light=standing-desk --toggle\n"+
+		"lights --light=Hallway --toggle\n")
+
+	before := server.Bridge.State().Lights
+	app := NewApp()
+	_, err = executeApp(t, app, "batch", "-f", file, "--config="+config, "--light-rate=5")
+	if err != nil {
+		t.Fatalf("batch failed: %s", err)
+	}
+
+	// the alias is used right after it was added
+	after := server.Bridge.State().Lights
+	if after["1"].State.On == before["1"].State.On || after["3"].State.On != before["3"].State.On {
+		t.Errorf("unexpected state of the lights: %+v", after)
+	}
+
+	// the commands share the configuration and the rate limiter
+	if len(app.configs) != 1 || len(app.transports) != 1 {
+		t.Errorf("expected a single configuration and transport, got %d and %d", len(app.configs), len(app.transports))
+	}
+	if stats := app.scheduler.Stats(); stats.Throttled == 0 {
+		t.Errorf("the updates of the commands were not throttled: %+v", stats)
+	}
+}
+
+func TestBatchError(t *testing.T) {
+	server := startBridge(t)
+	file := writeBatch(t, ""+
+		"lights --light=Hallway --toggle\n"+
+		"lights --light=Kitchen --toggle\n"+
+		"lights --light=Hallway --toggle\n")
+
+	before := server.Bridge.State().Lights["3"].State.On
+	_, err := execute(t, "batch", "-f", file, "--bridge="+server.Address(), "--username="+testUser)
+	if code := ExitCode(err); code != ExitNotFound || !strings.HasPrefix(err.Error(), "line 2: ") {
+		t.Errorf("expected exit code %d for line 2, got %d (%v)", ExitNotFound, code, err)
+	}
+	if server.Bridge.State().Lights["3"].State.On == before {
+		t.Errorf("the commands after the failure were run")
+	}
+
+	_, err = execute(t, "batch", "-f", file, "--continue-on-error", "--bridge="+server.Address(), "--username="+testUser)
+	if code := ExitCode(err); code != ExitPartialFailure {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitPartialFailure, code, err)
+	}
+	if server.Bridge.State().Lights["3"].State.On == before {
+		t.Errorf("the commands after the failure were not run")
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	for line, expected := range map[string][]string{
+		"":                                  {},
+		"  # a comment":                     {},
+		"lights --light=1":                  {"lights", "--light=1"},
+		`lights --light="desk lamp" # desk`: {"lights", "--light=desk lamp"},
+		`recall-scene --scene='Relax' a#b`:  {"recall-scene", "--scene=Relax", "a#b"},
+		`lights --light=desk\ lamp "" x`:    {"lights", "--light=desk lamp", "", "x"},
+		`lights --light="say \"hi\"" 'a\b'`: {"lights", `--light=say "hi"`, `a\b`},
+	} {
+		words, err := splitCommandLine(line)
+		if err != nil || !reflect.DeepEqual(words, expected) {
+			t.Errorf("splitCommandLine(%q) = %q (%v)", line, words, err)
+		}
+	}
+
+	_, err := splitCommandLine(`lights --light="desk lamp`)
+	if err == nil {
+		t.Errorf("expected an error for the open quote")
+	}
+}
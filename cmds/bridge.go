@@ -23,124 +23,285 @@ package cmds
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
 
-	hue "github.com/collinux/GoHue"
 	"github.com/spf13/cobra"
 
+	"github.com/nixpanic/hue-cli/client"
 	"github.com/nixpanic/hue-cli/utils"
 )
 
 type BridgeOptions struct {
 	ipaddress string
 	username  string
-}
+	backend   string
+	v2Address string
+	config    string
+	// userSecret refers to where the username is stored, when it is not
+	// in the configuration file
+	userSecret string
 
-var (
-	bridgeOptions BridgeOptions
-)
+	// https sends all requests over HTTPS, the certificate of the bridge
+	// is pinned with the bridgeID and fingerprint
+	https       bool
+	bridgeID    string
+	fingerprint string
 
-func addBridgeOptions(cmd *cobra.Command) {
+	// aliases, tags and macros from the configuration file
+	aliases []utils.Alias
+	tags    []utils.Tag
+	macros  []utils.Macro
+}
+
+func initBridge(app *App, cmd *cobra.Command) {
 	// hue-cli --bridge=<ip-address>
-	cmd.Flags().StringVar(&bridgeOptions.ipaddress, "bridge", bridgeOptions.ipaddress,
-		"IP-address of the bridge (optional)")
+	cmd.PersistentFlags().StringVar(&app.bridge.ipaddress, "bridge", "",
+		"IP-address of the bridge, or its name in the configuration (optional)")
 	// hue-cli --username=<username>
-	cmd.Flags().StringVar(&bridgeOptions.username, "username", bridgeOptions.username,
+	cmd.PersistentFlags().StringVar(&app.bridge.username, "username", "",
 		"username for authentication to the bridge (optional)")
-}
+	// hue-cli --backend=<hue|deconz|diyhue>
+	cmd.PersistentFlags().StringVar(&app.bridge.backend, "backend", client.DefaultBackend,
+		"type of gateway: "+strings.Join(client.Backends(), ", "))
+	cmd.RegisterFlagCompletionFunc("backend", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return client.Backends(), cobra.ShellCompDirectiveNoFileComp
+	})
+	// hue-cli --v2-address=<ip-address:port>
+	cmd.PersistentFlags().StringVar(&app.bridge.v2Address, "v2-address", "",
+		"address of HTTPS and the CLIP v2 API, when it is not on the HTTPS port of the bridge (optional)")
+	// hue-cli --https
+	cmd.PersistentFlags().BoolVar(&app.bridge.https, "https", false,
+		"send all requests to the bridge over HTTPS, its certificate is pinned on first use")
+	// hue-cli --config=<hue-cli.yaml>
+	cmd.PersistentFlags().StringVar(&app.bridge.config, "config", "hue-cli.yaml",
+		"configuration file with the bridge and username")
 
-func initBridge(cmd *cobra.Command) {
 	// hue-cli bridge-config
-	cmd.AddCommand(cmdBridgeConfig)
-	addBridgeOptions(cmdBridgeConfig)
-	cmdBridgeConfig.SilenceUsage = true
+	cmd.AddCommand(newBridgeConfigCommand(app))
+}
+
+// skipConfigAnnotation marks commands that read the configuration file
+// themselves, loadConfig does not fail on an invalid file for them.
+const skipConfigAnnotation = "hue-cli/skip-config"
 
-	// read the hue-cli.yaml configfile
-	config, err := utils.NewConfigFile("hue-cli.yaml")
+// loadConfig reads the configuration file, the options that are passed on
+// the command line take precedence. A missing configuration file is only an
+// error when --config is passed, an invalid one is always an error.
+func (app *App) loadConfig(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+
+	if _, ok := cmd.Annotations[skipConfigAnnotation]; ok {
+		return nil
+	}
+
+	config, err := app.readConfig()
 	if err != nil {
-		// could not find the hue-cli.yaml
-		// TODO: output for debugging only
-		fmt.Printf("failed to load hue-cli.yaml: %s\n", err)
-	} else {
-		if len(config.Bridges) > 0 {
-			bridgeOptions.ipaddress = config.Bridges[0].IPAddress
-			bridgeOptions.username = config.Bridges[0].User
+		var configErr *utils.ConfigError
+		if errors.As(err, &configErr) {
+			return invalidInputError("%s", err)
+		} else if flags.Changed("config") {
+			return invalidInputError("failed to load %s: %s", app.bridge.config, err)
 		}
+
+		app.logger.Verbosef("failed to load %s: %s\n", app.bridge.config, err)
+		return nil
 	}
-}
 
-func getBridge() (*hue.Bridge, error) {
-	// TODO: check for (--bridge && --username) || --config
-	if bridgeOptions.ipaddress == "" {
-		return nil, errors.New("--bridge=<ip-address> is required (for now)")
-	} else if bridgeOptions.username == "" {
-		return nil, errors.New("--username=<username> is required (for now)")
+	app.bridge.aliases = config.Aliases
+	app.bridge.tags = config.Tags
+	app.bridge.macros = config.Macros
+
+	if len(config.Bridges) == 0 {
+		return nil
 	}
 
-	// if we know the IP-addres, we dont do any discovery
-	bridge, err := hue.NewBridge(bridgeOptions.ipaddress)
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("failed to connect to bridge %s (%s)", bridgeOptions.ipaddress, err))
+	// --bridge can be the name of a bridge in the configuration
+	bc := config.Bridges[0]
+	if named := config.Bridge(app.bridge.ipaddress); named != nil {
+		bc = *named
+		app.bridge.ipaddress = bc.IPAddress
+	} else if !flags.Changed("bridge") {
+		app.bridge.ipaddress = bc.IPAddress
+	}
+	// the pinned certificate is only for the configured bridge
+	if app.bridge.ipaddress == bc.IPAddress {
+		app.bridge.bridgeID = bc.BridgeID
+		app.bridge.fingerprint = bc.Fingerprint
+	}
+	if !flags.Changed("username") {
+		app.bridge.username = bc.User
+		app.bridge.userSecret = bc.UserSecret
+	}
+	if bc.Backend != "" && !flags.Changed("backend") {
+		app.bridge.backend = bc.Backend
+	}
+	if bc.V2Address != "" && !flags.Changed("v2-address") {
+		app.bridge.v2Address = bc.V2Address
+	}
+	if bc.HTTPS && !flags.Changed("https") {
+		app.bridge.https = true
+	}
+	if bc.Timeout != 0 && !flags.Changed("timeout") {
+		app.retry.timeout = bc.Timeout
+	}
+	if bc.Retries != 0 && !flags.Changed("retries") {
+		app.retry.retries = bc.Retries
+	}
+	if bc.Backoff != 0 && !flags.Changed("backoff") {
+		app.retry.backoff = bc.Backoff
+	}
+
+	return nil
+}
+
+// readConfig returns the configuration file, it is only read once for the
+// commands of a batch.
+func (app *App) readConfig() (*utils.ConfigFile, error) {
+	if config, ok := app.configs[app.bridge.config]; ok {
+		return config, nil
 	}
 
-	err = bridge.Login(bridgeOptions.username)
+	config, err := client.LoadConfig(app.bridge.config)
 	if err != nil {
 		return nil, err
 	}
+	app.configs[app.bridge.config] = config
 
-	return bridge, nil
+	return config, nil
 }
 
-var cmdBridgeConfig = &cobra.Command{
-	Use:   "bridge-config",
-	Short: "detailed bridge configuration",
-	Long:  "detailed bridge configuration",
+// saveConfig writes the changed configuration file, the next command of a
+// batch reads it again. The clients have the aliases and tags of the old
+// configuration, they connect again too.
+func (app *App) saveConfig(config *utils.ConfigFile) error {
+	delete(app.configs, app.bridge.config)
+	for key := range app.clients {
+		delete(app.clients, key)
+	}
 
+	return config.Save(app.bridge.config)
+}
 
-	RunE: func(cmd *cobra.Command, args []string) error {
-		bridge, err := getBridge()
-		if err != nil {
-			return err
-		}
+// getClient logs in on the bridge that is selected with the options or in
+// the configuration file.
+func (app *App) getClient() (*client.Client, error) {
+	// the same bridge and user share the connection, the username is set
+	// below when it is a secret
+	user := app.bridge.username
+	if app.bridge.userSecret != "" {
+		user = app.bridge.userSecret
+	}
+	key := strings.Join([]string{app.bridge.ipaddress, user, app.bridge.backend, app.bridge.v2Address,
+		strconv.FormatBool(app.bridge.https), strconv.FormatBool(app.dryRun.dryRun)}, "|")
+	if c, ok := app.clients[key]; ok {
+		app.inventoryBridge = c.ID()
+		return c, nil
+	}
 
-		err = bridge.GetConfig()
+	// TODO: check for (--bridge && --username) || --config
+	if app.bridge.username == "" && app.bridge.userSecret != "" {
+		username, err := utils.LookupSecret(app.bridge.userSecret)
 		if err != nil {
-			return err
+			return nil, &Error{Code: ExitAuth, Err: err}
 		}
+		app.bridge.username = username
+	}
 
-		fmt.Println(bridgeConfigToString(bridge))
+	if app.bridge.ipaddress == "" {
+		return nil, invalidInputError("--bridge=<ip-address> is required (for now)")
+	} else if app.bridge.username == "" {
+		return nil, invalidInputError("--username=<username> is required (for now)")
+	}
 
-		return nil
-	},
+	_, err := client.LookupBackend(app.bridge.backend)
+	if err != nil {
+		return nil, invalidInputError("%s", err)
+	}
+
+	c, err := client.New(app.bridge.ipaddress, app.bridge.username, client.Options{
+		Backend:  app.bridge.backend,
+		Logger:   app.logger,
+		CacheTTL: app.cache.ttl,
+		NoCache:  app.cache.noCache,
+
+		V2Address: app.bridge.v2Address,
+		// requests pass the transports that are set up for the options
+		HTTPClient: &http.Client{},
+		// the event stream can not pass them, they read the complete
+		// response and time out
+		StreamHTTPClient: &http.Client{Transport: app.bridgeTransport},
+
+		Aliases: app.bridge.aliases,
+		Tags:    app.bridge.tags,
+
+		DryRun: app.dryRun.dryRun,
+	})
+	if err != nil {
+		return nil, err
+	}
+	app.inventoryBridge = c.ID()
+	app.clients[key] = c
+
+	return c, nil
+}
+
+// savePin stores the certificate of the bridge that was trusted on first
+// use in the configuration file, when the bridge is configured there.
+func (app *App) savePin(pin client.Pin) {
+	app.logger.Warnf("trusting the certificate of bridge %s on first use (bridge ID %s, fingerprint %s)\n",
+		app.bridge.ipaddress, pin.BridgeID, pin.Fingerprint)
+
+	config, err := client.LoadConfig(app.bridge.config)
+	bridge := -1
+	if err == nil {
+		for i, bc := range config.Bridges {
+			if bc.IPAddress == app.bridge.ipaddress {
+				bridge = i
+				break
+			}
+		}
+	}
+	if bridge == -1 {
+		app.logger.Warnf("add \"bridgeid: %s\" and \"fingerprint: %s\" to the bridge in the configuration to pin the certificate\n",
+			pin.BridgeID, pin.Fingerprint)
+		return
+	}
+
+	config.Bridges[bridge].BridgeID = pin.BridgeID
+	config.Bridges[bridge].Fingerprint = pin.Fingerprint
+	err = app.saveConfig(config)
+	if err != nil {
+		app.logger.Warnf("failed to pin the certificate in %s: %s\n", app.bridge.config, err)
+	}
 }
 
-func bridgeConfigToString(bridge *hue.Bridge) string {
-	s := fmt.Sprintf("Bridge:\n"+
-		"\tIP-address: %s",
-		bridge.IPAddress)
-
-	if bridge.Info.Device.DeviceType != "" {
-		s += fmt.Sprintf("\n\tDevice Information:\n"+
-			"\t\tDeviceType: %s\n"+
-			"\t\tFriendlyName: %s\n"+
-			"\t\tManufacturer: %s\n"+
-			"\t\tManufacturerURL: %s\n"+
-			"\t\tModelDescription: %s\n"+
-			"\t\tModelName: %s\n"+
-			"\t\tModelNumber: %s\n"+
-			"\t\tModelURL: %s\n"+
-			"\t\tSerialNumber: %s\n"+
-			"\t\tUDN: %s",
-			bridge.Info.Device.DeviceType,
-			bridge.Info.Device.FriendlyName,
-			bridge.Info.Device.Manufacturer,
-			bridge.Info.Device.ManufacturerURL,
-			bridge.Info.Device.ModelDescription,
-			bridge.Info.Device.ModelName,
-			bridge.Info.Device.ModelNumber,
-			bridge.Info.Device.ModelURL,
-			bridge.Info.Device.SerialNumber,
-			bridge.Info.Device.UDN)
-	}
-
-	return s
+func newBridgeConfigCommand(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:          "bridge-config",
+		Short:        "detailed bridge configuration",
+		Long:         "detailed bridge configuration",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			err = c.Config()
+			if err != nil {
+				return err
+			}
+
+			if app.output.format == "json" {
+				return printJSON(client.NewBridgeSummary(c.Bridge))
+			}
+
+			fmt.Println(client.FormatBridge(c.Bridge))
+
+			return nil
+		},
+	}
 }
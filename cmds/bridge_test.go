@@ -0,0 +1,152 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nixpanic/hue-cli/client"
+)
+
+func TestBridgeConfig(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "bridge-config")
+	if err != nil {
+		t.Fatalf("bridge-config failed: %s", err)
+	}
+
+	if !strings.Contains(out, "IP-address: "+server.Address()) {
+		t.Errorf("IP-address missing in output:\n%s", out)
+	}
+	if !strings.Contains(out, "ModelNumber: BSB002") {
+		t.Errorf("device information missing in output:\n%s", out)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	server := startBridge(t)
+
+	config := filepath.Join(t.TempDir(), "hue-cli.yaml")
+	err := os.WriteFile(config, []byte("bridges:\n"+
+		"- ipaddress: "+server.Address()+"\n"+
+		"  user: "+testUser+"\n"+
+		"  backend: diyhue\n"+
+		"  timeout: 3s\n"+
+		"  retries: 5\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", config, err)
+	}
+
+	// options on the command line take precedence over the config file
+	app := NewApp()
+	_, err = executeApp(t, app, "bridge-config", "--config="+config, "--retries=1")
+	if err != nil {
+		t.Fatalf("bridge-config failed: %s", err)
+	}
+
+	expected := RetryOptions{timeout: 3 * time.Second, retries: 1, backoff: 500 * time.Millisecond}
+	if app.bridge.ipaddress != server.Address() || app.bridge.backend != "diyhue" || app.retry != expected {
+		t.Errorf("unexpected options: %+v %+v", app.bridge, app.retry)
+	}
+
+	// a config file that is passed explicitly must exist
+	_, err = execute(t, "bridge-config", "--config="+filepath.Join(t.TempDir(), "missing.yaml"))
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+}
+
+func TestLoadConfigBridgeName(t *testing.T) {
+	server := startBridge(t)
+
+	config := filepath.Join(t.TempDir(), "hue-cli.yaml")
+	err := os.WriteFile(config, []byte("version: 1\n"+
+		"bridges:\n"+
+		"- name: home\n"+
+		"  ipaddress: 192.0.2.1\n"+
+		"  user: someone-else\n"+
+		"- name: office\n"+
+		"  ipaddress: "+server.Address()+"\n"+
+		"  user: "+testUser+"\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", config, err)
+	}
+
+	app := NewApp()
+	_, err = executeApp(t, app, "bridge-config", "--config="+config, "--bridge=Office")
+	if err != nil {
+		t.Fatalf("bridge-config failed: %s", err)
+	}
+
+	if app.bridge.ipaddress != server.Address() || app.bridge.username != testUser {
+		t.Errorf("the bridge named office was not selected: %+v", app.bridge)
+	}
+}
+
+func TestHTTPSPinning(t *testing.T) {
+	server := startBridge(t)
+
+	config := filepath.Join(t.TempDir(), "hue-cli.yaml")
+	err := os.WriteFile(config, []byte("bridges:\n"+
+		"- ipaddress: "+server.Address()+"\n"+
+		"  user: "+testUser+"\n"+
+		"  v2address: "+server.TLSAddress()+"\n"+
+		"  https: true\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", config, err)
+	}
+
+	// the certificate is pinned on first use
+	_, err = execute(t, "list-lights", "--config="+config)
+	if err != nil {
+		t.Fatalf("list-lights failed: %s", err)
+	}
+
+	pinned, err := client.LoadConfig(config)
+	if err != nil {
+		t.Fatalf("failed to load %s: %s", config, err)
+	}
+	bc := pinned.Bridges[0]
+	if bc.BridgeID != "001788FFFE23BFC2" || bc.Fingerprint != client.Fingerprint(server.TLS.Certificate()) || !bc.HTTPS {
+		t.Errorf("the certificate was not pinned: %+v", bc)
+	}
+
+	// another certificate is refused
+	bc.Fingerprint = strings.Repeat("0", 64)
+	pinned.Bridges[0] = bc
+	err = pinned.Save(config)
+	if err != nil {
+		t.Fatalf("failed to save %s: %s", config, err)
+	}
+
+	_, err = execute(t, "list-lights", "--config="+config)
+	if code := ExitCode(err); code != ExitAuth {
+		t.Fatalf("expected exit code %d, got %d (%v)", ExitAuth, code, err)
+	}
+	if !strings.Contains(err.Error(), "has changed") {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,159 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+type CacheOptions struct {
+	ttl     time.Duration
+	noCache bool
+}
+
+func initCache(app *App, cmd *cobra.Command) {
+	// hue-cli --cache-ttl=<duration>
+	cmd.PersistentFlags().DurationVar(&app.cache.ttl, "cache-ttl", client.DefaultCacheTTL,
+		"how long the cached names of lights, groups, sensors and scenes are used")
+	// hue-cli --no-cache
+	cmd.PersistentFlags().BoolVar(&app.cache.noCache, "no-cache", false,
+		"do not use the cached names of lights, groups, sensors and scenes")
+
+	// hue-cli cache
+	cmdCache := &cobra.Command{
+		Use:   "cache",
+		Short: "manage the cached inventory of bridges",
+		Long:  "manage the locally cached names of lights, groups, sensors and scenes of bridges",
+	}
+	cmd.AddCommand(cmdCache)
+
+	// hue-cli cache refresh
+	cmdCache.AddCommand(newCacheRefreshCommand(app))
+
+	// hue-cli cache clear
+	cmdCache.AddCommand(newCacheClearCommand(app))
+}
+
+func newCacheRefreshCommand(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:          "refresh",
+		Short:        "refresh the cached inventory of the bridge",
+		Long:         "read the lights, groups, sensors and scenes from the bridge, and cache them",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			inventory, err := c.RefreshInventory()
+			if err != nil {
+				return err
+			}
+
+			app.logger.Infof("cached %d lights, %d groups, %d sensors and %d scenes of bridge %s\n",
+				len(inventory.Lights), len(inventory.Groups), len(inventory.Sensors),
+				len(inventory.Scenes), inventory.Bridge)
+
+			return nil
+		},
+	}
+}
+
+func newCacheClearCommand(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:          "clear",
+		Short:        "remove the cached inventory of all bridges",
+		Long:         "remove the cached inventory of all bridges",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := utils.ClearInventories()
+			if err != nil {
+				return err
+			}
+
+			app.logger.Infof("removed the cached inventory of all bridges\n")
+
+			return nil
+		},
+	}
+}
+
+// inventoryTransport removes the cached inventory when a request adds,
+// renames or deletes a light, group, sensor or scene.
+type inventoryTransport struct {
+	transport http.RoundTripper
+	app       *App
+}
+
+func (it *inventoryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := it.transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || !changesInventory(req) {
+		return resp, err
+	}
+
+	if bridge := it.app.inventoryBridge; bridge != "" {
+		it.app.logger.Verbosef("removing the cached inventory of bridge %s\n", bridge)
+		utils.RemoveInventory(bridge)
+
+		for _, c := range it.app.clients {
+			if c.ID() == bridge {
+				c.ForgetInventory()
+			}
+		}
+	}
+
+	return resp, nil
+}
+
+// changesInventory returns true for requests that add, rename or delete a
+// resource. Changing the state of lights and groups does not count.
+func changesInventory(req *http.Request) bool {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "api" {
+		return false
+	}
+
+	switch parts[2] {
+	case "lights", "groups", "sensors", "scenes":
+	default:
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodPost, http.MethodDelete:
+		return true
+	case http.MethodPut:
+		// PUT /api/<username>/<resource>/<id> sets the name
+		return len(parts) == 4
+	}
+
+	return false
+}
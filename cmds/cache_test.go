@@ -0,0 +1,144 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/huetest"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// cachedInventory returns the inventory that is cached for the fake bridge.
+func cachedInventory(t *testing.T) *utils.Inventory {
+	t.Helper()
+
+	dir, _ := utils.InventoryDir()
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if len(matches) != 1 {
+		return nil
+	}
+
+	inventory, err := utils.LoadInventory(strings.TrimSuffix(filepath.Base(matches[0]), ".yaml"))
+	if err != nil {
+		t.Fatalf("failed to load the inventory: %s", err)
+	}
+
+	return inventory
+}
+
+// renameLight changes the name of a light without hue-cli.
+func renameLight(t *testing.T, server *huetest.Server, index, name string) {
+	req, _ := http.NewRequest(http.MethodPut, server.URL+"/api/"+testUser+"/lights/"+index,
+		strings.NewReader(`{"name":"`+name+`"}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to rename light: %s", err)
+	}
+	resp.Body.Close()
+}
+
+func TestCacheRefresh(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "cache", "refresh")
+	if err != nil {
+		t.Fatalf("cache refresh failed: %s", err)
+	}
+	if !strings.Contains(out, "cached 3 lights, 1 groups, 3 sensors and 1 scenes") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	inventory := cachedInventory(t)
+	if inventory == nil || len(inventory.Lights) != 3 || inventory.Lights[0].Name != "Desk Lamp" {
+		t.Errorf("unexpected inventory: %+v", inventory)
+	}
+}
+
+func TestCacheOutOfDate(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "cache", "refresh")
+	if err != nil {
+		t.Fatalf("cache refresh failed: %s", err)
+	}
+
+	renameLight(t, server, "2", "Kitchen")
+
+	// the new name is not cached yet
+	_, err = runHueCli(t, server, "lights", "--light=Kitchen", "--toggle")
+	if err != nil {
+		t.Fatalf("lights --light=Kitchen failed: %s", err)
+	}
+	if !server.Bridge.State().Lights["2"].State.On {
+		t.Error("light was not switched on")
+	}
+
+	// the old name points to the light with the new name
+	_, err = runHueCli(t, server, "lights", "--light=Ceiling", "--toggle")
+	if code := ExitCode(err); code != ExitNotFound {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitNotFound, code, err)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "toggle-group", "--name=Office")
+	if err != nil {
+		t.Fatalf("toggle-group failed: %s", err)
+	}
+
+	// changing the state keeps the inventory
+	if cachedInventory(t) == nil {
+		t.Fatal("inventory was not cached")
+	}
+
+	_, err = runHueCli(t, server, "delete-group", "--name=Office")
+	if err != nil {
+		t.Fatalf("delete-group failed: %s", err)
+	}
+
+	if cachedInventory(t) != nil {
+		t.Error("inventory was not removed after deleting a group")
+	}
+}
+
+func TestCacheClear(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "cache", "refresh")
+	if err != nil {
+		t.Fatalf("cache refresh failed: %s", err)
+	}
+
+	_, err = execute(t, "cache", "clear")
+	if err != nil {
+		t.Fatalf("cache clear failed: %s", err)
+	}
+
+	if cachedInventory(t) != nil {
+		t.Error("inventory was not removed")
+	}
+}
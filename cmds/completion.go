@@ -0,0 +1,205 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// roomClasses are the classes that the bridge accepts for a room.
+var roomClasses = []string{
+	"Living room", "Kitchen", "Dining", "Bedroom", "Kids bedroom",
+	"Bathroom", "Nursery", "Recreation", "Office", "Gym", "Hallway",
+	"Toilet", "Front door", "Garage", "Terrace", "Garden", "Driveway",
+	"Carport", "Other",
+}
+
+func initCompletion(app *App, cmd *cobra.Command) {
+	// hue-cli completion bash|zsh|fish|powershell
+	cmd.AddCommand(newCompletionCommand())
+	cmd.CompletionOptions.DisableDefaultCmd = true
+}
+
+func newCompletionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion bash|zsh|fish|powershell",
+		Short: "generate the shell completion script",
+		Long: "generate the script for completion of commands, options and the names of lights,\n" +
+			"groups, sensors and scenes in the given shell, for example:\n\n" +
+			"\tsource <(hue-cli completion bash)",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+
+			switch args[0] {
+			case "bash":
+				return cmd.Root().GenBashCompletionV2(out, true)
+			case "zsh":
+				return cmd.Root().GenZshCompletion(out)
+			case "fish":
+				return cmd.Root().GenFishCompletion(out, true)
+			case "powershell":
+				return cmd.Root().GenPowerShellCompletionWithDesc(out)
+			}
+
+			return invalidInputError("shell %s is not supported", args[0])
+		},
+	}
+}
+
+// The items of the inventory that can be completed.
+func inventoryLights(inventory *utils.Inventory) []utils.InventoryItem  { return inventory.Lights }
+func inventoryGroups(inventory *utils.Inventory) []utils.InventoryItem  { return inventory.Groups }
+func inventorySensors(inventory *utils.Inventory) []utils.InventoryItem { return inventory.Sensors }
+func inventoryScenes(inventory *utils.Inventory) []utils.InventoryItem  { return inventory.Scenes }
+
+// completionTimeout is how long completion waits for the bridge, the cached
+// inventory is used when the bridge does not answer in time.
+var completionTimeout = 2 * time.Second
+
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completeInventory returns a completion function for the names (or IDs)
+// of the items in the inventory of the bridge. The cached inventory is used
+// when it is available.
+func (app *App) completeInventory(items func(*utils.Inventory) []utils.InventoryItem, ids bool) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		inventory, err := app.completionInventory(cmd)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		completions := []string{}
+		for _, item := range items(inventory) {
+			if ids {
+				completions = append(completions, item.ID+"\t"+item.Name)
+			} else {
+				completions = append(completions, item.Name+"\t"+item.ID)
+			}
+		}
+
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completionInventory returns the inventory of the bridge for completion. The
+// cached inventory is used when the bridge can not be reached in time.
+func (app *App) completionInventory(cmd *cobra.Command) (*utils.Inventory, error) {
+	// the persistent hooks do not run for completions
+	err := app.loadConfig(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// the shell waits for the completions, do not retry slow requests
+	if app.retry.timeout <= 0 || app.retry.timeout > completionTimeout {
+		app.retry.timeout = completionTimeout
+	}
+	app.retry.retries = 0
+
+	err = app.setupTransport()
+	if err != nil {
+		return nil, err
+	}
+
+	// GoHue can not be cancelled, stop waiting for it instead
+	result := make(chan *utils.Inventory, 1)
+	go func() {
+		c, err := app.getClient()
+		if err != nil {
+			result <- nil
+			return
+		}
+
+		inventory, _, err := c.Inventory(false)
+		if err != nil {
+			result <- nil
+			return
+		}
+		result <- inventory
+	}()
+
+	select {
+	case inventory := <-result:
+		if inventory != nil {
+			return inventory, nil
+		}
+	case <-time.After(completionTimeout):
+	}
+
+	return utils.FindInventory(app.bridge.ipaddress)
+}
+
+// completeList completes the last item of a comma separated list.
+func completeList(complete completionFunc) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		prefix := ""
+		if i := strings.LastIndex(toComplete, ","); i != -1 {
+			prefix = toComplete[:i+1]
+		}
+
+		completions, directive := complete(cmd, args, toComplete[len(prefix):])
+		for i := range completions {
+			completions[i] = prefix + completions[i]
+		}
+
+		return completions, directive | cobra.ShellCompDirectiveNoSpace
+	}
+}
+
+// completeRoomClasses completes the classes of rooms.
+func completeRoomClasses(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return roomClasses, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSnapshots completes the names of the saved snapshots.
+func completeSnapshots(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	dir, err := utils.SnapshotDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	names := []string{}
+	for _, file := range files {
+		names = append(names, strings.TrimSuffix(filepath.Base(file), ".yaml"))
+	}
+	sort.Strings(names)
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,155 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nixpanic/hue-cli/huetest"
+)
+
+// complete returns the completions that hue-cli offers for the arguments,
+// the last argument is the word that is completed.
+func complete(t *testing.T, server *huetest.Server, args ...string) []string {
+	t.Helper()
+
+	args = append([]string{"__complete", args[0], "--bridge=" + server.Address(), "--username=" + testUser}, args[1:]...)
+	out, err := execute(t, args...)
+	if err != nil {
+		t.Fatalf("completion failed: %s", err)
+	}
+
+	// the last line is the directive for the shell
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+
+	return lines[:len(lines)-1]
+}
+
+func TestCompleteLights(t *testing.T) {
+	server := startBridge(t)
+
+	completions := complete(t, server, "lights", "--light", "")
+	expected := []string{"Desk Lamp\t1", "Ceiling\t2", "Hallway\t3"}
+	if strings.Join(completions, "|") != strings.Join(expected, "|") {
+		t.Errorf("unexpected completions: %q", completions)
+	}
+
+	// the list of lights is comma separated
+	completions = complete(t, server, "lights", "--light", "Desk Lamp,")
+	if len(completions) != 3 || completions[1] != "Desk Lamp,Ceiling\t2" {
+		t.Errorf("unexpected completions: %q", completions)
+	}
+}
+
+func TestCompleteSensorIndex(t *testing.T) {
+	server := startBridge(t)
+
+	completions := complete(t, server, "sensor-set", "--index", "")
+	if len(completions) != 3 || completions[0] != "1\tDaylight" {
+		t.Errorf("unexpected completions: %q", completions)
+	}
+}
+
+func TestCompleteGroupsAndScenes(t *testing.T) {
+	server := startBridge(t)
+
+	completions := complete(t, server, "toggle-group", "--name", "")
+	if len(completions) != 1 || completions[0] != "Office\t1" {
+		t.Errorf("unexpected completions: %q", completions)
+	}
+
+	completions = complete(t, server, "recall-scene", "--scene", "")
+	if len(completions) != 1 || completions[0] != "Relax\trelax" {
+		t.Errorf("unexpected completions: %q", completions)
+	}
+}
+
+func TestCompleteOffline(t *testing.T) {
+	server := startBridge(t)
+
+	// the inventory gets cached
+	completions := complete(t, server, "lights", "--light", "")
+	if len(completions) != 3 {
+		t.Fatalf("unexpected completions: %q", completions)
+	}
+
+	server.Close()
+
+	// the cached inventory is used when the bridge is not reachable
+	completions = complete(t, server, "lights", "--light", "")
+	if len(completions) != 3 || completions[0] != "Desk Lamp\t1" {
+		t.Errorf("unexpected completions: %q", completions)
+	}
+}
+
+func TestCompleteTimeout(t *testing.T) {
+	startBridge(t)
+
+	timeout := completionTimeout
+	completionTimeout = 100 * time.Millisecond
+	t.Cleanup(func() {
+		completionTimeout = timeout
+	})
+
+	// a bridge that does not answer
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(hanging.Close)
+
+	start := time.Now()
+	out, err := execute(t, "__complete", "lights", "--bridge="+strings.TrimPrefix(hanging.URL, "http://"),
+		"--username="+testUser, "--light", "")
+	if err != nil {
+		t.Fatalf("completion failed: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("completion waited %s for the bridge", elapsed)
+	}
+	if !strings.HasPrefix(out, ":") {
+		t.Errorf("expected no completions, got:\n%s", out)
+	}
+}
+
+func TestCompletionScript(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		out, err := execute(t, "completion", shell)
+		if err != nil {
+			t.Fatalf("completion %s failed: %s", shell, err)
+		}
+
+		if !strings.Contains(out, "hue-cli") {
+			t.Errorf("unexpected %s completion:\n%s", shell, out)
+		}
+	}
+
+	_, err := execute(t, "completion", "tcsh")
+	if err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
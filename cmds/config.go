@@ -0,0 +1,206 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+type ConfigOptions struct {
+	to      string
+	file    string
+	upgrade bool
+}
+
+func initConfig(app *App, cmd *cobra.Command) {
+	// hue-cli config
+	cmdConfig := &cobra.Command{
+		Use:   "config",
+		Short: "manage the configuration file",
+		Long:  "manage the configuration file with the bridges, see --config",
+	}
+	cmd.AddCommand(cmdConfig)
+
+	// hue-cli config migrate-secrets
+	cmdConfig.AddCommand(newMigrateSecretsCommand(app))
+	// hue-cli config validate
+	cmdConfig.AddCommand(newValidateConfigCommand(app))
+}
+
+func newValidateConfigCommand(app *App) *cobra.Command {
+	var configOptions ConfigOptions
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "check the configuration file",
+		Long: "check the configuration file for unknown fields and invalid values. " +
+			"Older layouts are migrated, --upgrade writes the migrated configuration back to the file.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		Annotations:  map[string]string{skipConfigAnnotation: ""},
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(app.bridge.config)
+			if err != nil {
+				return invalidInputError("failed to load %s: %s", app.bridge.config, err)
+			}
+
+			var header struct {
+				Version int `yaml:"version"`
+			}
+			// errors are reported by ParseConfig
+			_ = yaml.Unmarshal(data, &header)
+
+			config, err := utils.ParseConfig(data)
+			if err != nil {
+				var problems utils.ValidationError
+				if errors.As(err, &problems) {
+					for _, problem := range problems {
+						app.logger.Warnf("%s: %s\n", app.bridge.config, problem)
+					}
+					return invalidInputError("%s contains %d problem(s)", app.bridge.config, len(problems))
+				}
+
+				return invalidInputError("invalid configuration in %s: %s", app.bridge.config, err)
+			}
+
+			if header.Version == config.Version {
+				fmt.Printf("%s is valid (version %d)\n", app.bridge.config, config.Version)
+				return nil
+			}
+
+			if !configOptions.upgrade {
+				fmt.Printf("%s is valid, it can be migrated from version %d to %d with --upgrade\n",
+					app.bridge.config, header.Version, config.Version)
+				return nil
+			}
+
+			err = app.saveConfig(config)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%s is valid, migrated from version %d to %d\n", app.bridge.config, header.Version, config.Version)
+			return nil
+		},
+	}
+
+	// hue-cli config validate --upgrade
+	cmd.Flags().BoolVar(&configOptions.upgrade, "upgrade", false,
+		"write the configuration in the current layout when it was migrated")
+
+	return cmd
+}
+
+func newMigrateSecretsCommand(app *App) *cobra.Command {
+	var configOptions ConfigOptions
+
+	cmd := &cobra.Command{
+		Use:   "migrate-secrets",
+		Short: "move the usernames out of the configuration file",
+		Long: "move the usernames of the bridges from the configuration file to the keyring, " +
+			"or to a file that is encrypted with the passphrase in $" + utils.PassphraseEnv + ". " +
+			"The configuration refers to where the username is stored.",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if configOptions.to != "keyring" && configOptions.to != "file" {
+				return invalidInputError("unknown --to=%s, use keyring or file", configOptions.to)
+			}
+
+			config, err := client.LoadConfig(app.bridge.config)
+			if err != nil {
+				return invalidInputError("failed to load %s: %s", app.bridge.config, err)
+			}
+
+			file := configOptions.file
+			if configOptions.to == "file" && file == "" {
+				file, err = utils.DefaultSecretsFile()
+				if err != nil {
+					return err
+				}
+			}
+
+			migrated := 0
+			for i, bc := range config.Bridges {
+				if bc.User == "" {
+					continue
+				}
+
+				key := bc.BridgeID
+				if key == "" {
+					key = bc.IPAddress
+				}
+
+				ref := "keyring:" + key
+				if configOptions.to == "file" {
+					ref = "file:" + file + "#" + key
+				}
+
+				err = utils.StoreSecret(ref, bc.User)
+				if err != nil {
+					return err
+				}
+
+				// only remove the username when it can be read back
+				secret, err := utils.LookupSecret(ref)
+				if err != nil {
+					return err
+				} else if secret != bc.User {
+					return invalidInputError("the username of bridge %s was not stored correctly in %s", bc.IPAddress, ref)
+				}
+
+				config.Bridges[i].User = ""
+				config.Bridges[i].UserSecret = ref
+				migrated++
+
+				app.logger.Infof("moved the username of bridge %s to %s\n", bc.IPAddress, ref)
+			}
+
+			if migrated == 0 {
+				app.logger.Infof("%s does not contain usernames\n", app.bridge.config)
+				return nil
+			}
+
+			return app.saveConfig(config)
+		},
+	}
+
+	// hue-cli config migrate-secrets --to=keyring|file
+	cmd.Flags().StringVar(&configOptions.to, "to", "keyring",
+		"where to store the usernames: keyring or file")
+	// hue-cli config migrate-secrets --file=<path>
+	cmd.Flags().StringVar(&configOptions.file, "file", "",
+		"the encrypted file for --to=file (default secrets.json in the configuration directory of hue-cli)")
+	cmd.RegisterFlagCompletionFunc("to", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"keyring", "file"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return cmd
+}
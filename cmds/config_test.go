@@ -0,0 +1,128 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+func TestMigrateSecrets(t *testing.T) {
+	server := startBridge(t)
+	t.Setenv(utils.PassphraseEnv, "correct horse")
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "hue-cli.yaml")
+	secrets := filepath.Join(dir, "secrets.json")
+	err := os.WriteFile(config, []byte("bridges:\n"+
+		"- ipaddress: "+server.Address()+"\n"+
+		"  user: "+testUser+"\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", config, err)
+	}
+
+	_, err = execute(t, "config", "migrate-secrets", "--to=file", "--file="+secrets, "--config="+config)
+	if err != nil {
+		t.Fatalf("migrate-secrets failed: %s", err)
+	}
+
+	data, _ := os.ReadFile(config)
+	if strings.Contains(string(data), testUser) {
+		t.Errorf("the username is still in the configuration:\n%s", data)
+	}
+
+	migrated, err := client.LoadConfig(config)
+	if err != nil {
+		t.Fatalf("failed to load %s: %s", config, err)
+	}
+	if ref := migrated.Bridges[0].UserSecret; ref != "file:"+secrets+"#"+server.Address() {
+		t.Errorf("unexpected reference to the secret: %s", ref)
+	}
+
+	// the username is read from the secrets file
+	_, err = execute(t, "list-lights", "--config="+config)
+	if err != nil {
+		t.Fatalf("list-lights failed: %s", err)
+	}
+
+	t.Setenv(utils.PassphraseEnv, "")
+	_, err = execute(t, "list-lights", "--config="+config)
+	if code := ExitCode(err); code != ExitAuth {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitAuth, code, err)
+	}
+}
+
+func TestMigrateSecretsInvalid(t *testing.T) {
+	_, err := execute(t, "config", "migrate-secrets", "--to=vault")
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "hue-cli.yaml")
+	err := os.WriteFile(config, []byte("bridges:\n- ipaddress: 192.168.1.10\n  user: "+testUser+"\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", config, err)
+	}
+
+	out, err := execute(t, "config", "validate", "--upgrade", "--config="+config)
+	if err != nil {
+		t.Fatalf("validate failed: %s", err)
+	}
+	if !strings.Contains(out, "migrated from version 0 to 1") {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	data, _ := os.ReadFile(config)
+	if !strings.HasPrefix(string(data), "version: 1\nbridges:\n") {
+		t.Errorf("the configuration was not upgraded:\n%s", data)
+	}
+
+	out, err = execute(t, "config", "validate", "--config="+config)
+	if err != nil || !strings.Contains(out, "is valid (version 1)") {
+		t.Errorf("unexpected result of validate: %s (%v)", out, err)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "hue-cli.yaml")
+	err := os.WriteFile(config, []byte("bridges:\n- ipadress: 192.168.1.10\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", config, err)
+	}
+
+	_, err = execute(t, "config", "validate", "--config="+config)
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+
+	// other commands report the typo instead of a missing --bridge
+	_, err = execute(t, "list-lights", "--config="+config)
+	if err == nil || !strings.Contains(err.Error(), "ipadress") {
+		t.Errorf("expected an error about the unknown field, got %v", err)
+	}
+}
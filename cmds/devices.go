@@ -0,0 +1,69 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/client"
+)
+
+func initDevices(app *App, cmd *cobra.Command) {
+	// hue-cli list-devices
+	cmd.AddCommand(newListDevicesCommand(app))
+}
+
+func newListDevicesCommand(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:          "list-devices",
+		Short:        "list all devices",
+		Long:         "list all devices of the bridge with their services (CLIP v2 only)",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			devices, rooms, err := c.Devices()
+			if err != nil {
+				return err
+			}
+
+			if app.output.format == "json" {
+				output := []client.DeviceSummary{}
+				for _, device := range devices {
+					output = append(output, client.NewDeviceSummary(device, rooms[device.ID]))
+				}
+				return printJSON(output)
+			}
+
+			fmt.Printf("Found %d devices\n", len(devices))
+			for _, device := range devices {
+				fmt.Printf("%s\n", client.FormatDevice(device, rooms[device.ID]))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,51 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/client"
+)
+
+func TestListDevices(t *testing.T) {
+	server, v2 := startV2Bridge(t)
+
+	out, err := runHueCli(t, server, "list-devices", "--output=json", v2)
+	if err != nil {
+		t.Fatalf("list-devices failed: %s", err)
+	}
+
+	var devices []client.DeviceSummary
+	err = json.Unmarshal([]byte(out), &devices)
+	if err != nil {
+		t.Fatalf("failed to parse output: %s\n%s", err, out)
+	}
+
+	rooms := map[string]string{}
+	for _, device := range devices {
+		rooms[device.Name] = device.Room
+	}
+	if len(devices) != 4 || rooms["TV Lightstrip"] != "Living room" || rooms["Hallway"] != "" {
+		t.Errorf("unexpected devices: %+v", devices)
+	}
+}
@@ -21,167 +21,165 @@
 package cmds
 
 import (
-	"errors"
 	"fmt"
 
 	hue "github.com/collinux/GoHue"
 	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/client"
 )
 
 type DiscoverOptions struct {
 	newSensors bool
 }
 
-var (
-	discoverOptions DiscoverOptions
-)
-
-func initDiscover(cmd *cobra.Command) {
+func initDiscover(app *App, cmd *cobra.Command) {
 	// hue-cli discover-bridges
-	cmd.AddCommand(cmdDiscover)
-	addBridgeOptions(cmdDiscover)
-	cmdDiscover.SilenceUsage = true
+	cmd.AddCommand(newDiscoverCommand(app))
 
 	// hue-cli discover-lights
-	cmd.AddCommand(cmdDiscoverLights)
-	addBridgeOptions(cmdDiscoverLights)
-	cmdDiscoverLights.SilenceUsage = true
+	cmd.AddCommand(newDiscoverLightsCommand(app))
 
 	// hue-cli discover-sensors
-	cmd.AddCommand(cmdDiscoverSensors)
-	addBridgeOptions(cmdDiscoverSensors)
-	cmdDiscoverSensors.Flags().BoolVar(&discoverOptions.newSensors, "new", false,
-		"list the newly detected sensors only")
-	cmdDiscoverSensors.SilenceUsage = true
+	cmd.AddCommand(newDiscoverSensorsCommand(app))
 }
 
-var cmdDiscover = &cobra.Command{
-	Use:   "discover-bridges",
-	Short: "discover bridges",
-	Long:  "request the known bridges in this network from https://discovery.meethue.com/",
+func newDiscoverCommand(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:          "discover-bridges",
+		Short:        "discover bridges",
+		Long:         "request the known bridges in this network from https://discovery.meethue.com/ (or https://phoscon.de/discover for deCONZ)",
+		SilenceUsage: true,
 
-	RunE: func(cmd *cobra.Command, args []string) error {
-		var err error
-		var bridges []hue.Bridge
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var bridges []hue.Bridge
 
-		if bridgeOptions.ipaddress != "" {
-			// if we know the IP-addres, we dont do any discovery
-			bridge, err := hue.NewBridge(bridgeOptions.ipaddress)
+			backend, err := client.LookupBackend(app.bridge.backend)
 			if err != nil {
-				return errors.New(fmt.Sprintf("failed to find bridge %s: %s", bridgeOptions.ipaddress, err))
+				return invalidInputError("%s", err)
 			}
 
-			if bridgeOptions.username != "" {
-				err = bridge.Login(bridgeOptions.username)
+			if app.bridge.ipaddress != "" {
+				// if we know the IP-addres, we dont do any discovery
+				bridge, err := backend.Connect(app.bridge.ipaddress)
+				if err != nil {
+					return fmt.Errorf("failed to find bridge %s: %w", app.bridge.ipaddress, err)
+				}
+
+				if app.bridge.username != "" {
+					err = bridge.Login(app.bridge.username)
+					if err != nil {
+						return fmt.Errorf("failed to login on bridge %s: %w", bridge.Info.Device.FriendlyName, err)
+					}
+				}
+
+				bridges = append(bridges, *bridge)
+			} else {
+				bridges, err = client.Discover(backend.Name())
 				if err != nil {
-					return errors.New(fmt.Sprintf("failed to login on bridge %s: %s\n", bridge.Info.Device.FriendlyName, err))
+					return err
 				}
 			}
 
-			bridges = append(bridges, *bridge)
-		} else {
-			bridges, err = hue.FindBridges()
-			if err != nil {
-				return err
+			if app.output.format == "json" {
+				output := []client.BridgeSummary{}
+				for _, bridge := range bridges {
+					err := bridge.GetInfo()
+					if err != nil {
+						app.logger.Verbosef("failed to get info for bridge at %s (%s)\n", bridge.IPAddress, err)
+					}
+					output = append(output, client.NewBridgeSummary(&bridge))
+				}
+				return printJSON(output)
 			}
-		}
 
-		fmt.Printf("Found %d bridges\n", len(bridges))
-		for _, bridge := range bridges {
-			err := bridge.GetInfo()
-			if err != nil {
-				fmt.Printf("ERROR: failed to get info for bridge at %s (%s)\n", bridge.IPAddress, err)
-				// fall-through, just print few details
+			fmt.Printf("Found %d bridges\n", len(bridges))
+			for _, bridge := range bridges {
+				err := bridge.GetInfo()
+				if err != nil {
+					fmt.Printf("ERROR: failed to get info for bridge at %s (%s)\n", bridge.IPAddress, err)
+					// fall-through, just print few details
+				}
+				fmt.Printf("%s\n", client.FormatBridge(&bridge))
 			}
-			fmt.Printf("%s\n", bridgeToString(bridge))
-		}
-		return nil
-	},
+			return nil
+		},
+	}
 }
 
-var cmdDiscoverLights = &cobra.Command{
-	Use:   "discover-lights",
-	Short: "discover new lights",
-	Long:  "request the bridge to probe for new lights",
+func newDiscoverLightsCommand(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:          "discover-lights",
+		Short:        "discover new lights",
+		Long:         "request the bridge to probe for new lights",
+		SilenceUsage: true,
 
-	RunE: func(cmd *cobra.Command, args []string) error {
-		bridge, err := getBridge()
-		if err != nil {
-			return err
-		}
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
 
-		err = bridge.FindNewLights()
-		if err != nil {
-			return errors.New(fmt.Sprintf("failed to start detecting new lights on %s\n", bridge.Info.Device.FriendlyName))
-		}
+			bridge := c.Bridge
 
-		fmt.Printf("discovery for new lights on bridge %s started, check for new lights in 1 minute\n", bridge.Info.Device.FriendlyName)
+			err = c.FindNewLights()
+			if err != nil {
+				return fmt.Errorf("failed to start detecting new lights on %s: %w", bridge.Info.Device.FriendlyName, err)
+			}
+
+			app.logger.Infof("discovery for new lights on bridge %s started, check for new lights in 1 minute\n", bridge.Info.Device.FriendlyName)
 
-		return nil
-	},
+			return nil
+		},
+	}
 }
 
-var cmdDiscoverSensors = &cobra.Command{
-	Use:   "discover-sensors",
-	Short: "discover new sensors",
-	Long:  "request the bridge to probe for new sensors",
+func newDiscoverSensorsCommand(app *App) *cobra.Command {
+	var discoverOptions DiscoverOptions
 
-	RunE: func(cmd *cobra.Command, args []string) error {
-		bridge, err := getBridge()
-		if err != nil {
-			return err
-		}
+	cmd := &cobra.Command{
+		Use:          "discover-sensors",
+		Short:        "discover new sensors",
+		Long:         "request the bridge to probe for new sensors",
+		SilenceUsage: true,
 
-		if !discoverOptions.newSensors {
-			err = bridge.FindNewSensors()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
 			if err != nil {
-				return errors.New(fmt.Sprintf("failed to start detecting new sensors on %s\n", bridge.Info.Device.FriendlyName))
+				return err
 			}
 
-			fmt.Printf("discovery for new sensors on bridge %s started, check for new sensors in 1 minute\n", bridge.Info.Device.FriendlyName)
-		} else {
-			sensors, err := bridge.GetNewSensors()
-			if err != nil {
-				return errors.New(fmt.Sprintf("failed get new sensors from %s\n", bridge.Info.Device.FriendlyName))
-			}
+			bridge := c.Bridge
 
-			for _, sensor := range(sensors) {
-				fmt.Println(sensorToString(sensor))
-			}
-		}
+			if !discoverOptions.newSensors {
+				err = c.FindNewSensors()
+				if err != nil {
+					return fmt.Errorf("failed to start detecting new sensors on %s: %w", bridge.Info.Device.FriendlyName, err)
+				}
 
-		return nil
-	},
-}
+				app.logger.Infof("discovery for new sensors on bridge %s started, check for new sensors in 1 minute\n", bridge.Info.Device.FriendlyName)
+			} else {
+				sensors, err := c.NewSensors()
+				if err != nil {
+					return fmt.Errorf("failed get new sensors from %s: %w", bridge.Info.Device.FriendlyName, err)
+				}
 
-func bridgeToString(bridge hue.Bridge) string {
-	s := fmt.Sprintf("Bridge:\n"+
-		"\tIP-address: %s",
-		bridge.IPAddress)
-
-	if bridge.Info.Device.DeviceType != "" {
-		s += fmt.Sprintf("\n\tDevice Information:\n"+
-			"\t\tDeviceType: %s\n"+
-			"\t\tFriendlyName: %s\n"+
-			"\t\tManufacturer: %s\n"+
-			"\t\tManufacturerURL: %s\n"+
-			"\t\tModelDescription: %s\n"+
-			"\t\tModelName: %s\n"+
-			"\t\tModelNumber: %s\n"+
-			"\t\tModelURL: %s\n"+
-			"\t\tSerialNumber: %s\n"+
-			"\t\tUDN: %s",
-			bridge.Info.Device.DeviceType,
-			bridge.Info.Device.FriendlyName,
-			bridge.Info.Device.Manufacturer,
-			bridge.Info.Device.ManufacturerURL,
-			bridge.Info.Device.ModelDescription,
-			bridge.Info.Device.ModelName,
-			bridge.Info.Device.ModelNumber,
-			bridge.Info.Device.ModelURL,
-			bridge.Info.Device.SerialNumber,
-			bridge.Info.Device.UDN)
+				if app.output.format == "json" {
+					return printJSON(sensorSummaries(c, sensors))
+				}
+
+				for _, sensor := range sensors {
+					fmt.Println(client.FormatSensor(sensor, c.SensorKind(sensor)))
+				}
+			}
+
+			return nil
+		},
 	}
 
-	return s
+	// hue-cli discover-sensors --new
+	cmd.Flags().BoolVar(&discoverOptions.newSensors, "new", false,
+		"list the newly detected sensors only")
+
+	return cmd
 }
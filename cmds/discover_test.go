@@ -0,0 +1,80 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiscoverBridges(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "discover-bridges")
+	if err != nil {
+		t.Fatalf("discover-bridges failed: %s", err)
+	}
+
+	if !strings.HasPrefix(out, "Found 1 bridges\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if !strings.Contains(out, "FriendlyName: Philips hue") {
+		t.Errorf("bridge name missing in output:\n%s", out)
+	}
+}
+
+func TestDiscoverLights(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "discover-lights")
+	if err != nil {
+		t.Fatalf("discover-lights failed: %s", err)
+	}
+
+	if !strings.Contains(out, "discovery for new lights") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestDiscoverSensors(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "discover-sensors")
+	if err != nil {
+		t.Fatalf("discover-sensors failed: %s", err)
+	}
+
+	if !strings.Contains(out, "discovery for new sensors") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	out, err = runHueCli(t, server, "discover-sensors", "--new")
+	if err != nil {
+		t.Fatalf("discover-sensors --new failed: %s", err)
+	}
+
+	if !strings.Contains(out, "Sensor: Hue motion sensor 1") {
+		t.Errorf("new sensor missing in output:\n%s", out)
+	}
+	if strings.Contains(out, "Sensor: Daylight") {
+		t.Errorf("known sensor listed as new:\n%s", out)
+	}
+}
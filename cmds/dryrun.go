@@ -0,0 +1,150 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type DryRunOptions struct {
+	dryRun bool
+}
+
+func initDryRun(app *App, cmd *cobra.Command) {
+	// hue-cli --dry-run
+	cmd.PersistentFlags().BoolVar(&app.dryRun.dryRun, "dry-run", false,
+		"print the changes that would be sent to the bridge, without sending them")
+}
+
+// dryRunRequest is a request that was not sent for --output=json
+type dryRunRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+// dryRunTransport prints the requests that change something on the bridge,
+// and pretends that the bridge accepted them. Reading from the bridge is
+// still done, so that names can be resolved and input can be validated.
+type dryRunTransport struct {
+	transport http.RoundTripper
+	format    string
+}
+
+func (dt *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return dt.transport.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var params interface{}
+	if len(body) != 0 && json.Unmarshal(body, &params) != nil {
+		params = string(body)
+	}
+
+	if dt.format == "json" {
+		err := printJSON(dryRunRequest{Method: req.Method, Path: req.URL.Path, Body: params})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		fmt.Printf("%s %s\n", req.Method, req.URL.Path)
+		if len(body) != 0 {
+			fmt.Printf("%s\n", body)
+		}
+	}
+
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(dryRunResponse(req, params))),
+		Request:    req,
+	}, nil
+}
+
+// dryRunResponse returns what the bridge would reply when the request
+// succeeded.
+func dryRunResponse(req *http.Request, params interface{}) []byte {
+	if strings.HasPrefix(req.URL.Path, "/clip/v2/resource/") {
+		// the v2 API returns the changed resource
+		parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/clip/v2/resource/"), "/")
+		data, _ := json.Marshal(map[string]interface{}{
+			"errors": []interface{}{},
+			"data":   []map[string]string{{"rid": parts[len(parts)-1], "rtype": parts[0]}},
+		})
+
+		return data
+	}
+
+	address := strings.TrimPrefix(req.URL.Path, "/api")
+	if parts := strings.SplitN(strings.TrimPrefix(address, "/"), "/", 2); len(parts) == 2 {
+		// strip the username
+		address = "/" + parts[1]
+	}
+
+	var results []map[string]interface{}
+	switch {
+	case req.Method == http.MethodPost && (req.URL.Path == "/api" || req.URL.Path == "/api/"):
+		results = append(results, map[string]interface{}{
+			"success": map[string]string{"username": "dry-run"},
+		})
+	case req.Method == http.MethodPost:
+		results = append(results, map[string]interface{}{
+			"success": map[string]string{"id": "0"},
+		})
+	case req.Method == http.MethodDelete:
+		results = append(results, map[string]interface{}{
+			"success": address + " deleted",
+		})
+	default:
+		if values, ok := params.(map[string]interface{}); ok {
+			for _, key := range sortedParams(values) {
+				results = append(results, map[string]interface{}{
+					"success": map[string]interface{}{address + "/" + key: values[key]},
+				})
+			}
+		}
+	}
+
+	data, _ := json.Marshal(results)
+
+	return data
+}
@@ -0,0 +1,110 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDryRunDeleteGroup(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "delete-group", "--name=Office", "--dry-run")
+	if err != nil {
+		t.Fatalf("delete-group --dry-run failed: %s", err)
+	}
+
+	if !strings.Contains(out, "DELETE /api/"+testUser+"/groups/1\n") {
+		t.Errorf("request missing in output:\n%s", out)
+	}
+	if _, ok := server.Bridge.State().Groups["1"]; !ok {
+		t.Error("group was deleted")
+	}
+}
+
+func TestDryRunNewGroup(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "new-group", "--name=Hallway", "--lights=3", "--dry-run")
+	if err != nil {
+		t.Fatalf("new-group --dry-run failed: %s", err)
+	}
+
+	if !strings.Contains(out, "POST /api/"+testUser+"/groups\n") {
+		t.Errorf("request missing in output:\n%s", out)
+	}
+	if groups := server.Bridge.State().Groups; len(groups) != 1 {
+		t.Errorf("expected 1 group, got %d", len(groups))
+	}
+}
+
+func TestDryRunLightJSON(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "lights", "--light=Ceiling", "--toggle", "--dry-run", "--output=json")
+	if err != nil {
+		t.Fatalf("lights --dry-run failed: %s", err)
+	}
+
+	var request dryRunRequest
+	err = json.NewDecoder(strings.NewReader(out)).Decode(&request)
+	if err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, out)
+	}
+
+	body, _ := request.Body.(map[string]interface{})
+	if request.Method != "PUT" || request.Path != "/api/"+testUser+"/lights/2/state" || body["on"] != true {
+		t.Errorf("unexpected request: %+v", request)
+	}
+	if server.Bridge.State().Lights["2"].State.On {
+		t.Error("light was switched on")
+	}
+}
+
+func TestDryRunValidates(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "new-group", "--name=Hall", "--lights=1,x", "--dry-run")
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+	if out != "" {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestDryRunCreateUser(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "create-user", "--device=test", "--dry-run")
+	if err != nil {
+		t.Fatalf("create-user --dry-run failed: %s", err)
+	}
+
+	if !strings.Contains(out, "POST /api\n{\"devicetype\":\"hue-cli#test\"}\n") {
+		t.Errorf("request missing in output:\n%s", out)
+	}
+	if len(server.Bridge.State().Config.Whitelist) != 1 {
+		t.Error("user was created")
+	}
+}
@@ -0,0 +1,203 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"strings"
+
+	hue "github.com/collinux/GoHue"
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/clipv2"
+)
+
+type EffectOptions struct {
+	light  string
+	group  string
+	effect string
+	colors []string
+
+	executor ExecutorOptions
+}
+
+func initEffects(app *App, cmd *cobra.Command) {
+	// hue-cli effect
+	cmd.AddCommand(newEffectCommand(app))
+
+	// hue-cli gradient
+	cmd.AddCommand(newGradientCommand(app))
+}
+
+// addLightSelection adds the --light and --group options, that select the
+// lights for the CLIP v2 commands.
+func addLightSelection(app *App, cmd *cobra.Command, options *EffectOptions) {
+	// hue-cli <command> --light=<names>
+	cmd.Flags().StringVar(&options.light, "light", "",
+		"act on the given lights, a comma separated list of names and/or indexes")
+	// hue-cli <command> --group=<name>
+	cmd.Flags().StringVar(&options.group, "group", "",
+		"act on the lights of the room or zone")
+	cmd.RegisterFlagCompletionFunc("light", completeList(app.completeInventory(inventoryLights, false)))
+	cmd.RegisterFlagCompletionFunc("group", app.completeInventory(inventoryGroups, false))
+}
+
+// selectV2Lights returns the lights of the CLIP v2 API that are selected
+// with --light or --group. forEachLight and reportResults handle v1 lights,
+// the lights are returned as v1 lights as well, with the v2 lights by their
+// index.
+func selectV2Lights(c *client.Client, options EffectOptions) ([]hue.Light, map[int]clipv2.Light, error) {
+	var lights []clipv2.Light
+	var err error
+	switch {
+	case options.light != "" && options.group != "":
+		return nil, nil, invalidInputError("--light and --group can not be combined")
+	case options.light != "":
+		lights, err = c.V2Lights(options.light)
+	case options.group != "":
+		lights, err = c.V2GroupLights(options.group)
+	default:
+		return nil, nil, invalidInputError("--light=<names> or --group=<name> is required")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v1 := []hue.Light{}
+	byIndex := map[int]clipv2.Light{}
+	for _, light := range lights {
+		v1 = append(v1, hue.Light{Name: light.Metadata.Name, Index: light.V1Index()})
+		byIndex[light.V1Index()] = light
+	}
+
+	return v1, byIndex, nil
+}
+
+func newEffectCommand(app *App) *cobra.Command {
+	var effectOptions EffectOptions
+
+	cmd := &cobra.Command{
+		Use:          "effect",
+		Short:        "start an effect on lights",
+		Long:         "start an effect, like candle or fire, on lights that support it (CLIP v2 only)",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			effect := effectOptions.effect
+			known := false
+			for _, e := range clipv2.KnownEffects {
+				known = known || e == effect
+			}
+			if !known {
+				return invalidInputError("--effect=<%s> is required", strings.Join(clipv2.KnownEffects, "|"))
+			}
+
+			lights, byIndex, err := selectV2Lights(c, effectOptions)
+			if err != nil {
+				return err
+			}
+
+			results := forEachLight(lights, effectOptions.executor.parallel, func(light hue.Light) error {
+				v2 := byIndex[light.Index]
+				if !v2.SupportsEffect(effect) {
+					return invalidInputError("light %s does not support the effect %s", light.Name, effect)
+				}
+
+				return c.SetEffect(v2, effect)
+			})
+
+			return app.reportResults(results)
+		},
+	}
+
+	addExecutorOptions(cmd, &effectOptions.executor)
+	addLightSelection(app, cmd, &effectOptions)
+	// hue-cli effect --effect=<name>
+	cmd.Flags().StringVar(&effectOptions.effect, "effect", "",
+		"the effect to start, no_effect stops it")
+	cmd.RegisterFlagCompletionFunc("effect", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return clipv2.KnownEffects, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return cmd
+}
+
+func newGradientCommand(app *App) *cobra.Command {
+	var effectOptions EffectOptions
+
+	cmd := &cobra.Command{
+		Use:          "gradient",
+		Short:        "set the colors of gradient lights",
+		Long:         "set the colors along a gradient lightstrip, from its start to its end (CLIP v2 only)",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			if len(effectOptions.colors) < 2 {
+				return invalidInputError("at least two --color=<#rrggbb|x,y> are required")
+			}
+
+			colors := []clipv2.XY{}
+			for _, color := range effectOptions.colors {
+				xy, err := clipv2.ParseColor(color)
+				if err != nil {
+					return invalidInputError("%s", err)
+				}
+				colors = append(colors, xy)
+			}
+
+			lights, byIndex, err := selectV2Lights(c, effectOptions)
+			if err != nil {
+				return err
+			}
+
+			results := forEachLight(lights, effectOptions.executor.parallel, func(light hue.Light) error {
+				v2 := byIndex[light.Index]
+				if points := v2.GradientPoints(); points == 0 {
+					return invalidInputError("light %s does not support gradients", light.Name)
+				} else if len(colors) > points {
+					return invalidInputError("light %s supports at most %d colors", light.Name, points)
+				}
+
+				return c.SetGradient(v2, colors)
+			})
+
+			return app.reportResults(results)
+		},
+	}
+
+	addExecutorOptions(cmd, &effectOptions.executor)
+	addLightSelection(app, cmd, &effectOptions)
+	// hue-cli gradient --color=<#rrggbb|x,y> --color=...
+	cmd.Flags().StringArrayVar(&effectOptions.colors, "color", nil,
+		"a color of the gradient, pass it once per color")
+
+	return cmd
+}
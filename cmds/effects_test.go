@@ -0,0 +1,118 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/huetest"
+)
+
+// startV2Bridge starts a fake bridge with the state from
+// testdata/bridge-v2.yaml, the returned option passes the address of its
+// CLIP v2 API.
+func startV2Bridge(t *testing.T) (*huetest.Server, string) {
+	server := startFixture(t, "testdata/bridge-v2.yaml")
+
+	return server, "--v2-address=" + server.TLSAddress()
+}
+
+func TestEffect(t *testing.T) {
+	server, v2 := startV2Bridge(t)
+
+	out, err := runHueCli(t, server, "effect", "--group=living room", "--effect=candle", v2)
+	if err != nil {
+		t.Fatalf("effect failed: %s", err)
+	}
+
+	if !strings.Contains(out, "TV Lightstrip  2      ok") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	state := server.Bridge.State()
+	if state.V2.Lights["1"].Effect != "candle" || state.V2.Lights["2"].Effect != "candle" {
+		t.Errorf("effect was not started: %+v %+v", state.V2.Lights["1"], state.V2.Lights["2"])
+	}
+	if !state.Lights["2"].State.On {
+		t.Error("TV Lightstrip was not switched on")
+	}
+
+	// the Hallway does not support effects, the lightstrip does
+	_, err = runHueCli(t, server, "effect", "--light=hallway,tv lightstrip", "--effect=prism", v2)
+	if code := ExitCode(err); code != ExitPartialFailure {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitPartialFailure, code, err)
+	}
+
+	_, err = runHueCli(t, server, "effect", "--light=Desk Lamp", "--effect=disco", v2)
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+}
+
+func TestGradient(t *testing.T) {
+	server, v2 := startV2Bridge(t)
+
+	_, err := runHueCli(t, server, "gradient", "--light=TV Lightstrip", "--color=#ff0000", "--color=0.1532,0.0475", v2)
+	if err != nil {
+		t.Fatalf("gradient failed: %s", err)
+	}
+
+	gradient := server.Bridge.State().V2.Lights["2"].Gradient
+	if len(gradient) != 2 || gradient[0] != [2]float64{0.7006, 0.2993} || gradient[1] != [2]float64{0.1532, 0.0475} {
+		t.Errorf("unexpected gradient: %v", gradient)
+	}
+
+	_, err = runHueCli(t, server, "gradient", "--light=Desk Lamp", "--color=#ff0000", "--color=#0000ff", v2)
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+}
+
+func TestEffectWithoutV2(t *testing.T) {
+	server := startBridge(t)
+
+	// the bridge does not serve the CLIP v2 API
+	_, err := runHueCli(t, server, "effect", "--light=Desk Lamp", "--effect=candle", "--v2-address="+server.TLSAddress())
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Fatalf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+	if !strings.Contains(err.Error(), "CLIP v2 API is not supported") {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestEffectDryRun(t *testing.T) {
+	server, v2 := startV2Bridge(t)
+
+	out, err := runHueCli(t, server, "--dry-run", "effect", "--light=Desk Lamp", "--effect=fire", v2)
+	if err != nil {
+		t.Fatalf("effect failed: %s", err)
+	}
+
+	expected := "PUT /clip/v2/resource/light/" + huetest.V2ID("light", "1") + "\n" +
+		`{"on":{"on":true},"effects":{"effect":"fire"}}` + "\n"
+	if out != expected {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if server.Bridge.State().V2.Lights["1"].Effect != "" {
+		t.Error("the effect was started")
+	}
+}
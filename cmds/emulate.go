@@ -0,0 +1,105 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/huetest"
+)
+
+type EmulateOptions struct {
+	state     string
+	listen    string
+	noPersist bool
+}
+
+func initEmulate(app *App, cmd *cobra.Command) {
+	// hue-cli emulate
+	cmd.AddCommand(newEmulateCommand(app))
+}
+
+func newEmulateCommand(app *App) *cobra.Command {
+	var emulateOptions EmulateOptions
+
+	cmd := &cobra.Command{
+		Use:   "emulate",
+		Short: "run a simulated bridge",
+		Long: "run a simulated bridge that serves the Hue API with the state from a YAML file, " +
+			"changes to the state are written back to the file. Sensors can be modified with " +
+			"PUT /admin/sensors/<index>, the link button is pressed with POST /admin/linkbutton.",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if emulateOptions.state == "" {
+				return invalidInputError("--state=<fixture.yaml> is required")
+			}
+
+			state, err := huetest.LoadState(emulateOptions.state)
+			if err != nil {
+				return errors.New(fmt.Sprintf("failed to load state from %s: %s", emulateOptions.state, err))
+			}
+
+			bridge := huetest.NewBridge(state)
+			if !emulateOptions.noPersist {
+				bridge.OnChange(func(state *huetest.State) {
+					err := state.Save(emulateOptions.state)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "failed to save state to %s: %s\n", emulateOptions.state, err)
+					}
+				})
+			}
+
+			// fire the schedules
+			go func() {
+				for now := range time.Tick(time.Second) {
+					bridge.Tick(now)
+				}
+			}()
+
+			mux := http.NewServeMux()
+			mux.Handle("/admin/", bridge.AdminHandler())
+			mux.Handle("/", bridge)
+
+			fmt.Printf("emulating bridge '%s' on %s\n", state.Config.Name, emulateOptions.listen)
+
+			return http.ListenAndServe(emulateOptions.listen, mux)
+		},
+	}
+
+	// hue-cli emulate --state=fixture.yaml
+	cmd.Flags().StringVar(&emulateOptions.state, "state", "",
+		"YAML file with the state of the bridge")
+	// hue-cli emulate --listen=127.0.0.1:8080
+	cmd.Flags().StringVar(&emulateOptions.listen, "listen", "127.0.0.1:8080",
+		"address to listen on for requests, like :8080 for all interfaces")
+	// hue-cli emulate --no-persist
+	cmd.Flags().BoolVar(&emulateOptions.noPersist, "no-persist", false,
+		"do not write changes of the state back to the --state file")
+
+	return cmd
+}
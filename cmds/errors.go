@@ -0,0 +1,319 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/clipv2"
+)
+
+// The exit codes of hue-cli.
+const (
+	ExitOK             = 0
+	ExitFailure        = 1
+	ExitInvalidInput   = 2
+	ExitAuth           = 3
+	ExitNotFound       = 4
+	ExitUnreachable    = 5
+	ExitPartialFailure = 6
+)
+
+var exitKinds = map[int]string{
+	ExitFailure:        "failure",
+	ExitInvalidInput:   "invalid-input",
+	ExitAuth:           "auth",
+	ExitNotFound:       "not-found",
+	ExitUnreachable:    "unreachable",
+	ExitPartialFailure: "partial-failure",
+}
+
+// A BridgeError is an error that the bridge returned for a request.
+type BridgeError struct {
+	Type        int    `json:"type"`
+	Address     string `json:"address"`
+	Description string `json:"description"`
+}
+
+func (e *BridgeError) Error() string {
+	return fmt.Sprintf("Error type %d: %s.", e.Type, e.Description)
+}
+
+// ExitCode returns the exit code for the type of the bridge error.
+func (e *BridgeError) ExitCode() int {
+	switch e.Type {
+	case 1, 101:
+		// unauthorized user, link button not pressed
+		return ExitAuth
+	case 3:
+		// resource not available
+		return ExitNotFound
+	case 2, 5, 6, 7, 8, 11:
+		// invalid JSON, missing parameters, parameter not available,
+		// invalid value, parameter not modifiable, too many items
+		return ExitInvalidInput
+	case 201:
+		// the device is off, and can not be controlled
+		return ExitUnreachable
+	}
+
+	return ExitFailure
+}
+
+// An Error is returned by commands, the Code is used as exit code.
+type Error struct {
+	Code   int
+	Err    error
+	Bridge *BridgeError
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Code    int          `json:"code"`
+		Kind    string       `json:"kind"`
+		Message string       `json:"message"`
+		Bridge  *BridgeError `json:"bridge,omitempty"`
+	}{
+		Code:    e.Code,
+		Kind:    exitKinds[e.Code],
+		Message: e.Error(),
+		Bridge:  e.Bridge,
+	})
+}
+
+func invalidInputError(format string, a ...interface{}) error {
+	return &Error{Code: ExitInvalidInput, Err: errors.New(fmt.Sprintf(format, a...))}
+}
+
+func notFoundError(format string, a ...interface{}) error {
+	return &Error{Code: ExitNotFound, Err: errors.New(fmt.Sprintf(format, a...))}
+}
+
+// goHueError matches the errors that GoHue returns for bridge errors, it
+// only keeps the type and description.
+var goHueError = regexp.MustCompile(`^Error type (\d+): (.*)\.$`)
+
+// classifyError finds the cause of an error, and returns an Error with the
+// matching exit code. The errors that GoHue returns for errors of the bridge
+// are looked up in bridgeErrors, which contains the address too.
+func classifyError(err error, bridgeErrors []BridgeError) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	var be *BridgeError
+	if errors.As(err, &be) {
+		return &Error{Code: be.ExitCode(), Err: err, Bridge: be}
+	}
+
+	var name *client.NameError
+	if errors.As(err, &name) {
+		if name.Ambiguous() || name.OtherBridge() {
+			return &Error{Code: ExitInvalidInput, Err: err}
+		}
+		return &Error{Code: ExitNotFound, Err: err}
+	}
+
+	var ce *clipv2.Error
+	if errors.As(err, &ce) {
+		switch ce.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return &Error{Code: ExitAuth, Err: err}
+		case http.StatusNotFound:
+			return &Error{Code: ExitNotFound, Err: err}
+		case http.StatusBadRequest:
+			return &Error{Code: ExitInvalidInput, Err: err}
+		}
+		return &Error{Code: ExitFailure, Err: err}
+	}
+
+	// before net.Error, the *url.Error that wraps it is a net.Error too
+	var certificate *client.CertificateError
+	if errors.As(err, &certificate) {
+		return &Error{Code: ExitAuth, Err: err}
+	}
+
+	var unsupported *client.UnsupportedError
+	if errors.As(err, &unsupported) {
+		return &Error{Code: ExitInvalidInput, Err: err}
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return &Error{Code: ExitUnreachable, Err: err}
+	}
+
+	cause := err
+	for errors.Unwrap(cause) != nil {
+		cause = errors.Unwrap(cause)
+	}
+
+	if m := goHueError.FindStringSubmatch(cause.Error()); m != nil {
+		errType, _ := strconv.Atoi(m[1])
+		be = lookupBridgeError(bridgeErrors, errType, m[2])
+		return &Error{Code: be.ExitCode(), Err: err, Bridge: be}
+	}
+
+	if strings.HasPrefix(cause.Error(), "unknown command") {
+		// cobra does not use the FlagErrorFunc for unknown commands
+		return &Error{Code: ExitInvalidInput, Err: err}
+	}
+
+	if strings.HasPrefix(cause.Error(), "Unable to find") {
+		// GoHue could not find a light, group, ... by name
+		return &Error{Code: ExitNotFound, Err: err}
+	}
+
+	return &Error{Code: ExitFailure, Err: err}
+}
+
+// ExitCode returns the exit code for the error that a command returned.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	return classifyError(err, nil).Code
+}
+
+// Execute runs hue-cli with the arguments of the process, reports the error
+// (if any) and returns the exit code.
+func Execute() int {
+	return NewApp().Execute(os.Args[1:])
+}
+
+// Execute runs the App with the arguments, reports the error (if any) and
+// returns the exit code.
+func (app *App) Execute(args []string) int {
+	app.root.SetArgs(args)
+
+	err := app.root.Execute()
+	if err == nil {
+		return ExitOK
+	}
+
+	e := app.classifyError(err)
+	if app.output.format == "json" {
+		data, _ := json.MarshalIndent(map[string]interface{}{"error": e}, "", "  ")
+		fmt.Fprintf(os.Stderr, "%s\n", data)
+	} else {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+	}
+
+	return e.Code
+}
+
+// classifyError returns the Error for an error of the current command, with
+// the errors that the bridge returned for its requests.
+func (app *App) classifyError(err error) *Error {
+	app.bridgeErrorsLock.Lock()
+	defer app.bridgeErrorsLock.Unlock()
+
+	return classifyError(err, app.bridgeErrors)
+}
+
+// lookupBridgeError returns the error that the bridge reported with the type
+// and description.
+func lookupBridgeError(bridgeErrors []BridgeError, errType int, description string) *BridgeError {
+	for i := len(bridgeErrors) - 1; i >= 0; i-- {
+		be := bridgeErrors[i]
+		if be.Type == errType && be.Description == description {
+			return &be
+		}
+	}
+
+	return &BridgeError{Type: errType, Description: description}
+}
+
+// errorTransport keeps the errors that the bridge returns in the App.
+type errorTransport struct {
+	transport http.RoundTripper
+	app       *App
+}
+
+func (et *errorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := et.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	errs := parseBridgeErrors(body)
+	if len(errs) == 0 {
+		return resp, nil
+	}
+
+	et.app.bridgeErrorsLock.Lock()
+	defer et.app.bridgeErrorsLock.Unlock()
+
+	et.app.bridgeErrors = append(et.app.bridgeErrors, errs...)
+
+	return resp, nil
+}
+
+// parseBridgeErrors returns the errors in a response of the bridge.
+func parseBridgeErrors(body []byte) []BridgeError {
+	if !bytes.Contains(body, []byte(`"error"`)) {
+		return nil
+	}
+
+	var results []struct {
+		Error *BridgeError `json:"error"`
+	}
+	if json.Unmarshal(body, &results) != nil {
+		return nil
+	}
+
+	errs := []BridgeError{}
+	for _, result := range results {
+		if result.Error != nil {
+			errs = append(errs, *result.Error)
+		}
+	}
+
+	return errs
+}
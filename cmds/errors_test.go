@@ -0,0 +1,130 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/client"
+)
+
+func TestExitCodeUnauthorized(t *testing.T) {
+	server := startBridge(t)
+
+	app := NewApp()
+	_, err := executeApp(t, app, "list-lights", "--bridge="+server.Address(), "--username=unknown")
+	if code := ExitCode(err); code != ExitAuth {
+		t.Fatalf("expected exit code %d, got %d (%v)", ExitAuth, code, err)
+	}
+
+	e := app.classifyError(err)
+	if e.Bridge == nil || e.Bridge.Type != 1 || e.Bridge.Address == "" {
+		t.Errorf("unexpected bridge error: %+v", e.Bridge)
+	}
+
+	// the errors of the bridge are not kept for other Apps
+	e = NewApp().classifyError(err)
+	if e.Bridge == nil || e.Bridge.Address != "" {
+		t.Errorf("unexpected bridge error for another App: %+v", e.Bridge)
+	}
+}
+
+func TestExitCodeNotFound(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "lights", "--light=Garage", "--toggle")
+	if code := ExitCode(err); code != ExitNotFound {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitNotFound, code, err)
+	}
+}
+
+func TestExitCodeInvalidInput(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "new-group", "--lights=1,2")
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+
+	_, err = runHueCli(t, server, "list-lights", "--no-such-flag")
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+
+	_, err = execute(t, "no-such-command")
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+}
+
+func TestExitCodeOther(t *testing.T) {
+	if code := ExitCode(nil); code != ExitOK {
+		t.Errorf("expected exit code %d, got %d", ExitOK, code)
+	}
+
+	if code := ExitCode(errors.New("something failed")); code != ExitFailure {
+		t.Errorf("expected exit code %d, got %d", ExitFailure, code)
+	}
+}
+
+func TestErrorJSON(t *testing.T) {
+	err := &Error{
+		Code:   ExitAuth,
+		Err:    errors.New("Error type 1: unauthorized user."),
+		Bridge: &BridgeError{Type: 1, Address: "/lights", Description: "unauthorized user"},
+	}
+
+	data, jerr := json.Marshal(err)
+	if jerr != nil {
+		t.Fatalf("failed to marshal error: %s", jerr)
+	}
+
+	var out map[string]interface{}
+	json.Unmarshal(data, &out)
+	if out["kind"] != "auth" || out["code"] != float64(ExitAuth) {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+}
+
+func TestOutputJSON(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "list-lights", "--output=json")
+	if err != nil {
+		t.Fatalf("list-lights failed: %s", err)
+	}
+
+	var lights []client.LightSummary
+	err = json.Unmarshal([]byte(out), &lights)
+	if err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, out)
+	}
+	if len(lights) != 3 || lights[0].Name != "Desk Lamp" {
+		t.Errorf("unexpected lights: %+v", lights)
+	}
+
+	_, err = runHueCli(t, server, "list-lights", "--output=xml")
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+}
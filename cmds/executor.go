@@ -0,0 +1,144 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"text/tabwriter"
+
+	hue "github.com/collinux/GoHue"
+	"github.com/spf13/cobra"
+)
+
+type ExecutorOptions struct {
+	parallel int
+}
+
+func addExecutorOptions(cmd *cobra.Command, options *ExecutorOptions) {
+	// hue-cli --parallel=<workers>
+	cmd.Flags().IntVar(&options.parallel, "parallel", 4,
+		"number of lights that are handled at the same time")
+}
+
+// A lightResult is the outcome of an action on a single light.
+type lightResult struct {
+	Light hue.Light
+	Err   error
+}
+
+// forEachLight runs the action for all lights, with at most parallel lights
+// at the same time. The requests still pass the rate limiter, so
+// more workers do not exceed the budget of the bridge. The results are in
+// the order of the lights.
+func forEachLight(lights []hue.Light, parallel int, action func(hue.Light) error) []lightResult {
+	results := make([]lightResult, len(lights))
+
+	workers := parallel
+	if workers < 1 {
+		workers = 1
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = lightResult{Light: lights[i], Err: action(lights[i])}
+			}
+		}()
+	}
+
+	for i := range lights {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// lightResultOutput is the result for a light for --output=json
+type lightResultOutput struct {
+	Name  string `json:"name"`
+	Index int    `json:"index"`
+	OK    bool   `json:"ok"`
+	Error *Error `json:"error,omitempty"`
+}
+
+// reportResults prints a summary of the results, and returns the error for
+// the exit status. When there is only one light, its error is returned as
+// is.
+func (app *App) reportResults(results []lightResult) error {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+
+	if app.output.format == "json" {
+		output := []lightResultOutput{}
+		for _, result := range results {
+			ro := lightResultOutput{Name: result.Light.Name, Index: result.Light.Index, OK: result.Err == nil}
+			if result.Err != nil {
+				ro.Error = app.classifyError(result.Err)
+			}
+			output = append(output, ro)
+		}
+		err := printJSON(output)
+		if err != nil {
+			return err
+		}
+	} else if len(results) > 1 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(w, "LIGHT\tINDEX\tRESULT\n")
+		for _, result := range results {
+			status := "ok"
+			if result.Err != nil {
+				status = result.Err.Error()
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\n", result.Light.Name, result.Light.Index, status)
+		}
+		w.Flush()
+	}
+
+	switch {
+	case failed == 0:
+		return nil
+	case len(results) == 1:
+		return results[0].Err
+	case failed < len(results):
+		return &Error{
+			Code: ExitPartialFailure,
+			Err:  errors.New(fmt.Sprintf("%d of %d lights failed", failed, len(results))),
+		}
+	}
+
+	return &Error{
+		Code: ExitFailure,
+		Err:  errors.New(fmt.Sprintf("all %d lights failed", len(results))),
+	}
+}
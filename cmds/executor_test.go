@@ -0,0 +1,112 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	hue "github.com/collinux/GoHue"
+)
+
+func TestLightsMultiple(t *testing.T) {
+	server := startBridge(t)
+
+	before := server.Bridge.State().Lights
+	out, err := runHueCli(t, server, "lights", "--light=1,Ceiling,Hallway", "--toggle")
+	if err != nil {
+		t.Fatalf("lights --toggle failed: %s", err)
+	}
+
+	after := server.Bridge.State().Lights
+	for _, id := range []string{"1", "2", "3"} {
+		if after[id].State.On == before[id].State.On {
+			t.Errorf("light %s (%s) was not toggled, it is still on=%t", id, after[id].Name, after[id].State.On)
+		}
+	}
+	for _, name := range []string{"Desk Lamp", "Ceiling", "Hallway"} {
+		if !strings.Contains(out, name) {
+			t.Errorf("light %s missing in summary:\n%s", name, out)
+		}
+	}
+}
+
+func TestLightsPartialFailure(t *testing.T) {
+	server := startBridge(t)
+
+	// the dimmable Hallway light does not support the color-loop
+	out, err := runHueCli(t, server, "lights", "--light=Desk Lamp,Hallway", "--colorloop", "--output=json")
+	if code := ExitCode(err); code != ExitPartialFailure {
+		t.Fatalf("expected exit code %d, got %d (%v)", ExitPartialFailure, code, err)
+	}
+
+	var results []lightResultOutput
+	err = json.Unmarshal([]byte(out), &results)
+	if err != nil {
+		t.Fatalf("output is not valid JSON: %s\n%s", err, out)
+	}
+	if len(results) != 2 || !results[0].OK || results[1].OK || results[1].Error == nil {
+		t.Errorf("unexpected results:\n%s", out)
+	}
+}
+
+func TestReportResultsAllFailed(t *testing.T) {
+	results := []lightResult{
+		{Light: hue.Light{Name: "a"}, Err: errors.New("failed")},
+		{Light: hue.Light{Name: "b"}, Err: errors.New("failed")},
+	}
+
+	err := NewApp().reportResults(results)
+	if code := ExitCode(err); code != ExitFailure {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitFailure, code, err)
+	}
+}
+
+func TestForEachLightParallel(t *testing.T) {
+	var lock sync.Mutex
+	running, max := 0, 0
+
+	lights := make([]hue.Light, 6)
+	results := forEachLight(lights, 2, func(light hue.Light) error {
+		lock.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+
+		return nil
+	})
+
+	if len(results) != 6 || max != 2 {
+		t.Errorf("expected 6 results with 2 workers, got %d with %d", len(results), max)
+	}
+}
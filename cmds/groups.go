@@ -21,213 +21,195 @@
 package cmds
 
 import (
-	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
-	hue "github.com/collinux/GoHue"
 	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/client"
 )
 
 type GroupOptions struct {
 	name   string
 	class  string
 	lights string
-}
 
-var (
-	groupOptions GroupOptions
-)
+	verify VerifyOptions
+}
 
-func initGroup(cmd *cobra.Command) {
+func initGroup(app *App, cmd *cobra.Command) {
 	// hue-cli list-groups
-	cmd.AddCommand(cmdListGroups)
-	addBridgeOptions(cmdListGroups)
-	cmdListGroups.SilenceUsage = true
+	cmd.AddCommand(newListGroupsCommand(app))
 
 	// hue-cli new-group
-	cmd.AddCommand(cmdNewGroup)
-	addBridgeOptions(cmdNewGroup)
-	// hue-cli new-group --name=newgroupname
-	cmdNewGroup.Flags().StringVar(&groupOptions.name, "name", "",
-		"name of the new group")
-	// hue-cli new-group --class=Bedroom
-	cmdNewGroup.Flags().StringVar(&groupOptions.class, "class", "Other",
-		"type of the room (Bedroom, Kitchen, ...)")
-	// hue-cli new-group --lights=2,3,4
-	cmdNewGroup.Flags().StringVar(&groupOptions.lights, "lights", "",
-		"list of indexes with the lights that should get added to the group")
-	cmdNewGroup.SilenceUsage = true
+	cmd.AddCommand(newNewGroupCommand(app))
 
 	// hue-cli delete-group
-	cmd.AddCommand(cmdDeleteGroup)
-	addBridgeOptions(cmdDeleteGroup)
-	// hue-cli delete-group --bridge=<ip-address>
-	// hue-cli delete-group --name=newgroupname
-	cmdDeleteGroup.Flags().StringVar(&groupOptions.name, "name", "",
-		"name of the group to delete")
+	cmd.AddCommand(newDeleteGroupCommand(app))
 
 	// hue-cli toggle-group
-	cmd.AddCommand(cmdToggleGroup)
-	addBridgeOptions(cmdToggleGroup)
-	// hue-cli toggle-group --name=groupname
-	cmdToggleGroup.Flags().StringVar(&groupOptions.name, "name", "",
-		"name of the new group")
-	cmdToggleGroup.SilenceUsage = true
+	cmd.AddCommand(newToggleGroupCommand(app))
 }
 
-var cmdListGroups = &cobra.Command{
-	Use:   "list-groups",
-	Short: "list all lights attached to the bright",
-	Long:  "list all lights attached to the bridge",
+func newListGroupsCommand(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:          "list-groups",
+		Short:        "list all lights attached to the bright",
+		Long:         "list all lights attached to the bridge",
+		SilenceUsage: true,
 
-	RunE: func(cmd *cobra.Command, args []string) error {
-		bridge, err := getBridge()
-		if err != nil {
-			return err
-		}
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
 
-		groups, err := bridge.GetAllGroups()
-		if err != nil {
-			return err
-		}
-
-		fmt.Printf("Found %d groups\n", len(groups))
-		for _, group := range groups {
-			fmt.Printf("%s\n", groupToString(group))
-		}
-		return nil
-	},
-}
+			groups, err := c.ListGroups()
+			if err != nil {
+				return err
+			}
 
-var cmdNewGroup = &cobra.Command{
-	Use:   "new-group",
-	Short: "create a new group",
-	Long:  "create a new group with selected lights",
+			if app.output.format == "json" {
+				output := []client.GroupSummary{}
+				for _, group := range groups {
+					output = append(output, client.NewGroupSummary(group))
+				}
+				return printJSON(output)
+			}
 
-	RunE: func(cmd *cobra.Command, args []string) error {
-		bridge, err := getBridge()
-		if err != nil {
-			return err
-		}
+			fmt.Printf("Found %d groups\n", len(groups))
+			for _, group := range groups {
+				fmt.Printf("%s\n", client.FormatGroup(group))
+			}
+			return nil
+		},
+	}
+}
 
-		if groupOptions.name == "" {
-			return errors.New("can create group, no --name=newgroupname passed")
-		}
+func newNewGroupCommand(app *App) *cobra.Command {
+	var groupOptions GroupOptions
 
-		if groupOptions.lights == "" {
-			return errors.New("can create group, no --lights=2,3,4 passed")
-		}
+	cmd := &cobra.Command{
+		Use:          "new-group",
+		Short:        "create a new group",
+		Long:         "create a new group with selected lights",
+		SilenceUsage: true,
 
-		lights := []hue.Light{}
-		lightsStr := strings.Split(groupOptions.lights, ",")
-		for _, indexStr := range(lightsStr) {
-			indexInt, err := strconv.Atoi(indexStr)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
 			if err != nil {
-				return errors.New(fmt.Sprintf("failed to convert light-index %s to integer", indexStr))
+				return err
 			}
 
-			light, err := bridge.GetLightByIndex(indexInt)
-			if err != nil {
-				return errors.New(fmt.Sprintf("failed to get light for index %d: %s", indexInt, err))
+			if groupOptions.name == "" {
+				return invalidInputError("can create group, no --name=newgroupname passed")
 			}
 
-			lights = append(lights, light)
-		}
+			if groupOptions.lights == "" {
+				return invalidInputError("can create group, no --lights=2,3,4 passed")
+			}
 
-		_, err = bridge.NewGroup(groupOptions.name, groupOptions.class, lights)
-		if err != nil {
-			return errors.New(fmt.Sprintf("failed to create group: %s", err))
-		}
+			lights := []int{}
+			lightsStr := strings.Split(groupOptions.lights, ",")
+			for _, indexStr := range lightsStr {
+				indexInt, err := strconv.Atoi(indexStr)
+				if err != nil {
+					return invalidInputError("failed to convert light-index %s to integer", indexStr)
+				}
 
-		return nil
-	},
-}
+				lights = append(lights, indexInt)
+			}
 
-var cmdDeleteGroup = &cobra.Command{
-	Use:   "delete-group",
-	Short: "delete a group",
-	Long:  "delete a group",
+			_, err = c.NewGroup(groupOptions.name, groupOptions.class, lights)
 
-	RunE: func(cmd *cobra.Command, args []string) error {
-		bridge, err := getBridge()
-		if err != nil {
 			return err
-		}
+		},
+	}
+
+	// hue-cli new-group --name=newgroupname
+	cmd.Flags().StringVar(&groupOptions.name, "name", "",
+		"name of the new group")
+	// hue-cli new-group --class=Bedroom
+	cmd.Flags().StringVar(&groupOptions.class, "class", "Other",
+		"type of the room (Bedroom, Kitchen, ...)")
+	// hue-cli new-group --lights=2,3,4
+	cmd.Flags().StringVar(&groupOptions.lights, "lights", "",
+		"list of indexes with the lights that should get added to the group")
+	cmd.RegisterFlagCompletionFunc("lights", completeList(app.completeInventory(inventoryLights, true)))
+	cmd.RegisterFlagCompletionFunc("class", completeRoomClasses)
 
-		if groupOptions.name == "" {
-			return errors.New("can create group, no --name=newgroupname passed")
-		}
+	return cmd
+}
 
-		group, err := bridge.GetGroupByName(groupOptions.name)
-		if err != nil {
-			return errors.New(fmt.Sprintf("failed to get group: %s", err))
-		}
+func newDeleteGroupCommand(app *App) *cobra.Command {
+	var groupOptions GroupOptions
 
-		err = group.Delete()
-		if err != nil {
-			return errors.New(fmt.Sprintf("failed to delete group: %s", err))
-		}
+	cmd := &cobra.Command{
+		Use:   "delete-group",
+		Short: "delete a group",
+		Long:  "delete a group",
 
-		return nil
-	},
-}
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
 
-var cmdToggleGroup = &cobra.Command{
-	Use:   "toggle-group",
-	Short: "toggle the light-switch for a group",
-	Long:  "toggle the light-switch for a group",
+			if groupOptions.name == "" {
+				return invalidInputError("can create group, no --name=newgroupname passed")
+			}
 
-	RunE: func(cmd *cobra.Command, args []string) error {
-		bridge, err := getBridge()
-		if err != nil {
-			return err
-		}
-
-		if groupOptions.name == "" {
-			return errors.New("can create group, no --name=newgroupname passed")
-		}
-
-		group, err := bridge.GetGroupByName(groupOptions.name)
-		if err != nil {
-			return errors.New(fmt.Sprintf("could not find group %s: %s", groupOptions.name, err))
-		}
-
-		// depending on the state, need to turn on or off
-		if group.State.AnyOn {
-			err = group.Off()
-		} else {
-			err = group.On()
-		}
-
-		if err != nil {
-			return errors.New(fmt.Sprintf("failed to toggle light-switch for group %s: %s", groupOptions.name, err))
-		}
-
-		return nil
-	},
+			return c.DeleteGroup(groupOptions.name)
+		},
+	}
+
+	// hue-cli delete-group --name=newgroupname
+	cmd.Flags().StringVar(&groupOptions.name, "name", "",
+		"name of the group to delete")
+	cmd.RegisterFlagCompletionFunc("name", app.completeInventory(inventoryGroups, false))
+
+	return cmd
 }
 
-func groupToString(group hue.Group) string {
-	status := "lights are off"
-	if group.State.AllOn {
-		status = "lights are on"
-	} else if group.State.AnyOn {
-		status = "some lights are on"
-	}
+func newToggleGroupCommand(app *App) *cobra.Command {
+	var groupOptions GroupOptions
 
-	s := fmt.Sprintf("Group: %s\n"+
-		"Status: %s\n"+
-		"Type: %s",
-		group.Name, status, group.Type)
+	cmd := &cobra.Command{
+		Use:          "toggle-group",
+		Short:        "toggle the light-switch for a group",
+		Long:         "toggle the light-switch for a group",
+		SilenceUsage: true,
 
-	if len(group.Lights) > 0 {
-		s += "\nLights:"
-	}
-	for _, light := range group.Lights {
-		s += fmt.Sprintf("\n\t- %s", light.Name)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			if groupOptions.name == "" {
+				return invalidInputError("can create group, no --name=newgroupname passed")
+			}
+
+			group, err := c.ToggleGroup(groupOptions.name)
+			if err != nil {
+				return err
+			}
+
+			expected := map[int]map[string]interface{}{}
+			for _, light := range group.Lights {
+				expected[light.Index] = map[string]interface{}{"on": !group.State.AnyOn}
+			}
+
+			return app.verifyLights(c, groupOptions.verify, expected, -1)
+		},
 	}
 
-	return s
+	// hue-cli toggle-group --name=groupname
+	cmd.Flags().StringVar(&groupOptions.name, "name", "",
+		"name of the new group")
+	addVerifyOptions(cmd, &groupOptions.verify)
+	cmd.RegisterFlagCompletionFunc("name", app.completeInventory(inventoryGroups, false))
+
+	return cmd
 }
@@ -0,0 +1,167 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/huetest"
+)
+
+func TestListGroups(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "list-groups")
+	if err != nil {
+		t.Fatalf("list-groups failed: %s", err)
+	}
+
+	if !strings.HasPrefix(out, "Found 1 groups\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if !strings.Contains(out, "Group: Office\nStatus: some lights are on\n") {
+		t.Errorf("group status missing in output:\n%s", out)
+	}
+}
+
+func TestGroupsV2(t *testing.T) {
+	server, v2 := startV2Bridge(t)
+	requests := recordRequests(server)
+
+	out, err := runHueCli(t, server, "list-groups", v2)
+	if err != nil {
+		t.Fatalf("list-groups failed: %s", err)
+	}
+	if !strings.HasPrefix(out, "Found 2 groups\n") ||
+		!strings.Contains(out, "Group: Living room\nStatus: some lights are on\nType: Room\nLights:\n\t- Desk Lamp\n\t- TV Lightstrip\n") ||
+		!strings.Contains(out, "Group: Downstairs\nStatus: some lights are on\nType: Zone\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	_, err = runHueCli(t, server, "toggle-group", "--name=Living room", v2)
+	if err != nil {
+		t.Fatalf("toggle-group failed: %s", err)
+	}
+	lights := server.Bridge.State().Lights
+	if lights["1"].State.On || lights["2"].State.On {
+		t.Error("lights were not switched off")
+	}
+
+	// the groups are read and switched through the CLIP v2 API
+	for _, request := range []string{
+		"GET /clip/v2/resource/room",
+		"GET /clip/v2/resource/zone",
+		"GET /clip/v2/resource/grouped_light",
+		"PUT /clip/v2/resource/grouped_light/" + huetest.V2ID("grouped_light", "1"),
+	} {
+		if !hasRequest(requests(), request) {
+			t.Errorf("request %s is missing in %v", request, requests())
+		}
+	}
+	if hasRequest(requests(), "PUT /api/") {
+		t.Errorf("the v1 API was used to switch the group: %v", requests())
+	}
+}
+
+func TestNewGroup(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "new-group", "--name=Hall", "--class=Hallway", "--lights=3")
+	if err != nil {
+		t.Fatalf("new-group failed: %s", err)
+	}
+
+	groups := server.Bridge.State().Groups
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	group := groups["2"]
+	if group == nil || group.Name != "Hall" || group.Class != "Hallway" || len(group.Lights) != 1 || group.Lights[0] != "3" {
+		t.Errorf("unexpected group: %+v", group)
+	}
+
+	_, err = runHueCli(t, server, "new-group", "--name=Hall", "--lights=a")
+	if err == nil {
+		t.Error("expected an error for an invalid light index")
+	}
+
+	_, err = runHueCli(t, server, "new-group", "--lights=3")
+	if err == nil {
+		t.Error("expected an error without --name")
+	}
+}
+
+func TestDeleteGroup(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "delete-group", "--name=Office")
+	if err != nil {
+		t.Fatalf("delete-group failed: %s", err)
+	}
+
+	if len(server.Bridge.State().Groups) != 0 {
+		t.Error("group was not deleted")
+	}
+
+	_, err = runHueCli(t, server, "delete-group", "--name=Office")
+	if err == nil {
+		t.Error("expected an error for an unknown group")
+	}
+}
+
+func TestToggleGroup(t *testing.T) {
+	server := startBridge(t)
+
+	// some lights are on, toggling switches all lights off
+	_, err := runHueCli(t, server, "toggle-group", "--name=Office")
+	if err != nil {
+		t.Fatalf("toggle-group failed: %s", err)
+	}
+
+	lights := server.Bridge.State().Lights
+	if lights["1"].State.On || lights["2"].State.On {
+		t.Error("lights were not switched off")
+	}
+
+	_, err = runHueCli(t, server, "toggle-group", "--name=Office")
+	if err != nil {
+		t.Fatalf("toggle-group failed: %s", err)
+	}
+
+	lights = server.Bridge.State().Lights
+	if !lights["1"].State.On || !lights["2"].State.On {
+		t.Error("lights were not switched on")
+	}
+}
+
+func TestResolveSuggestion(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "toggle-group", "--name=Ofice")
+	if code := ExitCode(err); code != ExitNotFound {
+		t.Fatalf("expected exit code %d, got %d (%v)", ExitNotFound, code, err)
+	}
+
+	if !strings.Contains(err.Error(), "did you mean 'Office'?") {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -21,20 +21,143 @@
 package cmds
 
 import (
+	"sync"
+
 	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/utils"
 )
 
-var HueCli = &cobra.Command{
-	Use:   "hue-cli",
-	Short: "Commandline application to show the capabilities of GoHue",
-	Long:  "Commandline application to show the capabilities of GoHue",
+// An App is an instance of hue-cli with its own tree of commands. The global
+// options, and the state that is set up from them, are kept in the App and
+// passed to the commands, so that more than one App can be used after each
+// other. GoHue sends its requests through http.DefaultTransport, which the
+// App replaces with its chain of transports, so Apps can not run at the same
+// time.
+type App struct {
+	root *cobra.Command
+
+	bridge    BridgeOptions
+	log       LogOptions
+	output    OutputOptions
+	retry     RetryOptions
+	rateLimit RateLimitOptions
+	record    RecordOptions
+	dryRun    DryRunOptions
+	cache     CacheOptions
+
+	logger *utils.Logger
+
+	// scheduler is the rate limiter of the current command, and
+	// schedulerStats what it did before the command started
+	scheduler      *rateLimiter
+	schedulerStats RateLimitStats
+	// bridgeTransport sends the HTTPS requests to the bridge
+	bridgeTransport *client.BridgeTransport
+
+	// bridgeErrors contains the errors that the bridge returned for the
+	// current command, GoHue only reports the type and description, not
+	// the address
+	bridgeErrors     []BridgeError
+	bridgeErrorsLock sync.Mutex
+
+	// inventoryBridge is the ID of the bridge that the command uses, its
+	// inventory is removed when something gets added, renamed or deleted
+	inventoryBridge string
+
+	// clients are the connections that getClient made, configs the
+	// configuration files that loadConfig read, and transports the rate
+	// limiters that setupTransport made. The commands of a batch share them.
+	clients    map[string]*client.Client
+	configs    map[string]*utils.ConfigFile
+	transports map[string]*sharedTransport
+}
+
+// NewApp returns a new instance of hue-cli.
+func NewApp() *App {
+	app := &App{
+		logger:     &utils.Logger{},
+		clients:    map[string]*client.Client{},
+		configs:    map[string]*utils.ConfigFile{},
+		transports: map[string]*sharedTransport{},
+	}
+
+	app.root = &cobra.Command{
+		Use:   "hue-cli",
+		Short: "Commandline application to show the capabilities of GoHue",
+		Long:  "Commandline application to show the capabilities of GoHue",
+
+		// errors are reported by Execute
+		SilenceErrors: true,
+
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// these commands parse the options and call setup themselves
+			if cmd.DisableFlagParsing {
+				return nil
+			}
+
+			return app.setup(cmd)
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			app.reportRateLimits()
+		},
+	}
+
+	app.root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &Error{Code: ExitInvalidInput, Err: err}
+	})
+
+	initAlias(app, app.root)
+	initBatch(app, app.root)
+	initBridge(app, app.root)
+	initCache(app, app.root)
+	initConfig(app, app.root)
+	initDevices(app, app.root)
+	initDiscover(app, app.root)
+	initDryRun(app, app.root)
+	initEffects(app, app.root)
+	initEmulate(app, app.root)
+	initGroup(app, app.root)
+	initLights(app, app.root)
+	initLog(app, app.root)
+	initOutput(app, app.root)
+	initRateLimit(app, app.root)
+	initRecord(app, app.root)
+	initRetry(app, app.root)
+	initRun(app, app.root)
+	initScenes(app, app.root)
+	initSensors(app, app.root)
+	initSnapshot(app, app.root)
+	initUser(app, app.root)
+	initWatch(app, app.root)
+	initCompletion(app, app.root)
+
+	return app
+}
+
+// setup configures the App with the options of the command, after they are
+// parsed.
+func (app *App) setup(cmd *cobra.Command) error {
+	err := app.setupLogger()
+	if err != nil {
+		return err
+	}
+
+	err = app.setupOutput()
+	if err != nil {
+		return err
+	}
+
+	err = app.loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	return app.setupTransport()
 }
 
-func init() {
-	initBridge(HueCli)
-	initDiscover(HueCli)
-	initGroup(HueCli)
-	initLights(HueCli)
-	initSensors(HueCli)
-	initUser(HueCli)
+// Command returns the root command of the App.
+func (app *App) Command() *cobra.Command {
+	return app.root
 }
@@ -0,0 +1,157 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/huetest"
+)
+
+// testUser is the whitelisted user in testdata/bridge.yaml
+const testUser = "testuser"
+
+// startBridge starts a fake bridge with the state from testdata/bridge.yaml.
+// Snapshots and the inventory cache are stored in temporary directories for
+// the duration of the test.
+func startBridge(t *testing.T) *huetest.Server {
+	return startFixture(t, "testdata/bridge.yaml")
+}
+
+// startFixture starts a fake bridge with the state from the fixture.
+func startFixture(t *testing.T, fixture string) *huetest.Server {
+	state, err := huetest.LoadState(fixture)
+	if err != nil {
+		t.Fatalf("failed to load bridge state: %s", err)
+	}
+
+	server := huetest.NewServer(state)
+	t.Cleanup(server.Close)
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	return server
+}
+
+// recordRequests records the method and path of the requests that the fake
+// bridge receives over HTTP and HTTPS, the returned function returns them.
+func recordRequests(server *huetest.Server) func() []string {
+	var lock sync.Mutex
+	requests := []string{}
+
+	record := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		lock.Unlock()
+
+		server.Bridge.ServeHTTP(w, r)
+	})
+	server.Config.Handler = record
+	server.TLS.Config.Handler = record
+
+	return func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+
+		return append([]string{}, requests...)
+	}
+}
+
+// hasRequest returns true when one of the requests starts with the prefix.
+func hasRequest(requests []string, prefix string) bool {
+	for _, request := range requests {
+		if strings.HasPrefix(request, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runHueCli executes hue-cli with the arguments against the fake bridge, and
+// returns what the command wrote to stdout.
+func runHueCli(t *testing.T, server *huetest.Server, args ...string) (string, error) {
+	t.Helper()
+
+	return runApp(t, NewApp(), server, args...)
+}
+
+// runApp executes the App with the arguments against the fake bridge.
+func runApp(t *testing.T, app *App, server *huetest.Server, args ...string) (string, error) {
+	t.Helper()
+
+	return executeApp(t, app, append(args, "--bridge="+server.Address(), "--username="+testUser)...)
+}
+
+// execute runs hue-cli with the arguments, and returns what the command wrote
+// to stdout.
+func execute(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	return executeApp(t, NewApp(), args...)
+}
+
+// executeApp runs the App with the arguments, and returns what the command
+// wrote to stdout.
+func executeApp(t *testing.T, app *App, args ...string) (string, error) {
+	t.Helper()
+
+	app.root.SetArgs(args)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %s", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() {
+		os.Stdout = stdout
+	}()
+
+	out := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		out <- buf.String()
+	}()
+
+	err = app.root.Execute()
+	w.Close()
+
+	return <-out, err
+}
+
+func TestUnauthorizedUser(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := execute(t, "list-lights", "--bridge="+server.Address(), "--username=unknown")
+	if err == nil {
+		t.Fatal("expected an error for an unknown user")
+	}
+}
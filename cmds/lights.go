@@ -21,10 +21,15 @@
 package cmds
 
 import (
+	"errors"
 	"fmt"
 
 	hue "github.com/collinux/GoHue"
 	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/clipv2"
+	"github.com/nixpanic/hue-cli/utils"
 )
 
 type LightOptions struct {
@@ -32,113 +37,237 @@ type LightOptions struct {
 	toggle    bool
 	colorLoop bool
 	blink     int
-}
 
-var (
-	lightOptions LightOptions
-)
+	executor ExecutorOptions
+	verify   VerifyOptions
+}
 
-func initLights(cmd *cobra.Command) {
+func initLights(app *App, cmd *cobra.Command) {
 	// hue-cli list-lights
-	cmd.AddCommand(cmdListLights)
-	addBridgeOptions(cmdListLights)
-	cmdListLights.SilenceUsage = true
+	cmd.AddCommand(newListLightsCommand(app))
 
 	// hue-cli light
-	cmd.AddCommand(cmdLight)
-	addBridgeOptions(cmdLight)
-	cmdLight.Flags().StringVar(&lightOptions.light, "light", "",
-		"act on the given light by name")
-	cmdLight.Flags().BoolVar(&lightOptions.toggle, "toggle", false,
+	cmd.AddCommand(newLightCommand(app))
+}
+
+func newListLightsCommand(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:          "list-lights",
+		Short:        "list all lights attached to the bright",
+		Long:         "list all lights attached to the bridge",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			lights, err := c.ListLights()
+			if err != nil {
+				return err
+			}
+
+			if app.output.format == "json" {
+				output := []client.LightSummary{}
+				for _, light := range lights {
+					output = append(output, client.NewLightSummary(light))
+				}
+				return printJSON(output)
+			}
+
+			fmt.Printf("Found %d lights\n", len(lights))
+			for _, light := range lights {
+				fmt.Printf("%s\n", client.FormatLight(light))
+			}
+			return nil
+		},
+	}
+}
+
+func newLightCommand(app *App) *cobra.Command {
+	var lightOptions LightOptions
+
+	cmd := &cobra.Command{
+		Use:          "lights",
+		Short:        "list all lights attached to the bright",
+		Long:         "list all lights attached to the bridge",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			if lightOptions.light == "" {
+				return invalidInputError("--light=<name> is required")
+			}
+
+			// the lights are switched through the CLIP v2 API when the
+			// bridge serves it
+			if lightOptions.toggle {
+				if _, err := c.V2(); err == nil {
+					return app.toggleV2Lights(c, lightOptions)
+				}
+			}
+
+			lights, err := c.SelectLights(lightOptions.light)
+			if err != nil {
+				return err
+			}
+
+			results := forEachLight(lights, lightOptions.executor.parallel, func(light hue.Light) error {
+				return app.lightAction(c, light, lightOptions)
+			})
+
+			return app.reportResults(results)
+		},
+	}
+
+	addExecutorOptions(cmd, &lightOptions.executor)
+	addVerifyOptions(cmd, &lightOptions.verify)
+	// hue-cli lights --light=<names>
+	cmd.Flags().StringVar(&lightOptions.light, "light", "",
+		"act on the given lights, a comma separated list of names and/or indexes")
+	// hue-cli lights --toggle
+	cmd.Flags().BoolVar(&lightOptions.toggle, "toggle", false,
 		"Toggle light switch")
-	cmdLight.Flags().BoolVar(&lightOptions.colorLoop, "colorloop", false,
+	// hue-cli lights --colorloop
+	cmd.Flags().BoolVar(&lightOptions.colorLoop, "colorloop", false,
 		"enable/disable color-loop for a light")
-	cmdLight.Flags().IntVar(&lightOptions.blink, "blink", -1,
+	// hue-cli lights --blink=<seconds>
+	cmd.Flags().IntVar(&lightOptions.blink, "blink", -1,
 		"blink a light for the given number of seconds")
-	cmdLight.SilenceUsage = true
+	cmd.RegisterFlagCompletionFunc("light", completeList(app.completeInventory(inventoryLights, false)))
 
+	return cmd
 }
 
-var cmdListLights = &cobra.Command{
-	Use:   "list-lights",
-	Short: "list all lights attached to the bright",
-	Long:  "list all lights attached to the bridge",
+// toggleV2Lights toggles the lights through their light resources of the
+// CLIP v2 API.
+func (app *App) toggleV2Lights(c *client.Client, lightOptions LightOptions) error {
+	v2Lights, err := c.V2Lights(lightOptions.light)
+	if err != nil {
+		return err
+	}
 
-	RunE: func(cmd *cobra.Command, args []string) error {
-		bridge, err := getBridge()
+	lights := []hue.Light{}
+	byIndex := map[int]clipv2.Light{}
+	for _, light := range v2Lights {
+		lights = append(lights, hue.Light{Name: light.Metadata.Name, Index: light.V1Index()})
+		byIndex[light.V1Index()] = light
+	}
+
+	results := forEachLight(lights, lightOptions.executor.parallel, func(light hue.Light) error {
+		wasOn := byIndex[light.Index].On.On
+		err := c.SetLightOn(byIndex[light.Index], !wasOn)
 		if err != nil {
 			return err
 		}
 
-		lights, err := bridge.GetAllLights()
+		return app.verifyLights(c, lightOptions.verify, map[int]map[string]interface{}{
+			light.Index: {"on": !wasOn},
+		}, -1)
+	})
 
-		fmt.Printf("Found %d lights\n", len(lights))
-		for _, light := range lights {
-			fmt.Printf("%s\n", lightToString(light))
-		}
-		return nil
-	},
+	return app.reportResults(results)
 }
 
-var cmdLight = &cobra.Command{
-	Use:   "lights",
-	Short: "list all lights attached to the bright",
-	Long:  "list all lights attached to the bridge",
-
-	RunE: func(cmd *cobra.Command, args []string) error {
-		bridge, err := getBridge()
+// lightAction does what the options of the lights command ask for with a
+// single light.
+func (app *App) lightAction(c *client.Client, light hue.Light, lightOptions LightOptions) error {
+	if lightOptions.toggle {
+		// Toggle() refreshes the light, remember the state from before
+		wasOn := light.State.On
+		err := light.Toggle()
 		if err != nil {
 			return err
 		}
 
-		light, err := bridge.GetLightByName(lightOptions.light)
+		return app.verifyLights(c, lightOptions.verify, map[int]map[string]interface{}{
+			light.Index: {"on": !wasOn},
+		}, -1)
+	}
+
+	err := app.lightColorLoop(c, light, lightOptions.colorLoop, lightOptions.verify)
+	if err != nil {
+		return err
+	}
+
+	if lightOptions.blink != -1 {
+		err = app.lightBlink(c, light, lightOptions.blink, lightOptions.verify)
 		if err != nil {
 			return err
 		}
+	}
 
-		if lightOptions.toggle {
-			err = light.Toggle()
-			if err != nil {
-				return err
-			}
-			return nil
-		}
+	return nil
+}
+
+// lightColorLoop enables or disables the color-loop for a light. The state of
+// the light is saved when the color-loop gets enabled, and restored when it
+// is disabled again.
+func (app *App) lightColorLoop(c *client.Client, light hue.Light, activate bool, verify VerifyOptions) error {
+	// the index of the light is only unique on its bridge
+	name := fmt.Sprintf("colorloop-%s-%d", c.ID(), light.Index)
 
-		// TODO: split colorLoop into its own function
-		err = light.ColorLoop(lightOptions.colorLoop)
+	if activate && light.State.Effect != "colorloop" && !app.dryRun.dryRun {
+		err := c.Snapshot([]hue.Light{light}).Save(name)
 		if err != nil {
-			return err
+			return errors.New(fmt.Sprintf("failed to save the state of '%s': %s", light.Name, err))
 		}
+	}
 
-		var action string
-		if lightOptions.colorLoop {
-			action = "Activated"
-		} else {
-			action = "Deactivated"
-		}
-		fmt.Printf("%s color-loop for '%s'\n",  action, light.Name)
+	err := light.ColorLoop(activate)
+	if err != nil {
+		return err
+	}
+
+	effect := "none"
+	if activate {
+		effect = "colorloop"
+	}
+	err = app.verifyLights(c, verify, map[int]map[string]interface{}{
+		light.Index: {"effect": effect},
+	}, -1)
+	if err != nil {
+		return err
+	}
 
-		// TODO: split blink into its own function
-		if lightOptions.blink != -1 {
-			fmt.Printf("blinking %s for %d seconds\n",  light.Name, lightOptions.blink)
+	var action string
+	if activate {
+		action = "Activated"
+	} else {
+		action = "Deactivated"
 
-			err = light.Blink(lightOptions.blink)
+		// only restore when the color-loop was enabled by hue-cli
+		snapshot, err := utils.LoadSnapshot(name)
+		if err == nil && !app.dryRun.dryRun {
+			err = app.restoreSnapshot(c, snapshot, -1, verify)
 			if err != nil {
 				return err
 			}
+
+			utils.RemoveSnapshot(name)
 		}
+	}
+	app.logger.Infof("%s color-loop for '%s'\n", action, light.Name)
 
-		return nil
-	},
+	return nil
 }
 
-func lightToString(light hue.Light) string {
-	s := fmt.Sprintf("Light: %s\n"+
-		"\tIndex: %d\n"+
-		"\tType: %s\n"+
-		"\tModel: %s\n"+
-		"\tUniqueID: %s",
-		light.Name, light.Index, light.Type, light.ModelID, light.UniqueID)
+// lightBlink blinks the light for the given number of seconds, and restores
+// the state that the light had before blinking.
+func (app *App) lightBlink(c *client.Client, light hue.Light, seconds int, verify VerifyOptions) error {
+	snapshot := c.Snapshot([]hue.Light{light})
+
+	app.logger.Infof("blinking %s for %d seconds\n", light.Name, seconds)
+
+	err := light.Blink(seconds)
+	if err != nil {
+		return err
+	}
 
-	return s
+	return app.restoreSnapshot(c, snapshot, -1, verify)
 }
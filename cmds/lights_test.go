@@ -0,0 +1,184 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/huetest"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+func TestListLights(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "list-lights")
+	if err != nil {
+		t.Fatalf("list-lights failed: %s", err)
+	}
+
+	if !strings.HasPrefix(out, "Found 3 lights\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	for _, name := range []string{"Desk Lamp", "Ceiling", "Hallway"} {
+		if !strings.Contains(out, "Light: "+name+"\n") {
+			t.Errorf("light %s missing in output:\n%s", name, out)
+		}
+	}
+}
+
+func TestLightsV2(t *testing.T) {
+	server, v2 := startV2Bridge(t)
+	requests := recordRequests(server)
+
+	out, err := runHueCli(t, server, "list-lights", v2)
+	if err != nil {
+		t.Fatalf("list-lights failed: %s", err)
+	}
+	if !strings.HasPrefix(out, "Found 3 lights\n") ||
+		!strings.Contains(out, "Light: TV Lightstrip\n\tIndex: 2\n\tType: Extended color light\n\tModel: LCX004\n\tUniqueID: 00:17:88:01:00:00:00:02-0b\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	_, err = runHueCli(t, server, "lights", "--light=TV Lightstrip", "--toggle", v2)
+	if err != nil {
+		t.Fatalf("lights --toggle failed: %s", err)
+	}
+	if !server.Bridge.State().Lights["2"].State.On {
+		t.Error("light was not switched on")
+	}
+
+	// the lights are read and switched through the CLIP v2 API
+	for _, request := range []string{
+		"GET /clip/v2/resource/light",
+		"GET /clip/v2/resource/device",
+		"PUT /clip/v2/resource/light/" + huetest.V2ID("light", "2"),
+	} {
+		if !hasRequest(requests(), request) {
+			t.Errorf("request %s is missing in %v", request, requests())
+		}
+	}
+	if hasRequest(requests(), "PUT /api/") {
+		t.Errorf("the v1 API was used to switch the light: %v", requests())
+	}
+}
+
+func TestLightToggle(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "lights", "--light=Ceiling", "--toggle")
+	if err != nil {
+		t.Fatalf("lights --toggle failed: %s", err)
+	}
+
+	if !server.Bridge.State().Lights["2"].State.On {
+		t.Error("light was not switched on")
+	}
+
+	_, err = runHueCli(t, server, "lights", "--light=Ceiling", "--toggle")
+	if err != nil {
+		t.Fatalf("lights --toggle failed: %s", err)
+	}
+
+	if server.Bridge.State().Lights["2"].State.On {
+		t.Error("light was not switched off")
+	}
+}
+
+func TestLightUnknown(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "lights", "--light=Garage", "--toggle")
+	if err == nil {
+		t.Fatal("expected an error for an unknown light")
+	}
+}
+
+func TestLightColorLoop(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "lights", "--light=Desk Lamp", "--colorloop")
+	if err != nil {
+		t.Fatalf("lights --colorloop failed: %s", err)
+	}
+
+	if !strings.Contains(out, "Activated color-loop for 'Desk Lamp'") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if server.Bridge.State().Lights["1"].State.Effect != "colorloop" {
+		t.Error("color-loop was not activated")
+	}
+
+	// the state is saved for the light on this bridge
+	bridgeID := strings.ToLower(strings.Replace(server.Bridge.State().Config.MAC, ":", "", -1))
+	if _, err := utils.LoadSnapshot("colorloop-" + bridgeID + "-1"); err != nil {
+		t.Errorf("the state of the light was not saved: %s", err)
+	}
+
+	out, err = runHueCli(t, server, "lights", "--light=Desk Lamp")
+	if err != nil {
+		t.Fatalf("lights failed: %s", err)
+	}
+
+	if !strings.Contains(out, "Deactivated color-loop for 'Desk Lamp'") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	state := server.Bridge.State().Lights["1"].State
+	if state.Effect != "none" {
+		t.Error("color-loop was not deactivated")
+	}
+	if state.ColorMode != "xy" || state.XY != [2]float64{0.4573, 0.41} || state.Bri != 200 {
+		t.Errorf("state was not restored: %+v", state)
+	}
+}
+
+func TestLightBlink(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "lights", "--light=Desk Lamp", "--blink=1")
+	if err != nil {
+		t.Fatalf("lights --blink failed: %s", err)
+	}
+
+	if !strings.Contains(out, "blinking Desk Lamp for 1 seconds") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	state := server.Bridge.State().Lights["1"].State
+	if !state.On || state.Bri != 200 || state.Alert != "none" {
+		t.Errorf("state was not restored: %+v", state)
+	}
+}
+
+func TestResolveCaseInsensitive(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "lights", "--light=ceiling", "--toggle")
+	if err != nil {
+		t.Fatalf("lights --light=ceiling failed: %s", err)
+	}
+
+	if !server.Bridge.State().Lights["2"].State.On {
+		t.Error("light was not switched on")
+	}
+}
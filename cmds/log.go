@@ -0,0 +1,113 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+type LogOptions struct {
+	verbose int
+	quiet   bool
+}
+
+func initLog(app *App, cmd *cobra.Command) {
+	// hue-cli -v / -vv
+	cmd.PersistentFlags().CountVarP(&app.log.verbose, "verbose", "v",
+		"verbose output, repeat (-vv) to include the traffic with the bridge")
+	// hue-cli --quiet
+	cmd.PersistentFlags().BoolVarP(&app.log.quiet, "quiet", "q", false,
+		"only report errors")
+}
+
+func (app *App) setupLogger() error {
+	logOptions, logger := app.log, app.logger
+
+	if logOptions.quiet && logOptions.verbose > 0 {
+		return invalidInputError("--quiet and --verbose can not be used together")
+	}
+
+	logger.Level = utils.LevelNormal
+	if logOptions.quiet {
+		logger.Level = utils.LevelQuiet
+	} else if logOptions.verbose >= 2 {
+		logger.Level = utils.LevelDebug
+	} else if logOptions.verbose == 1 {
+		logger.Level = utils.LevelVerbose
+	}
+
+	return nil
+}
+
+// tracingTransport logs all requests and responses with the bridge.
+type tracingTransport struct {
+	transport http.RoundTripper
+	logger    *utils.Logger
+}
+
+func (tt *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	logger := tt.logger
+
+	path, username := redactPath(req.URL.RequestURI())
+	logger.Debugf("> %s %s://%s%s\n", req.Method, req.URL.Scheme, req.URL.Host, path)
+
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		if len(body) != 0 {
+			logger.Debugf("> %s\n", redactBody(body, username))
+		}
+	}
+
+	start := time.Now()
+	resp, err := tt.transport.RoundTrip(req)
+	latency := time.Since(start)
+	if err != nil {
+		logger.Debugf("< %s (%s)\n", err, latency)
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	logger.Debugf("< %s (%s)\n", resp.Status, latency)
+	if len(body) != 0 {
+		logger.Debugf("< %s\n", redactBody(body, username))
+	}
+
+	return resp, nil
+}
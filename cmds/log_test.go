@@ -0,0 +1,72 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogLevels(t *testing.T) {
+	server := startBridge(t)
+
+	// the flags are kept in the App, so each run gets a new one
+	var stderr bytes.Buffer
+	newApp := func() *App {
+		app := NewApp()
+		app.logger.Err = &stderr
+		return app
+	}
+
+	out, err := runApp(t, newApp(), server, "discover-lights", "--quiet")
+	if err != nil {
+		t.Fatalf("discover-lights --quiet failed: %s", err)
+	}
+	if out != "" || stderr.Len() != 0 {
+		t.Errorf("unexpected output with --quiet:\n%s%s", out, stderr.String())
+	}
+
+	_, err = runApp(t, newApp(), server, "discover-lights", "-v")
+	if err != nil {
+		t.Fatalf("discover-lights -v failed: %s", err)
+	}
+	if !strings.Contains(stderr.String(), "logged in on bridge") || strings.Contains(stderr.String(), "> GET") {
+		t.Errorf("unexpected output with -v:\n%s", stderr.String())
+	}
+
+	stderr.Reset()
+	_, err = runApp(t, newApp(), server, "discover-lights", "-vv")
+	if err != nil {
+		t.Fatalf("discover-lights -vv failed: %s", err)
+	}
+	if !strings.Contains(stderr.String(), "> POST http://"+server.Address()+"/api/"+redactedUser+"/lights") {
+		t.Errorf("request missing with -vv:\n%s", stderr.String())
+	}
+	if strings.Contains(stderr.String(), testUser) {
+		t.Errorf("username was not masked:\n%s", stderr.String())
+	}
+
+	_, err = runHueCli(t, server, "discover-lights", "-v", "--quiet")
+	if err == nil {
+		t.Error("expected an error for --quiet with -v")
+	}
+}
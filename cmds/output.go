@@ -0,0 +1,65 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+type OutputOptions struct {
+	format string
+}
+
+func initOutput(app *App, cmd *cobra.Command) {
+	// hue-cli --output=json
+	cmd.PersistentFlags().StringVarP(&app.output.format, "output", "o", "text",
+		"format of the output (text or json)")
+}
+
+func (app *App) setupOutput() error {
+	if app.output.format != "text" && app.output.format != "json" {
+		return invalidInputError("--output=%s is not supported, use text or json", app.output.format)
+	}
+
+	// keep stdout for the JSON document, progress goes to stderr
+	app.logger.Out = nil
+	if app.output.format == "json" {
+		app.logger.Out = os.Stderr
+	}
+
+	return nil
+}
+
+// printJSON writes the value as indented JSON to stdout.
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+
+	return nil
+}
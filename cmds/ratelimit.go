@@ -0,0 +1,289 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+type RateLimitOptions struct {
+	lightRate float64
+	groupRate float64
+}
+
+func initRateLimit(app *App, cmd *cobra.Command) {
+	// hue-cli --light-rate=<updates per second>
+	cmd.PersistentFlags().Float64Var(&app.rateLimit.lightRate, "light-rate", 10,
+		"maximum number of light updates per second (0 for unlimited)")
+	// hue-cli --group-rate=<updates per second>
+	cmd.PersistentFlags().Float64Var(&app.rateLimit.groupRate, "group-rate", 1,
+		"maximum number of group updates per second (0 for unlimited)")
+}
+
+// RateLimitStats counts what the rate limiter did with the requests.
+type RateLimitStats struct {
+	// Throttled requests had to wait before they were sent
+	Throttled int
+	// Coalesced requests were merged with a waiting update of the same
+	// light or group
+	Coalesced int
+	// Dropped requests were not handled by the bridge
+	Dropped int
+}
+
+// since returns what happened after the earlier stats.
+func (stats RateLimitStats) since(earlier RateLimitStats) RateLimitStats {
+	return RateLimitStats{
+		Throttled: stats.Throttled - earlier.Throttled,
+		Coalesced: stats.Coalesced - earlier.Coalesced,
+		Dropped:   stats.Dropped - earlier.Dropped,
+	}
+}
+
+// budget spreads the requests evenly over time.
+type budget struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func newBudget(rate float64) *budget {
+	if rate <= 0 {
+		return nil
+	}
+
+	return &budget{interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// reserve returns how long to wait before the request can be sent.
+func (b *budget) reserve(now time.Time) time.Duration {
+	if b.next.Before(now) {
+		b.next = now
+	}
+
+	delay := b.next.Sub(now)
+	b.next = b.next.Add(b.interval)
+
+	return delay
+}
+
+// queuedRequest is an update that is waiting for its turn. Updates of the
+// same resource that arrive while waiting are merged into the body.
+type queuedRequest struct {
+	body map[string]interface{}
+	done chan struct{}
+
+	resp *http.Response
+	data []byte
+	err  error
+}
+
+// response returns a copy of the response for each of the callers.
+func (q *queuedRequest) response() (*http.Response, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	resp := *q.resp
+	resp.Body = ioutil.NopCloser(bytes.NewReader(q.data))
+
+	return &resp, nil
+}
+
+// rateLimiter sends the state changes of lights and groups within the
+// budget that the bridge can handle. Reads are not limited.
+type rateLimiter struct {
+	transport http.RoundTripper
+	logger    *utils.Logger
+
+	lock    sync.Mutex
+	lights  *budget
+	groups  *budget
+	pending map[string]*queuedRequest
+	stats   RateLimitStats
+}
+
+func newRateLimiter(transport http.RoundTripper, lightRate, groupRate float64, logger *utils.Logger) *rateLimiter {
+	return &rateLimiter{
+		transport: transport,
+		logger:    logger,
+		lights:    newBudget(lightRate),
+		groups:    newBudget(groupRate),
+		pending:   make(map[string]*queuedRequest),
+	}
+}
+
+// Stats returns what the rate limiter did so far.
+func (rl *rateLimiter) Stats() RateLimitStats {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	return rl.stats
+}
+
+// budget returns the budget for the resource in /api/<username>/<resource>/...
+// or /clip/v2/resource/<type>/<id>.
+func (rl *rateLimiter) budget(path string) *budget {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) >= 4 && parts[0] == "clip" && parts[1] == "v2" && parts[2] == "resource" {
+		switch parts[3] {
+		case "light":
+			return rl.lights
+		case "grouped_light":
+			return rl.groups
+		}
+
+		return nil
+	}
+
+	if len(parts) < 3 || parts[0] != "api" {
+		return nil
+	}
+
+	switch parts[2] {
+	case "lights":
+		return rl.lights
+	case "groups":
+		return rl.groups
+	}
+
+	return nil
+}
+
+func (rl *rateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	b := rl.budget(req.URL.Path)
+	if b == nil || req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return rl.transport.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var update map[string]interface{}
+	if req.Method != http.MethodPut || json.Unmarshal(body, &update) != nil {
+		update = nil
+	}
+	key := req.URL.Host + req.URL.Path
+
+	rl.lock.Lock()
+	if q := rl.pending[key]; q != nil && update != nil {
+		// a newer update of the same resource replaces the values
+		for k, v := range update {
+			q.body[k] = v
+		}
+		rl.stats.Coalesced++
+		rl.lock.Unlock()
+
+		rl.logger.Verbosef("coalesced %s %s with a waiting update\n", req.Method, redactURL(req))
+		<-q.done
+		return q.response()
+	}
+
+	q := &queuedRequest{body: update, done: make(chan struct{})}
+	if update != nil {
+		rl.pending[key] = q
+	}
+	delay := b.reserve(time.Now())
+	if delay > 0 {
+		rl.stats.Throttled++
+	}
+	rl.lock.Unlock()
+
+	if delay > 0 {
+		rl.logger.Verbosef("throttling %s %s for %s\n", req.Method, redactURL(req), delay)
+
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			q.err = req.Context().Err()
+		}
+	}
+
+	rl.lock.Lock()
+	if rl.pending[key] == q {
+		delete(rl.pending, key)
+	}
+	if q.body != nil {
+		body, _ = json.Marshal(q.body)
+	}
+	rl.lock.Unlock()
+
+	if q.err == nil {
+		r := req.Clone(req.Context())
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+
+		q.resp, q.err = rl.transport.RoundTrip(r)
+		if q.err == nil {
+			q.data, q.err = ioutil.ReadAll(q.resp.Body)
+			q.resp.Body.Close()
+		}
+	}
+	close(q.done)
+
+	resp, err := q.response()
+	if err != nil || isTransient(req, resp, nil) {
+		rl.lock.Lock()
+		rl.stats.Dropped++
+		rl.lock.Unlock()
+	}
+
+	return resp, err
+}
+
+// redactURL returns the address of the request without the username.
+func redactURL(req *http.Request) string {
+	path, _ := redactPath(req.URL.Path)
+
+	return req.URL.Host + path
+}
+
+// reportRateLimits tells what the rate limiter did with the requests of the
+// command.
+func (app *App) reportRateLimits() {
+	if app.scheduler == nil {
+		return
+	}
+
+	stats := app.scheduler.Stats().since(app.schedulerStats)
+	if stats.Throttled > 0 || stats.Coalesced > 0 {
+		app.logger.Verbosef("rate limit: %d requests throttled, %d coalesced\n", stats.Throttled, stats.Coalesced)
+	}
+	if stats.Dropped > 0 {
+		app.logger.Warnf("WARNING: %d requests were not handled by the bridge\n", stats.Dropped)
+	}
+}
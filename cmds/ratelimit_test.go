@@ -0,0 +1,184 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// recordingServer keeps the paths and bodies of the requests it received.
+type recordingServer struct {
+	*httptest.Server
+
+	lock     sync.Mutex
+	requests []string
+	status   int
+}
+
+func newRecordingServer(t *testing.T, status int) *recordingServer {
+	rs := &recordingServer{status: status}
+	rs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		rs.lock.Lock()
+		rs.requests = append(rs.requests, r.URL.Path+" "+string(body))
+		rs.lock.Unlock()
+
+		w.WriteHeader(rs.status)
+		w.Write([]byte(`[{"success":{}}]`))
+	}))
+	t.Cleanup(rs.Close)
+
+	return rs
+}
+
+func put(t *testing.T, client *http.Client, url, body string) {
+	req, _ := http.NewRequest(http.MethodPut, url, strings.NewReader(body))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Errorf("PUT %s failed: %s", url, err)
+		return
+	}
+	resp.Body.Close()
+}
+
+func TestRateLimitThrottles(t *testing.T) {
+	server := newRecordingServer(t, http.StatusOK)
+	rl := newRateLimiter(defaultTransport, 20, 1, &utils.Logger{})
+	client := &http.Client{Transport: rl}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		put(t, client, server.URL+"/api/user/lights/1/state", `{"on":true}`)
+	}
+
+	// the first request is sent immediately, the others 50ms apart
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("requests were not throttled, took %s", elapsed)
+	}
+	if stats := rl.Stats(); stats.Throttled != 2 || stats.Dropped != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestRateLimitCoalesces(t *testing.T) {
+	server := newRecordingServer(t, http.StatusOK)
+	rl := newRateLimiter(defaultTransport, 10, 1, &utils.Logger{})
+	client := &http.Client{Transport: rl}
+
+	// use the budget, so that the next updates have to wait
+	put(t, client, server.URL+"/api/user/lights/1/state", `{"on":true}`)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		put(t, client, server.URL+"/api/user/lights/2/state", `{"on":true,"bri":10}`)
+	}()
+
+	// the second update of light 2 arrives while the first is waiting
+	time.Sleep(20 * time.Millisecond)
+	put(t, client, server.URL+"/api/user/lights/2/state", `{"bri":200}`)
+	wg.Wait()
+
+	if len(server.requests) != 2 {
+		t.Fatalf("expected 2 requests, got %v", server.requests)
+	}
+	if server.requests[1] != `/api/user/lights/2/state {"bri":200,"on":true}` {
+		t.Errorf("updates were not merged: %s", server.requests[1])
+	}
+	if stats := rl.Stats(); stats.Coalesced != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestRateLimitV2(t *testing.T) {
+	server := newRecordingServer(t, http.StatusOK)
+	rl := newRateLimiter(defaultTransport, 20, 1, &utils.Logger{})
+	client := &http.Client{Transport: rl}
+
+	// use the budgets, so that the next updates have to wait
+	put(t, client, server.URL+"/clip/v2/resource/light/abc", `{"on":{"on":true}}`)
+	put(t, client, server.URL+"/clip/v2/resource/grouped_light/def", `{"on":{"on":true}}`)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		put(t, client, server.URL+"/clip/v2/resource/light/abc", `{"effects":{"effect":"candle"}}`)
+	}()
+
+	// the second update of the light arrives while the first is waiting
+	time.Sleep(20 * time.Millisecond)
+	put(t, client, server.URL+"/clip/v2/resource/light/abc", `{"effects":{"effect":"fire"}}`)
+	wg.Wait()
+
+	// other resources are not limited
+	put(t, client, server.URL+"/clip/v2/resource/scene/ghi", `{"recall":{"action":"active"}}`)
+
+	if len(server.requests) != 4 {
+		t.Fatalf("expected 4 requests, got %v", server.requests)
+	}
+	if server.requests[2] != `/clip/v2/resource/light/abc {"effects":{"effect":"fire"}}` {
+		t.Errorf("updates were not merged: %s", server.requests[2])
+	}
+	if stats := rl.Stats(); stats.Throttled != 1 || stats.Coalesced != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestRateLimitDropped(t *testing.T) {
+	server := newRecordingServer(t, http.StatusServiceUnavailable)
+	rl := newRateLimiter(defaultTransport, 10, 1, &utils.Logger{})
+	client := &http.Client{Transport: rl}
+
+	put(t, client, server.URL+"/api/user/groups/1/action", `{"on":true}`)
+
+	if stats := rl.Stats(); stats.Dropped != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestRateLimitReads(t *testing.T) {
+	server := newRecordingServer(t, http.StatusOK)
+	rl := newRateLimiter(defaultTransport, 1, 1, &utils.Logger{})
+	client := &http.Client{Transport: rl}
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL + "/api/user/lights")
+		if err != nil {
+			t.Fatalf("GET failed: %s", err)
+		}
+		resp.Body.Close()
+	}
+
+	if stats := rl.Stats(); stats.Throttled != 0 {
+		t.Errorf("reads were throttled: %+v", stats)
+	}
+}
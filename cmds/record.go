@@ -0,0 +1,276 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// redactedUser replaces the username of the bridge in recordings
+const redactedUser = "REDACTED"
+
+type RecordOptions struct {
+	record string
+	replay string
+}
+
+func initRecord(app *App, cmd *cobra.Command) {
+	// hue-cli --record=<file>
+	cmd.PersistentFlags().StringVar(&app.record.record, "record", "",
+		"record all HTTP exchanges with the bridge in the given file")
+	// hue-cli --replay=<file>
+	cmd.PersistentFlags().StringVar(&app.record.replay, "replay", "",
+		"replay the HTTP exchanges from the given file instead of contacting the bridge")
+}
+
+// setupRecording returns the transport for --record or --replay. Recording
+// passes the requests on to the given transport.
+func (app *App) setupRecording(transport http.RoundTripper) (http.RoundTripper, error) {
+	recordOptions := app.record
+
+	if recordOptions.record != "" && recordOptions.replay != "" {
+		return nil, invalidInputError("--record and --replay can not be used together")
+	}
+
+	if recordOptions.record != "" {
+		transport = &recordingTransport{
+			transport: transport,
+			filename:  recordOptions.record,
+			cassette:  &utils.Cassette{},
+		}
+	} else if recordOptions.replay != "" {
+		cassette, err := utils.LoadCassette(recordOptions.replay)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("failed to load recording %s: %s", recordOptions.replay, err))
+		}
+
+		transport = &replayTransport{
+			cassette: cassette,
+			used:     make([]bool, len(cassette.Interactions)),
+		}
+
+		// the recording contains the bridge, and the username is not
+		// relevant
+		if app.bridge.ipaddress == "" && len(cassette.Interactions) > 0 {
+			app.bridge.ipaddress = cassette.Interactions[0].Request.Host
+		}
+		if app.bridge.username == "" {
+			app.bridge.username = redactedUser
+		}
+	}
+
+	return transport, nil
+}
+
+// redactPath replaces the username in /api/<username>/... and returns the
+// redacted path and the username.
+func redactPath(path string) (string, string) {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 3)
+	if len(parts) < 2 || parts[0] != "api" || parts[1] == "" || (parts[1] == "config" && len(parts) == 2) {
+		return path, ""
+	}
+
+	username := parts[1]
+	parts[1] = redactedUser
+
+	return "/" + strings.Join(parts, "/"), username
+}
+
+// redactBody removes the username, and the other users from the whitelist
+// in the bridge configuration.
+func redactBody(body []byte, username string) []byte {
+	if username != "" {
+		body = bytes.Replace(body, []byte(username), []byte(redactedUser), -1)
+	}
+
+	var doc interface{}
+	err := json.Unmarshal(body, &doc)
+	if err != nil || !redactJSON(doc) {
+		// keep the body as it is, the order of attributes matters
+		return body
+	}
+
+	redacted, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+
+	return redacted
+}
+
+func redactJSON(doc interface{}) bool {
+	changed := false
+
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if whitelist, ok := value.(map[string]interface{}); ok && key == "whitelist" {
+				redacted := map[string]interface{}{}
+				for _, entry := range whitelist {
+					redacted[fmt.Sprintf("%s-%d", redactedUser, len(redacted)+1)] = entry
+				}
+				v[key] = redacted
+				changed = true
+			} else if _, ok := value.(string); ok && key == "username" {
+				v[key] = redactedUser
+				changed = true
+			} else if redactJSON(value) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if redactJSON(value) {
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+// recordingTransport passes requests on to the bridge, and writes each
+// exchange to the recording.
+type recordingTransport struct {
+	transport http.RoundTripper
+	filename  string
+
+	lock     sync.Mutex
+	cassette *utils.Cassette
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := rt.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	path, username := redactPath(req.URL.RequestURI())
+
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	rt.cassette.Interactions = append(rt.cassette.Interactions, utils.Interaction{
+		Request: utils.RecordedRequest{
+			Method: req.Method,
+			Host:   req.URL.Host,
+			Path:   path,
+			Body:   string(redactBody(reqBody, username)),
+		},
+		Response: utils.RecordedResponse{
+			Status:      resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+			Body:        string(redactBody(respBody, username)),
+		},
+	})
+
+	// save after each exchange, the command may fail later on
+	err = rt.cassette.Save(rt.filename)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("failed to save recording %s: %s", rt.filename, err))
+	}
+
+	return resp, nil
+}
+
+// replayTransport returns the responses from a recording. Exchanges are
+// replayed in the recorded order, when a request is done more often than it
+// was recorded, the last matching response is repeated.
+type replayTransport struct {
+	lock     sync.Mutex
+	cassette *utils.Cassette
+	used     []bool
+}
+
+func (rt *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	path, _ := redactPath(req.URL.RequestURI())
+
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	match := -1
+	for i, interaction := range rt.cassette.Interactions {
+		if interaction.Request.Method != req.Method || interaction.Request.Path != path {
+			continue
+		}
+
+		match = i
+		if !rt.used[i] {
+			break
+		}
+	}
+
+	if match == -1 {
+		return nil, errors.New(fmt.Sprintf("no recorded response for %s %s", req.Method, path))
+	}
+	rt.used[match] = true
+
+	recorded := rt.cassette.Interactions[match].Response
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          ioutil.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}
+	if recorded.ContentType != "" {
+		resp.Header.Set("Content-Type", recorded.ContentType)
+	}
+
+	return resp, nil
+}
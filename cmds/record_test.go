@@ -0,0 +1,74 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordRedacted(t *testing.T) {
+	server := startBridge(t)
+	cassette := filepath.Join(t.TempDir(), "bridge-config.yaml")
+
+	// the configuration contains the whitelist with all users
+	_, err := runHueCli(t, server, "bridge-config", "--record="+cassette)
+	if err != nil {
+		t.Fatalf("bridge-config --record failed: %s", err)
+	}
+
+	data, err := ioutil.ReadFile(cassette)
+	if err != nil {
+		t.Fatalf("failed to read recording: %s", err)
+	}
+	if strings.Contains(string(data), testUser) {
+		t.Errorf("username was not redacted:\n%s", data)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	server := startBridge(t)
+	cassette := filepath.Join(t.TempDir(), "list-groups.yaml")
+
+	recorded, err := runHueCli(t, server, "list-groups", "--record="+cassette)
+	if err != nil {
+		t.Fatalf("list-groups --record failed: %s", err)
+	}
+
+	// the bridge is not needed for replaying
+	server.Close()
+
+	replayed, err := execute(t, "list-groups", "--replay="+cassette)
+	if err != nil {
+		t.Fatalf("list-groups --replay failed: %s", err)
+	}
+
+	if replayed != recorded {
+		t.Errorf("replayed output differs, expected:\n%s\ngot:\n%s", recorded, replayed)
+	}
+
+	_, err = execute(t, "discover-lights", "--replay="+cassette)
+	if err == nil {
+		t.Error("expected an error for a request that was not recorded")
+	}
+}
@@ -0,0 +1,205 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+type RetryOptions struct {
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+}
+
+func initRetry(app *App, cmd *cobra.Command) {
+	// the defaults can be changed per bridge in hue-cli.yaml
+	// hue-cli --timeout=<duration>
+	cmd.PersistentFlags().DurationVar(&app.retry.timeout, "timeout", time.Second,
+		"timeout for each request to the bridge")
+	// hue-cli --retries=<count>
+	cmd.PersistentFlags().IntVar(&app.retry.retries, "retries", 2,
+		"number of times a failed request to the bridge is retried")
+	// hue-cli --backoff=<duration>
+	cmd.PersistentFlags().DurationVar(&app.retry.backoff, "backoff", 500*time.Millisecond,
+		"delay before the first retry, doubled for each next retry")
+}
+
+// clientTimeout is the timeout of the http.Client of GoHue, the request and
+// all its retries need to finish within it.
+const clientTimeout = 5 * time.Second
+
+// maxDuration returns how long a request can take with all its retries, or
+// 0 when the requests do not time out.
+func (options RetryOptions) maxDuration() time.Duration {
+	if options.timeout <= 0 {
+		return 0
+	}
+
+	duration := options.timeout * time.Duration(options.retries+1)
+	backoff := options.backoff
+	for retry := 0; retry < options.retries; retry++ {
+		duration += backoff
+		backoff *= 2
+	}
+
+	return duration
+}
+
+// checkRetry warns when the last retries of a request can not be done,
+// because the http.Client of GoHue gives up before that.
+func (app *App) checkRetry() {
+	if duration := app.retry.maxDuration(); duration > clientTimeout {
+		app.logger.Warnf("WARNING: with --timeout=%s, --retries=%d and --backoff=%s a request can take %s, "+
+			"but requests are aborted after %s\n",
+			app.retry.timeout, app.retry.retries, app.retry.backoff, duration, clientTimeout)
+	}
+}
+
+// internalError is the type of the error that the bridge returns when it
+// could not handle the request for now.
+const internalError = 901
+
+// retryTransport applies the timeout to every request, and retries requests
+// that failed with a transient error.
+type retryTransport struct {
+	transport http.RoundTripper
+	options   RetryOptions
+	logger    *utils.Logger
+}
+
+func (rt *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	backoff := rt.options.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := rt.roundTrip(req, body)
+		if attempt >= rt.options.retries || !isTransient(req, resp, err) {
+			return resp, err
+		}
+
+		if err != nil {
+			rt.logger.Verbosef("request %s %s failed, retrying in %s: %s\n", req.Method, req.URL.Host, backoff, err)
+		} else {
+			rt.logger.Verbosef("request %s %s failed, retrying in %s: %s\n", req.Method, req.URL.Host, backoff, resp.Status)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		backoff *= 2
+	}
+}
+
+// roundTrip does a single attempt of the request. The response body is read
+// completely, so that the timeout can be cancelled afterwards.
+func (rt *retryTransport) roundTrip(req *http.Request, body []byte) (*http.Response, error) {
+	ctx := req.Context()
+	if rt.options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rt.options.timeout)
+		defer cancel()
+	}
+
+	r := req.Clone(ctx)
+	if body != nil {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+	}
+
+	resp, err := rt.transport.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	return resp, nil
+}
+
+// isTransient returns true when the request can be retried. Failed requests
+// are only retried when they do not modify anything, responses of the bridge
+// that it is (temporary) not able to handle the request can always be
+// retried.
+func isTransient(req *http.Request, resp *http.Response, err error) bool {
+	// another certificate does not go away by trying again
+	var certificate *client.CertificateError
+	if errors.As(err, &certificate) {
+		return false
+	}
+
+	if err != nil {
+		// client.V2 reads the bridge resource to check whether the v2
+		// API is served, the v1 API is used when it can not connect
+		if req.URL.Path == "/clip/v2/resource/bridge" {
+			return false
+		}
+		return req.Method == http.MethodGet || req.Method == http.MethodHead
+	}
+
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return true
+	}
+
+	return responseHasErrorType(resp, internalError)
+}
+
+// responseHasErrorType checks if the bridge returned an error of the type.
+func responseHasErrorType(resp *http.Response, errType int) bool {
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	for _, be := range parseBridgeErrors(body) {
+		if be.Type == errType {
+			return true
+		}
+	}
+
+	return false
+}
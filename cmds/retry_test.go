@@ -0,0 +1,191 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// retryClient returns a client that retries requests with the options.
+func retryClient(options RetryOptions) *http.Client {
+	return &http.Client{Transport: &retryTransport{
+		transport: defaultTransport,
+		options:   options,
+		logger:    &utils.Logger{},
+	}}
+}
+
+// failingServer fails the first requests with the handler, and succeeds
+// after that. The number of requests is returned through count, which is
+// updated while the test runs.
+func failingServer(t *testing.T, failures int32, fail http.HandlerFunc, count *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1) <= failures {
+			fail(w, r)
+			return
+		}
+		w.Write([]byte(`[{"success":{}}]`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func unavailable(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+func TestRetryUnavailable(t *testing.T) {
+	options := RetryOptions{timeout: time.Second, retries: 2, backoff: time.Millisecond}
+
+	var count atomic.Int32
+	server := failingServer(t, 2, unavailable, &count)
+	client := retryClient(options)
+
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"on":true}`))
+	if err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK || count.Load() != 3 {
+		t.Errorf("expected success after 3 requests, got %s after %d", resp.Status, count.Load())
+	}
+}
+
+func TestRetryInternalError(t *testing.T) {
+	options := RetryOptions{timeout: time.Second, retries: 1, backoff: time.Millisecond}
+
+	var count atomic.Int32
+	server := failingServer(t, 1, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"error":{"type":901,"address":"/lights","description":"Internal error, 503"}}]`))
+	}, &count)
+	client := retryClient(options)
+
+	_, err := client.Get(server.URL)
+	if err != nil || count.Load() != 2 {
+		t.Errorf("expected success after 2 requests, got %v after %d", err, count.Load())
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	options := RetryOptions{timeout: time.Second, retries: 2, backoff: time.Millisecond}
+
+	var count atomic.Int32
+	server := failingServer(t, 5, unavailable, &count)
+	client := retryClient(options)
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %s", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || count.Load() != 3 {
+		t.Errorf("expected to give up after 3 requests, got %s after %d", resp.Status, count.Load())
+	}
+}
+
+func TestRetryTimeout(t *testing.T) {
+	options := RetryOptions{timeout: 20 * time.Millisecond, retries: 1, backoff: time.Millisecond}
+
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+
+	var count atomic.Int32
+	server := failingServer(t, 1, slow, &count)
+	client := retryClient(options)
+
+	// a read is retried after the timeout
+	_, err := client.Get(server.URL)
+	if err != nil || count.Load() != 2 {
+		t.Errorf("expected success after 2 requests, got %v after %d", err, count.Load())
+	}
+
+	// a change is not retried, it may have been applied
+	count.Store(0)
+	_, err = client.Post(server.URL, "application/json", strings.NewReader(`{"on":true}`))
+	if err == nil || count.Load() != 1 {
+		t.Errorf("expected a timeout after 1 request, got %v after %d", err, count.Load())
+	}
+	if code := ExitCode(err); code != ExitUnreachable {
+		t.Errorf("expected exit code %d, got %d", ExitUnreachable, code)
+	}
+}
+
+func TestRetryTimeoutBridge(t *testing.T) {
+	server := startBridge(t)
+
+	// the first request does not get an answer in time
+	var count atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		server.Bridge.ServeHTTP(w, r)
+	}))
+	t.Cleanup(slow.Close)
+
+	out, err := execute(t, "list-lights", "--timeout=100ms", "--backoff=10ms",
+		"--bridge="+strings.TrimPrefix(slow.URL, "http://"), "--username="+testUser)
+	if err != nil {
+		t.Fatalf("list-lights failed: %s", err)
+	}
+	if !strings.Contains(out, "Desk Lamp") {
+		t.Errorf("lights missing in output:\n%s", out)
+	}
+	if count.Load() < 2 {
+		t.Errorf("expected the request to be retried, got %d requests", count.Load())
+	}
+}
+
+func TestRetryMaxDuration(t *testing.T) {
+	server := startBridge(t)
+
+	var stderr bytes.Buffer
+	app := NewApp()
+	app.logger.Err = &stderr
+
+	_, err := runApp(t, app, server, "list-lights", "--timeout=2s", "--retries=2", "--backoff=500ms")
+	if err != nil {
+		t.Fatalf("list-lights failed: %s", err)
+	}
+	if !strings.Contains(stderr.String(), "a request can take 7.5s") {
+		t.Errorf("expected a warning about the timeout, got:\n%s", stderr.String())
+	}
+
+	// the defaults fit
+	if duration := NewApp().retry.maxDuration(); duration > clientTimeout {
+		t.Errorf("the default options take up to %s", duration)
+	}
+}
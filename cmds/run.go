@@ -0,0 +1,236 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+// MacroSummary describes a macro and its parameters.
+type MacroSummary struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Params      []utils.MacroParam `json:"params,omitempty"`
+	Steps       int                `json:"steps"`
+}
+
+func initRun(app *App, cmd *cobra.Command) {
+	// hue-cli run <macro> [--<param>=<value>...]
+	cmd.AddCommand(newRunCommand(app))
+}
+
+func newRunCommand(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run [<macro>] [--<param>=<value>...]",
+		Short: "run a macro from the configuration file",
+		Long: "run the steps of a macro from the configuration file one after the other, the parameters " +
+			"of the macro are passed as options. The macros are listed when no macro is given.",
+		SilenceUsage: true,
+		// the options depend on the macro, they are parsed by RunE
+		DisableFlagParsing: true,
+
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			config, err := client.LoadConfig(app.bridge.config)
+			if err != nil || len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			names := []string{}
+			for _, macro := range config.Macros {
+				names = append(names, macro.Name+"\t"+macro.Description)
+			}
+
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := cmd.Flags()
+
+			// the parameters are only known after the configuration is
+			// loaded, parse the options of hue-cli first
+			flags.ParseErrorsWhitelist.UnknownFlags = true
+			err := flags.Parse(args)
+			if err != nil {
+				return invalidInputError("%s", err)
+			} else if help, _ := flags.GetBool("help"); help {
+				return cmd.Help()
+			}
+
+			err = app.setup(cmd)
+			if err != nil {
+				return err
+			}
+
+			if flags.NArg() == 0 {
+				return app.listMacros()
+			}
+
+			macro := utils.FindMacro(app.bridge.macros, flags.Arg(0))
+			if macro == nil {
+				return &Error{Code: ExitNotFound, Err: fmt.Errorf("there is no macro '%s' in %s", flags.Arg(0), app.bridge.config)}
+			}
+
+			// the options of hue-cli are accepted next to the parameters
+			macroFlags := pflag.NewFlagSet("run "+macro.Name, pflag.ContinueOnError)
+			macroFlags.SetOutput(io.Discard)
+			macroFlags.AddFlagSet(flags)
+
+			values := map[string]*string{}
+			for _, param := range macro.Params {
+				if macroFlags.Lookup(param.Name) != nil {
+					return invalidInputError("parameter %s of macro %s is also an option of hue-cli", param.Name, macro.Name)
+				}
+				values[param.Name] = macroFlags.String(param.Name, param.Default, param.Description)
+			}
+
+			err = macroFlags.Parse(args)
+			if err != nil {
+				return invalidInputError("%s", err)
+			} else if macroFlags.NArg() != 1 {
+				return invalidInputError("run accepts a single macro, got %s", strings.Join(macroFlags.Args(), " "))
+			}
+
+			params := map[string]string{}
+			for name, value := range values {
+				if macroFlags.Changed(name) {
+					params[name] = *value
+				}
+			}
+
+			// all steps are checked before the first one is done
+			actions, err := macro.Expand(params)
+			if err != nil {
+				return invalidInputError("macro %s: %s", macro.Name, err)
+			}
+
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			for i, action := range actions {
+				err = app.macroAction(c, action)
+				if err != nil {
+					return fmt.Errorf("macro %s step %d: %w", macro.Name, i+1, err)
+				}
+			}
+
+			app.logger.Infof("ran macro %s\n", macro.Name)
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// macroAction does a single step of a macro.
+func (app *App) macroAction(c *client.Client, action utils.MacroAction) error {
+	switch {
+	case action.Delay != 0:
+		app.logger.Verbosef("waiting %s\n", action.Delay)
+		if !app.dryRun.dryRun {
+			time.Sleep(action.Delay)
+		}
+
+	case action.Scene != "":
+		scene, err := c.RecallScene(action.Scene, action.Group)
+		if err != nil {
+			return err
+		}
+		app.logger.Verbosef("recalled scene %s\n", scene.Name)
+
+	case action.Light != "":
+		lights, err := c.SelectLights(action.Light)
+		if err != nil {
+			return err
+		}
+
+		for _, light := range lights {
+			err = c.SetLightState(light.Index, action.State)
+			if err != nil {
+				return err
+			}
+			app.logger.Verbosef("changed light %s\n", light.Name)
+		}
+
+	case action.Group != "":
+		group, err := c.Group(action.Group)
+		if err != nil {
+			return err
+		}
+
+		err = c.SetGroupState(group.Index, action.State)
+		if err != nil {
+			return err
+		}
+		app.logger.Verbosef("changed group %s\n", group.Name)
+	}
+
+	return nil
+}
+
+// listMacros prints the macros in the configuration file.
+func (app *App) listMacros() error {
+	summaries := []MacroSummary{}
+	for _, macro := range app.bridge.macros {
+		summaries = append(summaries, MacroSummary{
+			Name:        macro.Name,
+			Description: macro.Description,
+			Params:      macro.Params,
+			Steps:       len(macro.Steps),
+		})
+	}
+
+	if app.output.format == "json" {
+		return printJSON(summaries)
+	}
+
+	for _, summary := range summaries {
+		fmt.Printf("%s (%d steps)", summary.Name, summary.Steps)
+		if summary.Description != "" {
+			fmt.Printf(": %s", summary.Description)
+		}
+		fmt.Println()
+
+		for _, param := range summary.Params {
+			usage := "required"
+			if param.Default != "" {
+				usage = "default " + param.Default
+			}
+			if param.Description != "" {
+				usage = param.Description + ", " + usage
+			}
+			fmt.Printf("\t--%s (%s)\n", param.Name, usage)
+		}
+	}
+
+	return nil
+}
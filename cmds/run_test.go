@@ -0,0 +1,112 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/huetest"
+)
+
+// writeMacros writes a configuration for the bridge with the macros.
+func writeMacros(t *testing.T, server *huetest.Server, macros string) string {
+	t.Helper()
+
+	config := filepath.Join(t.TempDir(), "hue-cli.yaml")
+	err := os.WriteFile(config, []byte("version: 1\n"+
+		"bridges:\n"+
+		"- ipaddress: "+server.Address()+"\n"+
+		"  user: "+testUser+"\n"+
+		"macros:\n"+macros), 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", config, err)
+	}
+
+	return config
+}
+
+func TestRunMacro(t *testing.T) {
+	server := startBridge(t)
+	config := writeMacros(t, server, ""+
+		"- name: dim\n"+
+		"  description: dim the office\n"+
+		"  params:\n"+
+		"  - name: level\n"+
+		"    default: \"50\"\n"+
+		"  steps:\n"+
+		"  - light: Desk Lamp\n"+
+		"    brightness: \"{{level}}\"\n"+
+		"  - delay: 10ms\n"+
+		"  - light: Hallway\n"+
+		"    on: false\n")
+
+	_, err := execute(t, "run", "--config="+config, "dim", "--level", "100")
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+
+	state := server.Bridge.State()
+	if desk := state.Lights["1"].State; !desk.On || desk.Bri != 254 {
+		t.Errorf("unexpected state of the desk lamp: %+v", desk)
+	}
+	if state.Lights["3"].State.On {
+		t.Errorf("the hallway is still on")
+	}
+
+	out, err := execute(t, "run", "--config="+config)
+	if err != nil {
+		t.Fatalf("listing the macros failed: %s", err)
+	}
+	if out != "dim (3 steps): dim the office\n\t--level (default 50)\n" {
+		t.Errorf("unexpected list of macros:\n%s", out)
+	}
+}
+
+func TestRunMacroInvalid(t *testing.T) {
+	server := startBridge(t)
+	config := writeMacros(t, server, ""+
+		"- name: dim\n"+
+		"  params:\n"+
+		"  - name: level\n"+
+		"  steps:\n"+
+		"  - light: Desk Lamp\n"+
+		"    brightness: \"{{level}}\"\n")
+
+	for _, args := range [][]string{
+		{"dim"},
+		{"dim", "--level=0"},
+		{"dim", "--color=red", "--level=10"},
+		{"dim", "focus", "--level=10"},
+	} {
+		_, err := execute(t, append([]string{"run", "--config=" + config}, args...)...)
+		if code := ExitCode(err); code != ExitInvalidInput {
+			t.Errorf("%s: expected exit code %d, got %d (%v)", strings.Join(args, " "), ExitInvalidInput, code, err)
+		}
+	}
+
+	_, err := execute(t, "run", "--config="+config, "focus")
+	if code := ExitCode(err); code != ExitNotFound {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitNotFound, code, err)
+	}
+}
@@ -0,0 +1,144 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+type SceneOptions struct {
+	scene      string
+	group      string
+	dynamic    bool
+	deactivate bool
+}
+
+func initScenes(app *App, cmd *cobra.Command) {
+	// hue-cli recall-scene
+	cmd.AddCommand(newRecallSceneCommand(app))
+
+	// hue-cli smart-scene
+	cmd.AddCommand(newSmartSceneCommand(app))
+}
+
+func newRecallSceneCommand(app *App) *cobra.Command {
+	var sceneOptions SceneOptions
+
+	cmd := &cobra.Command{
+		Use:          "recall-scene",
+		Short:        "recall a scene",
+		Long:         "put the lights in the state that is stored in a scene on the bridge",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			if sceneOptions.scene == "" {
+				return invalidInputError("--scene=<name> is required")
+			}
+
+			var scene utils.InventoryItem
+			if sceneOptions.dynamic {
+				if sceneOptions.group != "" {
+					return invalidInputError("--dynamic recalls the scene for its own room or zone, --group can not be used")
+				}
+				scene, err = c.RecallDynamicScene(sceneOptions.scene)
+			} else {
+				scene, err = c.RecallScene(sceneOptions.scene, sceneOptions.group)
+			}
+			if err != nil {
+				return err
+			}
+
+			app.logger.Infof("recalled scene %s\n", scene.Name)
+
+			return nil
+		},
+	}
+
+	// hue-cli recall-scene --scene=<name>
+	cmd.Flags().StringVar(&sceneOptions.scene, "scene", "",
+		"name of the scene to recall")
+	// hue-cli recall-scene --group=<name>
+	cmd.Flags().StringVar(&sceneOptions.group, "group", "",
+		"name of the group to recall the scene for (default all lights)")
+	// hue-cli recall-scene --dynamic
+	cmd.Flags().BoolVar(&sceneOptions.dynamic, "dynamic", false,
+		"let the lights cycle through the colors of the scene (CLIP v2 only)")
+	cmd.RegisterFlagCompletionFunc("scene", app.completeInventory(inventoryScenes, false))
+	cmd.RegisterFlagCompletionFunc("group", app.completeInventory(inventoryGroups, false))
+
+	return cmd
+}
+
+func newSmartSceneCommand(app *App) *cobra.Command {
+	var sceneOptions SceneOptions
+
+	cmd := &cobra.Command{
+		Use:          "smart-scene",
+		Short:        "activate a smart scene",
+		Long:         "activate (or deactivate) a smart scene, which recalls scenes depending on the time of the day (CLIP v2 only)",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			if sceneOptions.scene == "" {
+				return invalidInputError("--scene=<name> is required")
+			}
+
+			smartScene, err := c.SmartScene(sceneOptions.scene)
+			if err != nil {
+				return err
+			}
+
+			err = c.RecallSmartScene(smartScene, !sceneOptions.deactivate)
+			if err != nil {
+				return err
+			}
+
+			if sceneOptions.deactivate {
+				app.logger.Infof("deactivated smart scene %s\n", smartScene.Metadata.Name)
+			} else {
+				app.logger.Infof("activated smart scene %s\n", smartScene.Metadata.Name)
+			}
+
+			return nil
+		},
+	}
+
+	// hue-cli smart-scene --scene=<name>
+	cmd.Flags().StringVar(&sceneOptions.scene, "scene", "",
+		"name of the smart scene")
+	// hue-cli smart-scene --deactivate
+	cmd.Flags().BoolVar(&sceneOptions.deactivate, "deactivate", false,
+		"deactivate the smart scene")
+
+	return cmd
+}
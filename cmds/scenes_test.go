@@ -0,0 +1,97 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecallScene(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "recall-scene", "--scene=relax", "--group=office")
+	if err != nil {
+		t.Fatalf("recall-scene failed: %s", err)
+	}
+
+	if !strings.Contains(out, "recalled scene Relax") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if bri := server.Bridge.State().Lights["1"].State.Bri; bri != 144 {
+		t.Errorf("scene was not recalled, brightness is %d", bri)
+	}
+}
+
+func TestRecallSceneUnsupported(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "recall-scene", "--scene=relax", "--backend=deconz")
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Fatalf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+	if !strings.Contains(err.Error(), "not supported by the deconz backend") {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	_, err = runHueCli(t, server, "recall-scene", "--scene=relax", "--backend=unknown")
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+}
+
+func TestRecallSceneDynamic(t *testing.T) {
+	server, v2 := startV2Bridge(t)
+
+	out, err := runHueCli(t, server, "recall-scene", "--scene=relax", "--dynamic", v2)
+	if err != nil {
+		t.Fatalf("recall-scene failed: %s", err)
+	}
+	if !strings.Contains(out, "recalled scene Relax") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	// the Read scene has no palette
+	_, err = runHueCli(t, server, "recall-scene", "--scene=read", "--dynamic", v2)
+	if err == nil || !strings.Contains(err.Error(), "has no palette") {
+		t.Errorf("expected an error for a scene without palette, got %v", err)
+	}
+}
+
+func TestSmartScene(t *testing.T) {
+	server, v2 := startV2Bridge(t)
+
+	out, err := runHueCli(t, server, "smart-scene", "--scene=natural light", v2)
+	if err != nil {
+		t.Fatalf("smart-scene failed: %s", err)
+	}
+	if !strings.Contains(out, "activated smart scene Natural light") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	_, err = runHueCli(t, server, "smart-scene", "--scene=natural light", "--deactivate", v2)
+	if err != nil {
+		t.Fatalf("smart-scene failed: %s", err)
+	}
+	if state := server.Bridge.State().V2.SmartScenes["natural"].State; state != "inactive" {
+		t.Errorf("smart scene is %s", state)
+	}
+}
@@ -21,99 +21,122 @@
 package cmds
 
 import (
-	"errors"
 	"fmt"
 
 	hue "github.com/collinux/GoHue"
 	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/client"
 )
 
 type SensorOptions struct {
-	index int
-	name  string
+	index  int
+	sensor string
+	name   string
 }
 
-var (
-	sensorOptions SensorOptions
-)
-
-func initSensors(cmd *cobra.Command) {
+func initSensors(app *App, cmd *cobra.Command) {
 	// hue-cli list-sensors
-	cmd.AddCommand(cmdListSensors)
-	addBridgeOptions(cmdListSensors)
-	cmdListSensors.SilenceUsage = true
+	cmd.AddCommand(newListSensorsCommand(app))
 
 	// hue-cli sensor-set
-	cmd.AddCommand(cmdSensorSet)
-	addBridgeOptions(cmdSensorSet)
-	cmdSensorSet.Flags().IntVar(&sensorOptions.index, "index", -1,
-		"index of the sensor to modify")
-	cmdSensorSet.Flags().StringVar(&sensorOptions.name, "name", "",
-		"name to set for the sensor")
-	cmdSensorSet.SilenceUsage = true
+	cmd.AddCommand(newSensorSetCommand(app))
 }
 
-var cmdListSensors = &cobra.Command{
-	Use:   "list-sensors",
-	Short: "list all sensors",
-	Long:  "list all sensors attached to the bridge",
-
-	RunE: func(cmd *cobra.Command, args []string) error {
-		bridge, err := getBridge()
-		if err != nil {
-			return err
-		}
-
-		sensors, err := bridge.GetAllSensors()
-
-		fmt.Printf("Found %d sensors\n", len(sensors))
-		for _, sensor := range sensors {
-			fmt.Printf("%s\n", sensorToString(sensor))
-		}
-		return nil
-	},
+func newListSensorsCommand(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:          "list-sensors",
+		Short:        "list all sensors",
+		Long:         "list all sensors attached to the bridge",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			sensors, err := c.Sensors()
+			if err != nil {
+				return err
+			}
+
+			if app.output.format == "json" {
+				return printJSON(sensorSummaries(c, sensors))
+			}
+
+			fmt.Printf("Found %d sensors\n", len(sensors))
+			for _, sensor := range sensors {
+				fmt.Printf("%s\n", client.FormatSensor(sensor, c.SensorKind(sensor)))
+			}
+			return nil
+		},
+	}
 }
 
-var cmdSensorSet = &cobra.Command{
-	Use:   "sensor-set",
-	Short: "set attributes of a sensor",
-	Long:  "set attributes of a sensor",
-
-	RunE: func(cmd *cobra.Command, args []string) error {
-		bridge, err := getBridge()
-		if err != nil {
-			return err
-		}
-
-		if sensorOptions.index == -1 {
-			return errors.New("--index=... is required")
-		}
-
-		if sensorOptions.name == "" {
-			return errors.New("--name=... is required")
-		}
-
-		sensor, err := bridge.GetSensorByIndex(sensorOptions.index)
-		if err != nil {
-			return err
-		}
-
-		err = sensor.SetName(sensorOptions.name)
-		if err != nil {
-			return err
-		}
-
-		return nil
-	},
+func newSensorSetCommand(app *App) *cobra.Command {
+	var sensorOptions SensorOptions
+
+	cmd := &cobra.Command{
+		Use:          "sensor-set",
+		Short:        "set attributes of a sensor",
+		Long:         "set attributes of a sensor",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			if sensorOptions.index == -1 && sensorOptions.sensor == "" {
+				return invalidInputError("--sensor=... or --index=... is required")
+			}
+
+			if sensorOptions.name == "" {
+				return invalidInputError("--name=... is required")
+			}
+
+			var sensor hue.Sensor
+			if sensorOptions.sensor != "" {
+				sensor, err = c.Sensor(sensorOptions.sensor)
+			} else {
+				sensor, err = c.Bridge.GetSensorByIndex(sensorOptions.index)
+			}
+			if err != nil {
+				return err
+			}
+
+			err = sensor.SetName(sensorOptions.name)
+			if err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	// hue-cli sensor-set --index=<index>
+	cmd.Flags().IntVar(&sensorOptions.index, "index", -1,
+		"index of the sensor to modify")
+	// hue-cli sensor-set --sensor=<name>
+	cmd.Flags().StringVar(&sensorOptions.sensor, "sensor", "",
+		"name or index of the sensor to modify")
+	// hue-cli sensor-set --name=<name>
+	cmd.Flags().StringVar(&sensorOptions.name, "name", "",
+		"name to set for the sensor")
+	cmd.RegisterFlagCompletionFunc("sensor", app.completeInventory(inventorySensors, false))
+	cmd.RegisterFlagCompletionFunc("index", app.completeInventory(inventorySensors, true))
+
+	return cmd
 }
 
-func sensorToString(sensor hue.Sensor) string {
-	s := fmt.Sprintf("Sensor: %s\n"+
-		"\tIndex: %d\n"+
-		"\tType: %s\n"+
-		"\tProductName: %s\n"+
-		"\tUniqueID: %s",
-		sensor.Name, sensor.Index, sensor.Type, sensor.ModelID, sensor.UniqueID)
+// sensorSummaries converts the sensors for --output=json.
+func sensorSummaries(c *client.Client, sensors []hue.Sensor) []client.SensorSummary {
+	output := []client.SensorSummary{}
+	for _, sensor := range sensors {
+		output = append(output, client.NewSensorSummary(sensor, c.SensorKind(sensor)))
+	}
 
-	return s
+	return output
 }
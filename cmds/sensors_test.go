@@ -0,0 +1,78 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListSensors(t *testing.T) {
+	server := startBridge(t)
+
+	out, err := runHueCli(t, server, "list-sensors")
+	if err != nil {
+		t.Fatalf("list-sensors failed: %s", err)
+	}
+
+	if !strings.HasPrefix(out, "Found 3 sensors\n") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if !strings.Contains(out, "Sensor: Dimmer switch\n\tIndex: 2\n\tType: ZLLSwitch\n\tKind: switch") {
+		t.Errorf("sensor missing in output:\n%s", out)
+	}
+}
+
+func TestSensorSet(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "sensor-set", "--index=2", "--name=Desk switch")
+	if err != nil {
+		t.Fatalf("sensor-set failed: %s", err)
+	}
+
+	if server.Bridge.State().Sensors["2"].Name != "Desk switch" {
+		t.Error("name of the sensor was not changed")
+	}
+
+	_, err = runHueCli(t, server, "sensor-set", "--index=9", "--name=Nothing")
+	if err == nil {
+		t.Error("expected an error for an unknown sensor")
+	}
+
+	_, err = runHueCli(t, server, "sensor-set", "--name=Nothing")
+	if err == nil {
+		t.Error("expected an error without --index")
+	}
+}
+
+func TestSensorSetByName(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "sensor-set", "--sensor=dimmer switch", "--name=Bedroom switch")
+	if err != nil {
+		t.Fatalf("sensor-set failed: %s", err)
+	}
+
+	if name := server.Bridge.State().Sensors["2"].Name; name != "Bedroom switch" {
+		t.Errorf("sensor was not renamed: %s", name)
+	}
+}
@@ -0,0 +1,160 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+type SnapshotOptions struct {
+	selection  string
+	transition int
+	force      bool
+
+	verify VerifyOptions
+}
+
+func initSnapshot(app *App, cmd *cobra.Command) {
+	// hue-cli snapshot
+	cmdSnapshot := &cobra.Command{
+		Use:   "snapshot",
+		Short: "save and restore the state of lights",
+		Long:  "save the state of lights locally, and restore the lights to that state later on",
+	}
+	cmd.AddCommand(cmdSnapshot)
+
+	// hue-cli snapshot save <name>
+	cmdSnapshot.AddCommand(newSnapshotSaveCommand(app))
+
+	// hue-cli snapshot restore <name>
+	cmdSnapshot.AddCommand(newSnapshotRestoreCommand(app))
+}
+
+func newSnapshotSaveCommand(app *App) *cobra.Command {
+	var snapshotOptions SnapshotOptions
+
+	cmd := &cobra.Command{
+		Use:          "save <name>",
+		Short:        "save the state of lights",
+		Long:         "save the on/off state, brightness and color of lights under the given name",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			lights, err := c.SelectLights(snapshotOptions.selection)
+			if err != nil {
+				return err
+			}
+
+			snapshot := c.Snapshot(lights)
+			err = snapshot.Save(args[0])
+			if err != nil {
+				return errors.New(fmt.Sprintf("failed to save snapshot %s: %s", args[0], err))
+			}
+
+			app.logger.Infof("saved the state of %d lights in snapshot %s\n", len(lights), args[0])
+
+			return nil
+		},
+	}
+
+	// hue-cli snapshot save --select=1,3,desk <name>
+	cmd.Flags().StringVar(&snapshotOptions.selection, "select", "",
+		"comma separated list of light names or indexes (default all lights)")
+	cmd.RegisterFlagCompletionFunc("select", completeList(app.completeInventory(inventoryLights, false)))
+
+	return cmd
+}
+
+func newSnapshotRestoreCommand(app *App) *cobra.Command {
+	var snapshotOptions SnapshotOptions
+
+	cmd := &cobra.Command{
+		Use:          "restore <name>",
+		Short:        "restore the state of lights",
+		Long:         "restore the lights to the state that was saved under the given name",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			snapshot, err := utils.LoadSnapshot(args[0])
+			if err != nil {
+				return notFoundError("failed to load snapshot %s: %s", args[0], err)
+			}
+
+			// the lights are matched by index or unique ID on this bridge
+			if snapshotOptions.force {
+				snapshot.Bridge = ""
+				snapshot.BridgeID = ""
+			}
+
+			err = app.restoreSnapshot(c, snapshot, snapshotOptions.transition, snapshotOptions.verify)
+			var bridgeErr *client.SnapshotBridgeError
+			if errors.As(err, &bridgeErr) {
+				return invalidInputError("%s, use --force to restore it anyway", err)
+			} else if err != nil {
+				return err
+			}
+
+			app.logger.Infof("restored the state of %d lights from snapshot %s\n", len(snapshot.Lights), args[0])
+
+			return nil
+		},
+	}
+
+	// hue-cli snapshot restore --transition=10 <name>
+	cmd.Flags().IntVar(&snapshotOptions.transition, "transition", -1,
+		"transition time in multiples of 100ms (default as configured on the bridge)")
+	// hue-cli snapshot restore --force <name>
+	cmd.Flags().BoolVar(&snapshotOptions.force, "force", false,
+		"restore a snapshot that was taken of another bridge")
+	addVerifyOptions(cmd, &snapshotOptions.verify)
+	cmd.ValidArgsFunction = completeSnapshots
+
+	return cmd
+}
+
+// restoreSnapshot restores the state of the lights, and verifies it with
+// --verify.
+func (app *App) restoreSnapshot(c *client.Client, snapshot *utils.Snapshot, transition int, verify VerifyOptions) error {
+	states, err := c.RestoreSnapshot(snapshot, transition)
+	if err != nil {
+		return err
+	}
+
+	return app.verifyLights(c, verify, states, transition)
+}
@@ -0,0 +1,95 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"testing"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+func TestSnapshot(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "snapshot", "save", "--select=Desk Lamp,2", "demo")
+	if err != nil {
+		t.Fatalf("snapshot save failed: %s", err)
+	}
+
+	before := server.Bridge.State().Lights
+
+	// switch the lights to a different state
+	_, err = runHueCli(t, server, "lights", "--light=Desk Lamp", "--colorloop")
+	if err != nil {
+		t.Fatalf("lights --colorloop failed: %s", err)
+	}
+	_, err = runHueCli(t, server, "lights", "--light=Ceiling", "--toggle")
+	if err != nil {
+		t.Fatalf("lights --toggle failed: %s", err)
+	}
+
+	_, err = runHueCli(t, server, "snapshot", "restore", "--transition=0", "demo")
+	if err != nil {
+		t.Fatalf("snapshot restore failed: %s", err)
+	}
+
+	after := server.Bridge.State().Lights
+	for _, id := range []string{"1", "2"} {
+		b, a := before[id].State, after[id].State
+		if a.On != b.On || (a.On && (a.Bri != b.Bri || a.ColorMode != b.ColorMode || a.XY != b.XY || a.CT != b.CT)) {
+			t.Errorf("light %s was not restored, expected %+v, got %+v", id, b, a)
+		}
+	}
+
+	_, err = runHueCli(t, server, "snapshot", "restore", "unknown")
+	if err == nil {
+		t.Error("expected an error for an unknown snapshot")
+	}
+}
+
+func TestSnapshotOtherBridge(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "snapshot", "save", "--select=Desk Lamp", "other")
+	if err != nil {
+		t.Fatalf("snapshot save failed: %s", err)
+	}
+
+	snapshot, err := utils.LoadSnapshot("other")
+	if err != nil {
+		t.Fatalf("failed to load the snapshot: %s", err)
+	}
+	snapshot.BridgeID = "001788FFFE000000"
+	err = snapshot.Save("other")
+	if err != nil {
+		t.Fatalf("failed to save the snapshot: %s", err)
+	}
+
+	_, err = runHueCli(t, server, "snapshot", "restore", "other")
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+
+	_, err = runHueCli(t, server, "snapshot", "restore", "--force", "other")
+	if err != nil {
+		t.Errorf("snapshot restore --force failed: %s", err)
+	}
+}
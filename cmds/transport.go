@@ -0,0 +1,125 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+var (
+	// GoHue uses the default transport for all requests, the original is
+	// kept so that the transport can be set up more than once
+	defaultTransport = originalTransport()
+)
+
+// originalTransport returns the default transport of net/http, or a new one
+// when it was replaced by something else before hue-cli started.
+func originalTransport() *http.Transport {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		transport = &http.Transport{Proxy: http.ProxyFromEnvironment}
+	}
+
+	return transport
+}
+
+// setupTransport installs the chain of transports that all requests to the
+// bridge pass through, depending on the options. The chain replaces the
+// global http.DefaultTransport, as GoHue does not accept a transport.
+func (app *App) setupTransport() error {
+	app.checkRetry()
+
+	// the errors of the bridge are kept for the current command only
+	app.bridgeErrorsLock.Lock()
+	app.bridgeErrors = nil
+	app.bridgeErrorsLock.Unlock()
+
+	shared := app.sharedTransport()
+	app.bridgeTransport = shared.bridge
+	app.scheduler = shared.scheduler
+	app.schedulerStats = app.scheduler.Stats()
+
+	transport, err := app.setupRecording(app.scheduler)
+	if err != nil {
+		return err
+	}
+
+	transport = &inventoryTransport{transport: transport, app: app}
+
+	if app.dryRun.dryRun {
+		transport = &dryRunTransport{transport: transport, format: app.output.format}
+	}
+
+	transport = &errorTransport{transport: transport, app: app}
+
+	if app.logger.Level >= utils.LevelDebug {
+		transport = &tracingTransport{transport: transport, logger: app.logger}
+	}
+
+	http.DefaultTransport = transport
+
+	return nil
+}
+
+// A sharedTransport is the part of the chain of transports that keeps state
+// between requests, the commands of a batch with the same options share it.
+type sharedTransport struct {
+	bridge    *client.BridgeTransport
+	scheduler *rateLimiter
+}
+
+// sharedTransport returns the rate limiter, with the transports below it,
+// for the options of the App.
+func (app *App) sharedTransport() *sharedTransport {
+	key := fmt.Sprintf("%s|%s|%t|%s|%s|%s|%+v|%+v|%d", app.bridge.ipaddress, app.bridge.v2Address,
+		app.bridge.https, app.bridge.bridgeID, app.bridge.fingerprint, app.bridge.config,
+		app.retry, app.rateLimit, app.logger.Level)
+	if shared, ok := app.transports[key]; ok {
+		return shared
+	}
+
+	bridge := &client.BridgeTransport{
+		Transport:    defaultTransport,
+		Address:      app.bridge.ipaddress,
+		HTTPSAddress: app.bridge.v2Address,
+		Upgrade:      app.bridge.https,
+		Pin:          client.Pin{BridgeID: app.bridge.bridgeID, Fingerprint: app.bridge.fingerprint},
+		OnPin:        app.savePin,
+	}
+
+	retry := &retryTransport{
+		transport: bridge,
+		options:   app.retry,
+		logger:    app.logger,
+	}
+
+	shared := &sharedTransport{
+		bridge:    bridge,
+		scheduler: newRateLimiter(retry, app.rateLimit.lightRate, app.rateLimit.groupRate, app.logger),
+	}
+	app.transports[key] = shared
+
+	return shared
+}
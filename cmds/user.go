@@ -27,64 +27,85 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/nixpanic/hue-cli/client"
 	"github.com/nixpanic/hue-cli/utils"
 )
 
 type UserOptions struct {
 	deviceName string
+	secret     string
 }
 
-var (
-	userOptions UserOptions
-)
-
-func initUser(cmd *cobra.Command) {
+func initUser(app *App, cmd *cobra.Command) {
 	// hue-cli create-user
-	cmd.AddCommand(cmdCreateUser)
-	addBridgeOptions(cmdCreateUser)
+	cmd.AddCommand(newCreateUserCommand(app))
+}
+
+func newCreateUserCommand(app *App) *cobra.Command {
+	var userOptions UserOptions
+
+	cmd := &cobra.Command{
+		Use:          "create-user",
+		Short:        "create a new user on the bridge",
+		Long:         "create a new user on the bridge, should have pressed the 'link button' in advance",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			// we got a bridge, create a new user
+			user, err := c.CreateUser("hue-cli#" + userOptions.deviceName)
+			if err != nil {
+				return fmt.Errorf("failed to create user: %w", err)
+			}
+
+			// generate a new config file
+			config := &utils.ConfigFile{
+				Bridges: []utils.BridgeConfig{{
+					IPAddress: c.Bridge.IPAddress,
+					User:      user,
+				}},
+			}
+			if userOptions.secret != "" {
+				// with --dry-run the user is not created, do not store it
+				if !app.dryRun.dryRun {
+					err = utils.StoreSecret(userOptions.secret, user)
+					if err != nil {
+						return err
+					}
+				}
+
+				config.Bridges[0].User = ""
+				config.Bridges[0].UserSecret = userOptions.secret
+			}
+			if c.Backend.Name() != client.DefaultBackend {
+				config.Bridges[0].Backend = c.Backend.Name()
+			}
+
+			configOut, err := config.String()
+			if err != nil {
+				return errors.New(fmt.Sprintf("failed to conver config to string (%s)", err))
+			}
+
+			fmt.Printf("new configuration: %s\n", configOut)
+
+			return nil
+		},
+	}
 
 	// hue-cli create-user --device=<name>
 	hostname, err := os.Hostname()
 	if err != nil {
 		hostname = "unknown"
 	}
-	cmdCreateUser.Flags().StringVar(&userOptions.deviceName, "device", hostname,
+	cmd.Flags().StringVar(&userOptions.deviceName, "device", hostname,
 		"name of the device hue-cli is running on (optional)")
-	cmdCreateUser.SilenceUsage = true
-}
+	// hue-cli create-user --secret=<reference>
+	cmd.Flags().StringVar(&userOptions.secret, "secret", "",
+		"store the user in the keyring (keyring:<key>) or an encrypted file (file:<path>#<key>) instead of printing it")
 
-var cmdCreateUser = &cobra.Command{
-	Use:   "create-user",
-	Short: "create a new user on the bridge",
-	Long:  "create a new user on the bridge, should have pressed the 'link button' in advance",
-
-	RunE: func(cmd *cobra.Command, args []string) error {
-		bridge, err := getBridge()
-		if err != nil {
-			return err
-		}
-
-		// we got a bridge, create a new user
-		user, err := bridge.CreateUser("hue-cli#"+userOptions.deviceName)
-		if err != nil {
-			return err
-		}
-
-		// generate a new config file
-		config := &utils.ConfigFile{
-			Bridges: []utils.BridgeConfig{{
-				IPAddress: bridge.IPAddress,
-				User: user,
-			}},
-		}
-
-		configOut, err := config.String()
-		if err != nil {
-			return errors.New(fmt.Sprintf("failed to conver config to string (%s)", err))
-		}
-
-		fmt.Printf("new configuration: %s\n", configOut)
-
-		return nil
-	},
+	return cmd
 }
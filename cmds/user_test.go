@@ -0,0 +1,102 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nixpanic/hue-cli/utils"
+)
+
+func TestCreateUser(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "create-user", "--device=test")
+	if err == nil || !strings.Contains(err.Error(), "link button not pressed") {
+		t.Fatalf("expected link button error, got: %v", err)
+	}
+
+	server.Bridge.PressLinkButton()
+
+	out, err := runHueCli(t, server, "create-user", "--device=test")
+	if err != nil {
+		t.Fatalf("create-user failed: %s", err)
+	}
+
+	if !strings.Contains(out, "ipaddress: "+server.Address()) {
+		t.Errorf("bridge missing in new configuration:\n%s", out)
+	}
+
+	whitelist := server.Bridge.State().Config.Whitelist
+	if len(whitelist) != 2 {
+		t.Fatalf("expected 2 users, got %d", len(whitelist))
+	}
+	for user, entry := range whitelist {
+		if user != testUser && entry.Name != "hue-cli#test" {
+			t.Errorf("unexpected device name %s", entry.Name)
+		}
+	}
+}
+
+func TestCreateUserBackend(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "create-user", "--backend=deconz")
+	if err == nil || !strings.Contains(err.Error(), "Phoscon app") {
+		t.Fatalf("expected a hint to unlock the gateway, got: %v", err)
+	}
+
+	server.Bridge.PressLinkButton()
+
+	out, err := runHueCli(t, server, "create-user", "--backend=deconz")
+	if err != nil {
+		t.Fatalf("create-user failed: %s", err)
+	}
+
+	if !strings.Contains(out, "backend: deconz") {
+		t.Errorf("backend missing in new configuration:\n%s", out)
+	}
+}
+
+func TestCreateUserDryRun(t *testing.T) {
+	server := startBridge(t)
+	server.Bridge.PressLinkButton()
+	t.Setenv(utils.PassphraseEnv, "correct horse")
+
+	secrets := filepath.Join(t.TempDir(), "secrets.json")
+	out, err := runHueCli(t, server, "create-user", "--device=test", "--dry-run", "--secret=file:"+secrets+"#test")
+	if err != nil {
+		t.Fatalf("create-user --dry-run failed: %s", err)
+	}
+
+	if !strings.Contains(out, "usersecret: file:"+secrets+"#test") {
+		t.Errorf("secret missing in new configuration:\n%s", out)
+	}
+	if _, err := os.Stat(secrets); !os.IsNotExist(err) {
+		t.Errorf("the user was stored in %s with --dry-run", secrets)
+	}
+	if whitelist := server.Bridge.State().Config.Whitelist; len(whitelist) != 1 {
+		t.Errorf("expected 1 user, got %d", len(whitelist))
+	}
+}
@@ -0,0 +1,232 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	hue "github.com/collinux/GoHue"
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/client"
+)
+
+type VerifyOptions struct {
+	verify  bool
+	retries int
+}
+
+var (
+	// defaultTransition is the transition time that the bridge uses when
+	// none is given
+	defaultTransition = 400 * time.Millisecond
+)
+
+func addVerifyOptions(cmd *cobra.Command, options *VerifyOptions) {
+	// hue-cli --verify
+	cmd.Flags().BoolVar(&options.verify, "verify", false,
+		"read the state of the lights back, and check that the change was applied")
+	// hue-cli --verify-retries=<count>
+	cmd.Flags().IntVar(&options.retries, "verify-retries", 1,
+		"number of times the change is sent again when --verify finds a difference")
+}
+
+// The tolerances for comparing the state of a light, the bridge rounds the
+// values to what the light supports.
+var tolerances = map[string]float64{
+	"bri": 2,
+	"ct":  2,
+	"hue": 200,
+	"sat": 2,
+	"xy":  0.01,
+}
+
+// verifyLights checks that the lights (by index) have the state that was
+// requested, after the transition time (in multiples of 100ms, -1 for the
+// default) has passed. Lights with a different state get the state sent
+// again, up to --verify-retries times. Nothing is done without --verify.
+func (app *App) verifyLights(c *client.Client, verifyOptions VerifyOptions, expected map[int]map[string]interface{}, transition int) error {
+	// with --dry-run nothing was changed
+	if !verifyOptions.verify || app.dryRun.dryRun || len(expected) == 0 {
+		return nil
+	}
+
+	wait := defaultTransition
+	if transition >= 0 {
+		wait = time.Duration(transition) * 100 * time.Millisecond
+	}
+
+	for attempt := 0; ; attempt++ {
+		time.Sleep(wait)
+
+		failed := map[int]string{}
+		unreachable := 0
+		for index, state := range expected {
+			light, err := c.Bridge.GetLightByIndex(index)
+			if err != nil {
+				return err
+			}
+
+			if !light.State.Reachable {
+				failed[index] = fmt.Sprintf("light %s is not reachable", light.Name)
+				unreachable++
+			} else if diffs := compareLightState(light, state); len(diffs) != 0 {
+				failed[index] = fmt.Sprintf("state of light %s differs: %s", light.Name, strings.Join(diffs, ", "))
+			}
+		}
+
+		if len(failed) == 0 {
+			app.logger.Verbosef("verified the state of %d lights\n", len(expected))
+			return nil
+		}
+
+		if attempt >= verifyOptions.retries {
+			return verifyError(failed, unreachable)
+		}
+
+		for index, reason := range failed {
+			app.logger.Verbosef("%s, sending the change again\n", reason)
+
+			err := c.SetLightState(index, expected[index])
+			if err != nil {
+				return err
+			}
+		}
+
+		// only check the lights that failed again
+		retry := map[int]map[string]interface{}{}
+		for index := range failed {
+			retry[index] = expected[index]
+		}
+		expected = retry
+	}
+}
+
+func verifyError(failed map[int]string, unreachable int) error {
+	reasons := []string{}
+	for _, reason := range failed {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	code := ExitFailure
+	if unreachable == len(failed) {
+		code = ExitUnreachable
+	}
+
+	return &Error{Code: code, Err: errors.New(strings.Join(reasons, "; "))}
+}
+
+// compareLightState returns the differences between the state of the light
+// and the requested state.
+func compareLightState(light hue.Light, expected map[string]interface{}) []string {
+	actual := map[string]interface{}{
+		"on":     light.State.On,
+		"bri":    float64(light.State.Bri),
+		"hue":    float64(light.State.Hue),
+		"sat":    float64(light.State.Saturation),
+		"ct":     float64(light.State.CT),
+		"xy":     []float64{float64(light.State.XY[0]), float64(light.State.XY[1])},
+		"effect": light.State.Effect,
+	}
+
+	diffs := []string{}
+	for _, key := range sortedParams(expected) {
+		value, ok := actual[key]
+		if !ok {
+			// transitiontime and others are not part of the state
+			continue
+		}
+
+		if !equalValue(value, expected[key], tolerances[key]) {
+			diffs = append(diffs, fmt.Sprintf("%s is %v instead of %v", key, value, expected[key]))
+		}
+	}
+
+	return diffs
+}
+
+func equalValue(actual, expected interface{}, tolerance float64) bool {
+	switch a := actual.(type) {
+	case float64:
+		e, ok := toFloat(expected)
+		return ok && math.Abs(a-e) <= tolerance
+	case []float64:
+		e := toFloats(expected)
+		if len(e) != len(a) {
+			return false
+		}
+		for i := range a {
+			if math.Abs(a[i]-e[i]) > tolerance {
+				return false
+			}
+		}
+		return true
+	}
+
+	return actual == expected
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	}
+
+	return 0, false
+}
+
+func toFloats(value interface{}) []float64 {
+	switch v := value.(type) {
+	case []float64:
+		return v
+	case [2]float32:
+		return []float64{float64(v[0]), float64(v[1])}
+	case []interface{}:
+		floats := []float64{}
+		for _, item := range v {
+			f, _ := toFloat(item)
+			floats = append(floats, f)
+		}
+		return floats
+	}
+
+	return nil
+}
+
+func sortedParams(params map[string]interface{}) []string {
+	keys := []string{}
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
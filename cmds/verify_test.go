@@ -0,0 +1,108 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	hue "github.com/collinux/GoHue"
+)
+
+// fastTransition makes --verify not wait for the default transition time.
+func fastTransition(t *testing.T) {
+	defaultTransition = time.Millisecond
+	t.Cleanup(func() {
+		defaultTransition = 400 * time.Millisecond
+	})
+}
+
+func TestVerifyToggle(t *testing.T) {
+	fastTransition(t)
+	server := startBridge(t)
+	before := server.Bridge.State().Lights["2"].State.On
+
+	_, err := runHueCli(t, server, "lights", "--light=Ceiling", "--toggle", "--verify")
+	if err != nil {
+		t.Fatalf("lights --toggle --verify failed: %s", err)
+	}
+
+	after := server.Bridge.State().Lights["2"].State.On
+	if after == before {
+		t.Errorf("Ceiling was not toggled, it is still on=%t", after)
+	}
+}
+
+func TestVerifyUnreachable(t *testing.T) {
+	fastTransition(t)
+	server := startBridge(t)
+	server.Bridge.SetLightReachable("2", false)
+
+	// without --verify the change seems to succeed
+	_, err := runHueCli(t, server, "lights", "--light=Ceiling", "--toggle")
+	if err != nil {
+		t.Fatalf("lights --toggle failed: %s", err)
+	}
+
+	_, err = runHueCli(t, server, "lights", "--light=Ceiling", "--toggle", "--verify")
+	if code := ExitCode(err); code != ExitUnreachable {
+		t.Fatalf("expected exit code %d, got %d (%v)", ExitUnreachable, code, err)
+	}
+	if !strings.Contains(err.Error(), "Ceiling is not reachable") {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestVerifyGroup(t *testing.T) {
+	fastTransition(t)
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "toggle-group", "--name=Office", "--verify")
+	if err != nil {
+		t.Fatalf("toggle-group --verify failed: %s", err)
+	}
+}
+
+func TestCompareLightState(t *testing.T) {
+	light := hue.Light{}
+	light.State.On = true
+	light.State.Bri = 199
+	light.State.XY = [2]float32{0.4573, 0.41}
+
+	diffs := compareLightState(light, map[string]interface{}{
+		"on":             true,
+		"bri":            200,
+		"xy":             []float64{0.457, 0.4101},
+		"transitiontime": 4,
+	})
+	if len(diffs) != 0 {
+		t.Errorf("unexpected differences: %v", diffs)
+	}
+
+	diffs = compareLightState(light, map[string]interface{}{
+		"on":  false,
+		"bri": 100,
+	})
+	if len(diffs) != 2 {
+		t.Errorf("expected 2 differences, got %v", diffs)
+	}
+}
@@ -0,0 +1,150 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nixpanic/hue-cli/client"
+)
+
+type WatchOptions struct {
+	types     []string
+	selection string
+	interval  time.Duration
+	count     int
+}
+
+// errWatchDone stops watching after the requested number of events.
+var errWatchDone = errors.New("received the requested number of events")
+
+func initWatch(app *App, cmd *cobra.Command) {
+	// hue-cli watch
+	cmd.AddCommand(newWatchCommand(app))
+}
+
+func newWatchCommand(app *App) *cobra.Command {
+	var watchOptions WatchOptions
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "print changes of lights, groups and sensors",
+		Long: "print the changes of the state of lights, groups and sensors as they happen, " +
+			"one per line (or a JSON object per line with --output=json). The changes are " +
+			"received from the event stream of the CLIP v2 API, other bridges are polled.",
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, rtype := range watchOptions.types {
+				if !isWatchType(rtype) {
+					return invalidInputError("unknown --type=%s, use light, group or sensor", rtype)
+				}
+			}
+
+			c, err := app.getClient()
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			options := client.WatchOptions{
+				Types:     watchOptions.types,
+				Selection: watchOptions.selection,
+				Interval:  watchOptions.interval,
+			}
+
+			events := 0
+			err = c.Watch(ctx, options, func(event client.Event) error {
+				err := printEvent(app, event)
+				if err != nil {
+					return err
+				}
+
+				events++
+				if watchOptions.count != 0 && events >= watchOptions.count {
+					return errWatchDone
+				}
+				return nil
+			})
+			if errors.Is(err, errWatchDone) {
+				return nil
+			}
+
+			return err
+		},
+	}
+
+	// hue-cli watch --type=light,group,sensor
+	cmd.Flags().StringSliceVar(&watchOptions.types, "type", nil,
+		"types of the resources to watch: light, group and/or sensor (default all)")
+	// hue-cli watch --select=<names>
+	cmd.Flags().StringVar(&watchOptions.selection, "select", "",
+		"comma separated list of names and/or indexes to watch (default all)")
+	// hue-cli watch --interval=<duration>
+	cmd.Flags().DurationVar(&watchOptions.interval, "interval", client.DefaultWatchInterval,
+		"how often bridges without the event stream are polled")
+	// hue-cli watch --count=<number>
+	cmd.Flags().IntVar(&watchOptions.count, "count", 0,
+		"stop after this number of changes (default until interrupted)")
+	cmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return client.WatchTypes, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return cmd
+}
+
+func isWatchType(rtype string) bool {
+	for _, t := range client.WatchTypes {
+		if t == rtype {
+			return true
+		}
+	}
+
+	return false
+}
+
+// printEvent prints the event on a single line, for --output=json as a JSON
+// object (NDJSON).
+func printEvent(app *App, event client.Event) error {
+	if app.output.format == "json" {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s\n", data)
+		return nil
+	}
+
+	fmt.Println(client.FormatEvent(event))
+
+	return nil
+}
@@ -0,0 +1,114 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package cmds
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nixpanic/hue-cli/client"
+	"github.com/nixpanic/hue-cli/huetest"
+)
+
+// watch runs hue-cli watch with the arguments, and calls change repeatedly
+// until the command returns.
+func watch(t *testing.T, server *huetest.Server, change func(i int), args ...string) string {
+	t.Helper()
+
+	type result struct {
+		out string
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		out, err := runHueCli(t, server, append([]string{"watch", "--count=1"}, args...)...)
+		done <- result{out, err}
+	}()
+
+	timeout := time.After(5 * time.Second)
+	for i := 0; ; i++ {
+		change(i)
+
+		select {
+		case r := <-done:
+			if r.err != nil {
+				t.Fatalf("watch failed: %s", r.err)
+			}
+			return r.out
+		case <-time.After(50 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("watch did not report a change")
+		}
+	}
+}
+
+func TestWatchEventStream(t *testing.T) {
+	server, v2 := startV2Bridge(t)
+
+	// the App replaces http.DefaultTransport
+	httpClient := &http.Client{Transport: defaultTransport}
+
+	out := watch(t, server, func(i int) {
+		body := fmt.Sprintf(`{"on":%t}`, i%2 == 0)
+		for _, light := range []string{"3", "1"} {
+			req, _ := http.NewRequest(http.MethodPut, server.URL+"/api/"+testUser+"/lights/"+light+"/state", strings.NewReader(body))
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				t.Fatalf("failed to change light %s: %s", light, err)
+			}
+			resp.Body.Close()
+		}
+	}, "--type=light", "--select=desk lamp", "--output=json", v2)
+
+	var event client.Event
+	err := json.Unmarshal([]byte(out), &event)
+	if err != nil {
+		t.Fatalf("failed to parse output: %s\n%s", err, out)
+	}
+	if event.Type != "light" || event.ID != "1" || event.Name != "Desk Lamp" || event.Changes["on"] == nil {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+func TestWatchPolling(t *testing.T) {
+	server := startBridge(t)
+
+	out := watch(t, server, func(i int) {
+		server.Bridge.SetSensorState("3", map[string]interface{}{"presence": i%2 == 0})
+	}, "--type=sensor", "--interval=10ms", "--v2-address="+server.TLSAddress())
+
+	if !strings.Contains(out, " sensor 3 (Hue motion sensor 1): presence=") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestWatchInvalidType(t *testing.T) {
+	server := startBridge(t)
+
+	_, err := runHueCli(t, server, "watch", "--type=scene")
+	if code := ExitCode(err); code != ExitInvalidInput {
+		t.Errorf("expected exit code %d, got %d (%v)", ExitInvalidInput, code, err)
+	}
+}
@@ -27,8 +27,5 @@ import (
 )
 
 func main() {
-	err := huecli.HueCli.Execute()
-	if err != nil {
-		os.Exit(1)
-	}
+	os.Exit(huecli.Execute())
 }
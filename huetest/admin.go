@@ -0,0 +1,127 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package huetest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SetSensorState changes the state of a sensor, like a real sensor does when
+// a button is pressed or motion is detected. The lastupdated attribute of the
+// sensor is set to the current time.
+func (bridge *Bridge) SetSensorState(id string, state map[string]interface{}) error {
+	bridge.mu.Lock()
+	defer bridge.mu.Unlock()
+
+	sensor, ok := bridge.state.Sensors[id]
+	if !ok {
+		return errors.New(fmt.Sprintf("sensor %s does not exist", id))
+	}
+
+	for key, value := range state {
+		sensor.State[key] = value
+	}
+	sensor.State["lastupdated"] = time.Now().UTC().Format(timeLayout)
+
+	bridge.changed()
+
+	return nil
+}
+
+// SetLightReachable makes a light (un)reachable, like a light that is
+// switched off at the wall. An unreachable light ignores changes of its
+// state.
+func (bridge *Bridge) SetLightReachable(id string, reachable bool) error {
+	bridge.mu.Lock()
+	defer bridge.mu.Unlock()
+
+	light, ok := bridge.state.Lights[id]
+	if !ok {
+		return errors.New(fmt.Sprintf("light %s does not exist", id))
+	}
+
+	light.State.Reachable = reachable
+
+	bridge.changed()
+
+	return nil
+}
+
+// AdminHandler returns a handler for manipulating the bridge in ways that
+// are not possible through the Hue API. It handles the following requests:
+//
+//	POST /admin/linkbutton      press the link button
+//	PUT  /admin/sensors/<id>    set attributes in the state of a sensor
+//	PUT  /admin/lights/<id>     set the reachable attribute of a light
+//	GET  /admin/state           return the complete state of the bridge
+func (bridge *Bridge) AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin"), "/")
+		parts := strings.Split(path, "/")
+
+		switch {
+		case path == "linkbutton" && r.Method == "POST":
+			bridge.PressLinkButton()
+			writeJSON(w, map[string]bool{"linkbutton": true})
+		case len(parts) == 2 && parts[0] == "sensors" && r.Method == "PUT":
+			state := map[string]interface{}{}
+			err := json.NewDecoder(r.Body).Decode(&state)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			err = bridge.SetSensorState(parts[1], state)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			writeJSON(w, bridge.State().Sensors[parts[1]])
+		case len(parts) == 2 && parts[0] == "lights" && r.Method == "PUT":
+			var state struct {
+				Reachable bool `json:"reachable"`
+			}
+			err := json.NewDecoder(r.Body).Decode(&state)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			err = bridge.SetLightReachable(parts[1], state.Reachable)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			writeJSON(w, bridge.State().Lights[parts[1]])
+		case path == "state" && r.Method == "GET":
+			writeJSON(w, bridge.State())
+		default:
+			http.Error(w, "404 page not found", http.StatusNotFound)
+		}
+	})
+}
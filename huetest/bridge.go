@@ -0,0 +1,455 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+// Package huetest provides a fake Hue bridge that implements the parts of the
+// Hue REST API that hue-cli uses. It can be used for testing and developing
+// without a real bridge.
+package huetest
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LinkButtonTimeout is the time that the link button stays pressed.
+const LinkButtonTimeout = 30 * time.Second
+
+// Bridge is an http.Handler that behaves like a Hue bridge.
+type Bridge struct {
+	mu    sync.Mutex
+	state *State
+
+	linkButtonPressed time.Time
+	onChange          func(state *State)
+	lastTick          time.Time
+
+	// subscribers of the event stream, and the state they last got the
+	// changes of
+	subscribers map[chan []byte]bool
+	published   *State
+}
+
+// NewBridge returns a fake bridge that starts with a copy of the given state.
+func NewBridge(state *State) *Bridge {
+	bridge := &Bridge{
+		state:       state.copy(),
+		subscribers: map[chan []byte]bool{},
+	}
+
+	if bridge.state.Config.LinkButton {
+		bridge.linkButtonPressed = time.Now()
+	}
+
+	bridge.initSchedules(time.Now())
+
+	return bridge
+}
+
+// A Server is a fake bridge listening on a random local port. Like a real
+// bridge, it serves the API over HTTPS as well, on another port.
+type Server struct {
+	*httptest.Server
+	TLS    *httptest.Server
+	Bridge *Bridge
+}
+
+// NewServer starts a fake bridge with the given state. The server should be
+// stopped with Close when it is not needed anymore. The HTTPS server has a
+// new certificate for the bridge ID every time.
+func NewServer(state *State) *Server {
+	bridge := NewBridge(state)
+
+	tlsServer := httptest.NewUnstartedServer(bridge)
+	cert, err := bridgeCertificate(state.Config.BridgeID)
+	if err == nil {
+		tlsServer.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+	tlsServer.StartTLS()
+
+	return &Server{
+		Server: httptest.NewServer(bridge),
+		TLS:    tlsServer,
+		Bridge: bridge,
+	}
+}
+
+// Close stops the HTTP and HTTPS servers.
+func (server *Server) Close() {
+	server.Bridge.closeEventStreams()
+	server.Server.Close()
+	server.TLS.Close()
+}
+
+// Address returns the <ip-address>:<port> of the server, this can be passed
+// as the address of the bridge to hue-cli.
+func (server *Server) Address() string {
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+// TLSAddress returns the <ip-address>:<port> of the HTTPS server, which
+// serves the CLIP v2 API.
+func (server *Server) TLSAddress() string {
+	return strings.TrimPrefix(server.TLS.URL, "https://")
+}
+
+// PressLinkButton simulates pressing the link button on the bridge, new
+// users can be created during LinkButtonTimeout.
+func (bridge *Bridge) PressLinkButton() {
+	bridge.mu.Lock()
+	defer bridge.mu.Unlock()
+
+	bridge.linkButtonPressed = time.Now()
+}
+
+// State returns a copy of the current state of the bridge.
+func (bridge *Bridge) State() *State {
+	bridge.mu.Lock()
+	defer bridge.mu.Unlock()
+
+	return bridge.state.copy()
+}
+
+func (bridge *Bridge) linkButton() bool {
+	return time.Since(bridge.linkButtonPressed) < LinkButtonTimeout
+}
+
+// apiError is the description of an error that the bridge returns. The order
+// of the attributes matters, GoHue parses the JSON as a string.
+type apiError struct {
+	Type        int    `json:"type"`
+	Address     string `json:"address"`
+	Description string `json:"description"`
+}
+
+type response map[string]interface{}
+
+func errorResponse(errType int, address, description string) response {
+	return response{
+		"error": apiError{
+			Type:        errType,
+			Address:     address,
+			Description: description,
+		},
+	}
+}
+
+func successResponse(key string, value interface{}) response {
+	return response{
+		"success": map[string]interface{}{key: value},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, errType int, address, description string) {
+	writeJSON(w, []response{errorResponse(errType, address, description)})
+}
+
+func (bridge *Bridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/eventstream/clip/v2" {
+		bridge.serveEventStream(w, r)
+		return
+	}
+
+	bridge.mu.Lock()
+	defer bridge.mu.Unlock()
+
+	if r.URL.Path == "/description.xml" {
+		bridge.description(w, r)
+		return
+	}
+
+	if strings.HasPrefix(r.URL.Path, "/clip/") {
+		bridge.serveV2(w, r)
+		return
+	}
+
+	var params map[string]interface{}
+	if r.Method == "PUT" || r.Method == "POST" {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// searching for lights/sensors is done with an empty body
+		if len(body) != 0 && string(body) != "null" {
+			err = json.Unmarshal(body, &params)
+			if err != nil {
+				writeError(w, 2, "/"+strings.TrimPrefix(strings.Trim(r.URL.Path, "/"), "api/"), "body contains invalid JSON")
+				return
+			}
+		}
+	}
+
+	bridge.route(w, r.Method, r.URL.Path, params)
+
+	if r.Method != "GET" {
+		bridge.changed()
+	}
+}
+
+// route handles a request for the REST API under /api.
+func (bridge *Bridge) route(w http.ResponseWriter, method, path string, params map[string]interface{}) {
+	path = strings.Trim(path, "/")
+	if path != "api" && !strings.HasPrefix(path, "api/") {
+		http.Error(w, "404 page not found", http.StatusNotFound)
+		return
+	}
+
+	parts := strings.Split(path, "/")[1:]
+	switch {
+	case len(parts) == 0:
+		if method != "POST" {
+			writeError(w, 4, "/", fmt.Sprintf("method, %s, not available for resource, /", method))
+			return
+		}
+		bridge.createUser(w, params)
+	case len(parts) == 1 && parts[0] == "config" && method == "GET":
+		writeJSON(w, bridge.publicConfig())
+	default:
+		user := parts[0]
+		resource := parts[1:]
+		if _, ok := bridge.state.Config.Whitelist[user]; !ok {
+			writeError(w, 1, "/"+strings.Join(resource, "/"), "unauthorized user")
+			return
+		}
+		bridge.handleResource(w, method, resource, params)
+	}
+}
+
+// OnChange registers a function that gets called with a copy of the state
+// after it was (possibly) modified.
+func (bridge *Bridge) OnChange(fn func(state *State)) {
+	bridge.mu.Lock()
+	defer bridge.mu.Unlock()
+
+	bridge.onChange = fn
+}
+
+func (bridge *Bridge) changed() {
+	bridge.publishEvents()
+
+	if bridge.onChange != nil {
+		bridge.onChange(bridge.state.copy())
+	}
+}
+
+func (bridge *Bridge) description(w http.ResponseWriter, r *http.Request) {
+	config := bridge.state.Config
+
+	w.Header().Set("Content-Type", "text/xml")
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8" ?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+<specVersion>
+<major>1</major>
+<minor>0</minor>
+</specVersion>
+<URLBase>http://%s/</URLBase>
+<device>
+<deviceType>urn:schemas-upnp-org:device:Basic:1</deviceType>
+<friendlyName>%s (%s)</friendlyName>
+<manufacturer>Royal Philips Electronics</manufacturer>
+<manufacturerURL>http://www.philips.com</manufacturerURL>
+<modelDescription>Philips hue Personal Wireless Lighting</modelDescription>
+<modelName>Philips hue bridge 2015</modelName>
+<modelNumber>%s</modelNumber>
+<modelURL>http://www.meethue.com</modelURL>
+<serialNumber>%s</serialNumber>
+<UDN>uuid:2f402f80-da50-11e1-9b23-%s</UDN>
+<presentationURL>index.html</presentationURL>
+</device>
+</root>
+`, r.Host, config.Name, r.Host, config.ModelID,
+		strings.ToLower(strings.Replace(config.MAC, ":", "", -1)),
+		strings.ToLower(strings.Replace(config.MAC, ":", "", -1)))
+}
+
+// publicConfig is returned for /api/config, without authentication.
+func (bridge *Bridge) publicConfig() map[string]interface{} {
+	config := bridge.state.Config
+
+	return map[string]interface{}{
+		"name":             config.Name,
+		"datastoreversion": "70",
+		"swversion":        config.SWVersion,
+		"apiversion":       config.APIVersion,
+		"mac":              config.MAC,
+		"bridgeid":         config.BridgeID,
+		"factorynew":       false,
+		"replacesbridgeid": nil,
+		"modelid":          config.ModelID,
+	}
+}
+
+func (bridge *Bridge) fullConfig() map[string]interface{} {
+	config := bridge.publicConfig()
+	config["ipaddress"] = bridge.state.Config.IPAddress
+	config["linkbutton"] = bridge.linkButton()
+	config["whitelist"] = bridge.state.Config.Whitelist
+
+	return config
+}
+
+func (bridge *Bridge) createUser(w http.ResponseWriter, params map[string]interface{}) {
+	deviceType, ok := params["devicetype"].(string)
+	if !ok || deviceType == "" {
+		writeError(w, 5, "/", "invalid/missing parameters in body")
+		return
+	}
+
+	if !bridge.linkButton() {
+		writeError(w, 101, "", "link button not pressed")
+		return
+	}
+
+	key := make([]byte, 20)
+	_, err := rand.Read(key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	username := hex.EncodeToString(key)
+
+	now := time.Now().UTC().Format("2006-01-02T15:04:05")
+	bridge.state.Config.Whitelist[username] = WhitelistEntry{
+		Name:       deviceType,
+		CreateDate: now,
+		LastUse:    now,
+	}
+
+	writeJSON(w, []response{successResponse("username", username)})
+}
+
+func (bridge *Bridge) handleResource(w http.ResponseWriter, method string, resource []string, params map[string]interface{}) {
+	if len(resource) == 0 {
+		if method != "GET" {
+			writeError(w, 4, "/", fmt.Sprintf("method, %s, not available for resource, /", method))
+			return
+		}
+
+		bridge.updateGroupStates()
+		writeJSON(w, map[string]interface{}{
+			"config":        bridge.fullConfig(),
+			"lights":        bridge.state.Lights,
+			"groups":        bridge.state.Groups,
+			"sensors":       bridge.state.Sensors,
+			"scenes":        bridge.state.Scenes,
+			"schedules":     bridge.state.Schedules,
+			"rules":         map[string]interface{}{},
+			"resourcelinks": map[string]interface{}{},
+		})
+		return
+	}
+
+	address := "/" + strings.Join(resource, "/")
+
+	switch resource[0] {
+	case "config":
+		bridge.handleConfig(w, method, address, resource[1:], params)
+	case "lights":
+		bridge.handleLights(w, method, address, resource[1:], params)
+	case "groups":
+		bridge.handleGroups(w, method, address, resource[1:], params)
+	case "sensors":
+		bridge.handleSensors(w, method, address, resource[1:], params)
+	case "scenes":
+		bridge.handleScenes(w, method, address, resource[1:], params)
+	case "schedules":
+		bridge.handleSchedules(w, method, address, resource[1:], params)
+	default:
+		writeError(w, 3, address, fmt.Sprintf("resource, %s, not available", address))
+	}
+}
+
+func (bridge *Bridge) handleConfig(w http.ResponseWriter, method, address string, resource []string, params map[string]interface{}) {
+	if len(resource) != 0 {
+		writeError(w, 3, address, fmt.Sprintf("resource, %s, not available", address))
+		return
+	}
+
+	switch method {
+	case "GET":
+		writeJSON(w, bridge.fullConfig())
+	case "PUT":
+		results := []response{}
+		for _, key := range sortedKeys(params) {
+			value := params[key]
+			switch key {
+			case "name":
+				name, ok := value.(string)
+				if !ok || name == "" {
+					results = append(results, invalidValue(address+"/"+key, key, value))
+					continue
+				}
+				bridge.state.Config.Name = name
+			case "linkbutton":
+				pressed, ok := value.(bool)
+				if !ok {
+					results = append(results, invalidValue(address+"/"+key, key, value))
+					continue
+				}
+				if pressed {
+					bridge.linkButtonPressed = time.Now()
+				} else {
+					bridge.linkButtonPressed = time.Time{}
+				}
+			default:
+				results = append(results, errorResponse(6, address+"/"+key, fmt.Sprintf("parameter, %s, not available", key)))
+				continue
+			}
+			results = append(results, successResponse(address+"/"+key, value))
+		}
+		writeJSON(w, results)
+	default:
+		methodNotAvailable(w, method, address)
+	}
+}
+
+func methodNotAvailable(w http.ResponseWriter, method, address string) {
+	writeError(w, 4, address, fmt.Sprintf("method, %s, not available for resource, %s", method, address))
+}
+
+func notAvailable(w http.ResponseWriter, address string) {
+	writeError(w, 3, address, fmt.Sprintf("resource, %s, not available", address))
+}
+
+func invalidValue(address, key string, value interface{}) response {
+	return errorResponse(7, address, fmt.Sprintf("invalid value, %v, for parameter, %s", value, key))
+}
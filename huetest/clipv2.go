@@ -0,0 +1,497 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package huetest
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// V2ID returns the ID of the CLIP v2 resource of a type (like "light" or
+// "room") that belongs to the v1 resource with the ID.
+func V2ID(rtype, id string) string {
+	sum := sha1.Sum([]byte(rtype + "/" + id))
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// v2Resource is a resource of the CLIP v2 API, with its attributes as they
+// are returned in JSON.
+type v2Resource map[string]interface{}
+
+func (r v2Resource) id() string {
+	return r["id"].(string)
+}
+
+func (r v2Resource) rtype() string {
+	return r["type"].(string)
+}
+
+func reference(rtype, id string) map[string]interface{} {
+	return map[string]interface{}{"rid": V2ID(rtype, id), "rtype": rtype}
+}
+
+func writeV2(w http.ResponseWriter, status int, data []interface{}, errs ...string) {
+	errors := []map[string]string{}
+	for _, err := range errs {
+		errors = append(errors, map[string]string{"description": err})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": errors,
+		"data":   data,
+	})
+}
+
+// serveV2 handles a request for the CLIP v2 API under /clip/v2/resource. The
+// user is passed in the hue-application-key header.
+func (bridge *Bridge) serveV2(w http.ResponseWriter, r *http.Request) {
+	if bridge.state.V2 == nil || !strings.HasPrefix(r.URL.Path, "/clip/v2/resource") {
+		http.NotFound(w, r)
+		return
+	}
+
+	if _, ok := bridge.state.Config.Whitelist[r.Header.Get("hue-application-key")]; !ok {
+		writeV2(w, http.StatusForbidden, []interface{}{}, "unauthorized user")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/clip/v2/resource"), "/"), "/")
+	if parts[0] == "" {
+		parts = parts[1:]
+	}
+
+	switch {
+	case r.Method == "GET" && len(parts) <= 2:
+		data := []interface{}{}
+		for _, resource := range bridge.v2Resources() {
+			if len(parts) > 0 && resource.rtype() != parts[0] {
+				continue
+			}
+			if len(parts) > 1 && resource.id() != parts[1] {
+				continue
+			}
+			data = append(data, resource)
+		}
+
+		if len(parts) == 2 && len(data) == 0 {
+			writeV2(w, http.StatusNotFound, data, "Not Found")
+			return
+		}
+		writeV2(w, http.StatusOK, data)
+	case r.Method == "PUT" && len(parts) == 2:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var params map[string]interface{}
+		err = json.Unmarshal(body, &params)
+		if err != nil {
+			writeV2(w, http.StatusBadRequest, []interface{}{}, "invalid JSON in the body")
+			return
+		}
+
+		bridge.updateV2(w, parts[0], parts[1], params)
+		bridge.changed()
+	default:
+		writeV2(w, http.StatusMethodNotAllowed, []interface{}{}, fmt.Sprintf("method %s not allowed for %s", r.Method, r.URL.Path))
+	}
+}
+
+// v2Resources returns the resources of the CLIP v2 API for the state, sorted
+// by type and ID.
+func (bridge *Bridge) v2Resources() []v2Resource {
+	bridge.updateGroupStates()
+
+	config := bridge.state.Config
+	resources := []v2Resource{{
+		"id":        V2ID("bridge", config.BridgeID),
+		"owner":     reference("device", "bridge"),
+		"bridge_id": strings.ToLower(config.BridgeID),
+		"type":      "bridge",
+	}, {
+		"id":       V2ID("device", "bridge"),
+		"metadata": map[string]interface{}{"name": config.Name, "archetype": "bridge_v2"},
+		"product_data": map[string]interface{}{
+			"model_id":          config.ModelID,
+			"manufacturer_name": "Signify Netherlands B.V.",
+			"product_name":      "Hue Bridge",
+			"software_version":  config.SWVersion,
+		},
+		"services": []interface{}{reference("bridge", config.BridgeID)},
+		"type":     "device",
+	}}
+
+	for id, light := range bridge.state.Lights {
+		resources = append(resources, v2Resource{
+			"id":       V2ID("device", id),
+			"id_v1":    "/lights/" + id,
+			"metadata": map[string]interface{}{"name": light.Name, "archetype": "classic_bulb"},
+			"product_data": map[string]interface{}{
+				"model_id":          light.ModelID,
+				"manufacturer_name": light.ManufacturerName,
+				"product_name":      light.Type,
+				"software_version":  light.SWVersion,
+			},
+			"services": []interface{}{reference("light", id), reference("zigbee_connectivity", id)},
+			"type":     "device",
+		}, bridge.v2Light(id, light))
+
+		status := "connected"
+		if !light.State.Reachable {
+			status = "connectivity_issue"
+		}
+		resources = append(resources, v2Resource{
+			"id":          V2ID("zigbee_connectivity", id),
+			"owner":       reference("device", id),
+			"status":      status,
+			"mac_address": strings.Split(light.UniqueID, "-")[0],
+			"type":        "zigbee_connectivity",
+		})
+	}
+
+	groups := map[string]*Group{"0": bridge.allLights()}
+	for id, group := range bridge.state.Groups {
+		groups[id] = group
+	}
+	for id, group := range groups {
+		resources = append(resources, v2Resource{
+			"id":    V2ID("grouped_light", id),
+			"id_v1": "/groups/" + id,
+			"on":    map[string]interface{}{"on": group.State.AnyOn},
+			"type":  "grouped_light",
+		})
+
+		rtype := strings.ToLower(group.Type)
+		if rtype != "room" && rtype != "zone" {
+			continue
+		}
+
+		// the children of a room are devices, those of a zone are lights
+		children := []interface{}{}
+		for _, light := range group.Lights {
+			if rtype == "room" {
+				children = append(children, reference("device", light))
+			} else {
+				children = append(children, reference("light", light))
+			}
+		}
+
+		resources = append(resources, v2Resource{
+			"id":       V2ID(rtype, id),
+			"id_v1":    "/groups/" + id,
+			"metadata": map[string]interface{}{"name": group.Name, "archetype": strings.ToLower(strings.Replace(group.Class, " ", "_", -1))},
+			"children": children,
+			"services": []interface{}{reference("grouped_light", id)},
+			"type":     rtype,
+		})
+	}
+
+	for id, scene := range bridge.state.Scenes {
+		resource := v2Resource{
+			"id":       V2ID("scene", id),
+			"id_v1":    "/scenes/" + id,
+			"metadata": map[string]interface{}{"name": scene.Name},
+			"type":     "scene",
+		}
+		if group, ok := bridge.state.Groups[scene.Group]; ok {
+			resource["group"] = reference(strings.ToLower(group.Type), scene.Group)
+		}
+		if bridge.dynamicScene(id) {
+			resource["palette"] = map[string]interface{}{"color": []interface{}{}}
+		}
+		resources = append(resources, resource)
+	}
+
+	for id, smartScene := range bridge.state.V2.SmartScenes {
+		resource := v2Resource{
+			"id":       V2ID("smart_scene", id),
+			"metadata": map[string]interface{}{"name": smartScene.Name},
+			"state":    smartScene.State,
+			"type":     "smart_scene",
+		}
+		if group, ok := bridge.state.Groups[smartScene.Group]; ok {
+			resource["group"] = reference(strings.ToLower(group.Type), smartScene.Group)
+		}
+		resources = append(resources, resource)
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].rtype() != resources[j].rtype() {
+			return resources[i].rtype() < resources[j].rtype()
+		}
+		return resources[i].id() < resources[j].id()
+	})
+
+	return resources
+}
+
+func (bridge *Bridge) v2Light(id string, light *Light) v2Resource {
+	resource := v2Resource{
+		"id":       V2ID("light", id),
+		"id_v1":    "/lights/" + id,
+		"owner":    reference("device", id),
+		"metadata": map[string]interface{}{"name": light.Name, "archetype": "classic_bulb"},
+		"on":       map[string]interface{}{"on": light.State.On},
+		"dimming":  map[string]interface{}{"brightness": math.Round(float64(light.State.Bri)*10000/254) / 100},
+		"type":     "light",
+	}
+
+	if supportsParameter(light, "xy") {
+		resource["color"] = map[string]interface{}{
+			"xy": map[string]interface{}{"x": light.State.XY[0], "y": light.State.XY[1]},
+		}
+	}
+
+	if v2, ok := bridge.state.V2.Lights[id]; ok {
+		if len(v2.Effects) != 0 {
+			effect := v2.Effect
+			if effect == "" {
+				effect = "no_effect"
+			}
+			resource["effects"] = map[string]interface{}{
+				"effect":        effect,
+				"effect_values": append([]string{"no_effect"}, v2.Effects...),
+				"status":        effect,
+			}
+		}
+
+		if v2.GradientPoints != 0 {
+			points := []interface{}{}
+			for _, xy := range v2.Gradient {
+				points = append(points, map[string]interface{}{
+					"color": map[string]interface{}{"xy": map[string]interface{}{"x": xy[0], "y": xy[1]}},
+				})
+			}
+			resource["gradient"] = map[string]interface{}{
+				"points":         points,
+				"points_capable": v2.GradientPoints,
+			}
+		}
+	}
+
+	return resource
+}
+
+func (bridge *Bridge) dynamicScene(id string) bool {
+	for _, scene := range bridge.state.V2.DynamicScenes {
+		if scene == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// v1ID returns the ID of the v1 resource for the ID of a CLIP v2 resource.
+func v1ID(rtype, id string, ids []string) (string, bool) {
+	for _, v1 := range ids {
+		if V2ID(rtype, v1) == id {
+			return v1, true
+		}
+	}
+
+	return "", false
+}
+
+// v1State converts the on, dimming and color attributes of a CLIP v2 update
+// to the parameters of the v1 API.
+func v1State(params map[string]interface{}) map[string]interface{} {
+	state := map[string]interface{}{}
+
+	if on, ok := params["on"].(map[string]interface{}); ok {
+		state["on"] = on["on"]
+	}
+	if dimming, ok := params["dimming"].(map[string]interface{}); ok {
+		if brightness, ok := dimming["brightness"].(float64); ok {
+			state["bri"] = float64(clamp(int(math.Round(brightness*254/100)), 1, 254))
+		}
+	}
+	if color, ok := params["color"].(map[string]interface{}); ok {
+		if xy, ok := color["xy"].(map[string]interface{}); ok {
+			state["xy"] = []interface{}{xy["x"], xy["y"]}
+		}
+	}
+
+	return state
+}
+
+func (bridge *Bridge) updateV2(w http.ResponseWriter, rtype, id string, params map[string]interface{}) {
+	var ids []string
+	switch rtype {
+	case "light":
+		ids = sortedLightIDs(bridge.state.Lights)
+	case "grouped_light":
+		ids = append([]string{"0"}, sortedGroupIDs(bridge.state.Groups)...)
+	case "scene":
+		for scene := range bridge.state.Scenes {
+			ids = append(ids, scene)
+		}
+	case "smart_scene":
+		for scene := range bridge.state.V2.SmartScenes {
+			ids = append(ids, scene)
+		}
+	default:
+		writeV2(w, http.StatusMethodNotAllowed, []interface{}{}, fmt.Sprintf("resource type %s can not be modified", rtype))
+		return
+	}
+
+	v1, ok := v1ID(rtype, id, ids)
+	if !ok {
+		writeV2(w, http.StatusNotFound, []interface{}{}, "Not Found")
+		return
+	}
+
+	var err string
+	switch rtype {
+	case "light":
+		err = bridge.updateV2Light(v1, params)
+	case "grouped_light":
+		group := bridge.allLights()
+		if v1 != "0" {
+			group = bridge.state.Groups[v1]
+		}
+		bridge.groupAction(group, "/groups/"+v1+"/action", v1State(params))
+	case "scene":
+		err = bridge.recallV2Scene(v1, params)
+	case "smart_scene":
+		err = bridge.recallSmartScene(v1, params)
+	}
+
+	if err != "" {
+		writeV2(w, http.StatusBadRequest, []interface{}{}, err)
+		return
+	}
+
+	writeV2(w, http.StatusOK, []interface{}{map[string]interface{}{"rid": id, "rtype": rtype}})
+}
+
+func (bridge *Bridge) updateV2Light(id string, params map[string]interface{}) string {
+	light := bridge.state.Lights[id]
+	v2, ok := bridge.state.V2.Lights[id]
+	if !ok {
+		v2 = &V2Light{}
+	}
+
+	if effects, ok := params["effects"].(map[string]interface{}); ok {
+		effect, _ := effects["effect"].(string)
+		supported := effect == "no_effect"
+		for _, e := range v2.Effects {
+			supported = supported || e == effect
+		}
+		if !supported {
+			return fmt.Sprintf("effect %s is not supported by the light", effect)
+		}
+
+		if effect == "no_effect" {
+			effect = ""
+		}
+		v2.Effect = effect
+	}
+
+	if gradient, ok := params["gradient"].(map[string]interface{}); ok {
+		points, _ := gradient["points"].([]interface{})
+		if v2.GradientPoints == 0 {
+			return "the light does not support gradients"
+		} else if len(points) < 2 || len(points) > v2.GradientPoints {
+			return fmt.Sprintf("a gradient needs 2 to %d points", v2.GradientPoints)
+		}
+
+		v2.Gradient = [][2]float64{}
+		for _, point := range points {
+			p, _ := point.(map[string]interface{})
+			color, _ := p["color"].(map[string]interface{})
+			xy, _ := color["xy"].(map[string]interface{})
+			x, _ := xy["x"].(float64)
+			y, _ := xy["y"].(float64)
+			v2.Gradient = append(v2.Gradient, [2]float64{x, y})
+		}
+	}
+
+	setLightState(light, "/lights/"+id+"/state", v1State(params))
+
+	return ""
+}
+
+func (bridge *Bridge) recallV2Scene(id string, params map[string]interface{}) string {
+	recall, _ := params["recall"].(map[string]interface{})
+	action, _ := recall["action"].(string)
+
+	switch action {
+	case "active", "static":
+	case "dynamic_palette":
+		if !bridge.dynamicScene(id) {
+			return fmt.Sprintf("scene %s has no palette", bridge.state.Scenes[id].Name)
+		}
+	default:
+		return fmt.Sprintf("invalid recall action %q", action)
+	}
+
+	bridge.groupAction(bridge.allLights(), "/groups/0/action", map[string]interface{}{"scene": id})
+
+	return ""
+}
+
+func (bridge *Bridge) recallSmartScene(id string, params map[string]interface{}) string {
+	recall, _ := params["recall"].(map[string]interface{})
+	action, _ := recall["action"].(string)
+
+	switch action {
+	case "activate":
+		bridge.state.V2.SmartScenes[id].State = "active"
+	case "deactivate":
+		bridge.state.V2.SmartScenes[id].State = "inactive"
+	default:
+		return fmt.Sprintf("invalid recall action %q", action)
+	}
+
+	return ""
+}
+
+func sortedLightIDs(lights map[string]*Light) []string {
+	ids := []string{}
+	for id := range lights {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+func sortedGroupIDs(groups map[string]*Group) []string {
+	ids := []string{}
+	for id := range groups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
@@ -0,0 +1,235 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package huetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// buttonEvents are the names of the CLIP v2 button events, by the last
+// digit of the v1 buttonevent.
+var buttonEvents = []string{"initial_press", "repeat", "short_release", "long_release"}
+
+// serveEventStream sends the changes of the state as server-sent events, like
+// /eventstream/clip/v2 of a real bridge. The bridge is not locked while the
+// stream is open.
+func (bridge *Bridge) serveEventStream(w http.ResponseWriter, r *http.Request) {
+	bridge.mu.Lock()
+	if bridge.state.V2 == nil {
+		bridge.mu.Unlock()
+		http.NotFound(w, r)
+		return
+	}
+
+	if _, ok := bridge.state.Config.Whitelist[r.Header.Get("hue-application-key")]; !ok {
+		bridge.mu.Unlock()
+		writeV2(w, http.StatusForbidden, []interface{}{}, "unauthorized user")
+		return
+	}
+
+	if len(bridge.subscribers) == 0 {
+		bridge.updateGroupStates()
+		bridge.published = bridge.state.copy()
+	}
+
+	events := make(chan []byte, 64)
+	bridge.subscribers[events] = true
+	bridge.mu.Unlock()
+
+	defer func() {
+		bridge.mu.Lock()
+		delete(bridge.subscribers, events)
+		bridge.mu.Unlock()
+	}()
+
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": hi\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	for n := 0; ; n++ {
+		select {
+		case <-r.Context().Done():
+			return
+		case data, ok := <-events:
+			if !ok {
+				return
+			}
+
+			fmt.Fprintf(w, "id: %d:%d\ndata: %s\n\n", time.Now().Unix(), n, data)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// closeEventStreams ends the open event streams, the server can not be
+// stopped while they are open.
+func (bridge *Bridge) closeEventStreams() {
+	bridge.mu.Lock()
+	defer bridge.mu.Unlock()
+
+	for events := range bridge.subscribers {
+		close(events)
+		delete(bridge.subscribers, events)
+	}
+}
+
+// publishEvents sends the differences with the previously published state
+// to the subscribers of the event stream.
+func (bridge *Bridge) publishEvents() {
+	if len(bridge.subscribers) == 0 {
+		return
+	}
+
+	bridge.updateGroupStates()
+	previous := bridge.published
+	bridge.published = bridge.state.copy()
+
+	data := bridge.v2Events(previous)
+	if len(data) == 0 {
+		return
+	}
+
+	message, err := json.Marshal([]map[string]interface{}{{
+		"creationtime": time.Now().UTC().Format(time.RFC3339),
+		"id":           V2ID("event", fmt.Sprint(time.Now().UnixNano())),
+		"type":         "update",
+		"data":         data,
+	}})
+	if err != nil {
+		return
+	}
+
+	for events := range bridge.subscribers {
+		select {
+		case events <- message:
+		default:
+			// the subscriber does not keep up, drop the event
+		}
+	}
+}
+
+// v2Events returns the CLIP v2 update events for the lights, groups and
+// sensors that changed since the previous state.
+func (bridge *Bridge) v2Events(previous *State) []interface{} {
+	data := []interface{}{}
+
+	for _, id := range sortedLightIDs(bridge.state.Lights) {
+		old, ok := previous.Lights[id]
+		if !ok {
+			continue
+		}
+
+		light := bridge.state.Lights[id]
+		event := v2Resource{}
+		if old.State.On != light.State.On {
+			event["on"] = map[string]interface{}{"on": light.State.On}
+		}
+		if old.State.Bri != light.State.Bri {
+			event["dimming"] = map[string]interface{}{"brightness": math.Round(float64(light.State.Bri)*10000/254) / 100}
+		}
+		if old.State.XY != light.State.XY && supportsParameter(light, "xy") {
+			event["color"] = map[string]interface{}{
+				"xy": map[string]interface{}{"x": light.State.XY[0], "y": light.State.XY[1]},
+			}
+		}
+		if old.State.CT != light.State.CT && supportsParameter(light, "ct") {
+			event["color_temperature"] = map[string]interface{}{"mirek": light.State.CT, "mirek_valid": true}
+		}
+		if v2, ok := bridge.state.V2.Lights[id]; ok {
+			if oldV2, ok := previous.V2.Lights[id]; ok && oldV2.Effect != v2.Effect {
+				event["effects"] = map[string]interface{}{"status": v2.Effect}
+			}
+		}
+
+		if len(event) != 0 {
+			data = append(data, event.update("light", "/lights/", id))
+		}
+	}
+
+	for _, id := range sortedGroupIDs(bridge.state.Groups) {
+		old, ok := previous.Groups[id]
+		if ok && old.State.AnyOn != bridge.state.Groups[id].State.AnyOn {
+			event := v2Resource{"on": map[string]interface{}{"on": bridge.state.Groups[id].State.AnyOn}}
+			data = append(data, event.update("grouped_light", "/groups/", id))
+		}
+	}
+
+	ids := []string{}
+	for id := range bridge.state.Sensors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		old, ok := previous.Sensors[id]
+		if !ok {
+			continue
+		}
+
+		state := bridge.state.Sensors[id].State
+		changed := func(key string) bool {
+			_, ok := state[key]
+			return ok && !reflect.DeepEqual(old.State[key], state[key])
+		}
+
+		switch {
+		case changed("presence"):
+			event := v2Resource{"motion": map[string]interface{}{"motion": state["presence"], "motion_valid": true}}
+			data = append(data, event.update("motion", "/sensors/", id))
+		case changed("temperature"):
+			temperature, _ := state["temperature"].(float64)
+			event := v2Resource{"temperature": map[string]interface{}{"temperature": temperature / 100, "temperature_valid": true}}
+			data = append(data, event.update("temperature", "/sensors/", id))
+		case changed("lightlevel"):
+			event := v2Resource{"light": map[string]interface{}{"light_level": state["lightlevel"], "light_level_valid": true}}
+			data = append(data, event.update("light_level", "/sensors/", id))
+		case changed("buttonevent"), changed("lastupdated") && state["buttonevent"] != nil:
+			// pressing the same button again only changes lastupdated
+			code, _ := state["buttonevent"].(float64)
+			event := v2Resource{"button": map[string]interface{}{"last_event": buttonEvents[int(code)%1000%len(buttonEvents)]}}
+			data = append(data, event.update("button", "/sensors/", id))
+		}
+	}
+
+	return data
+}
+
+// update adds the attributes that identify the resource to the changed
+// attributes of an event.
+func (r v2Resource) update(rtype, v1Path, id string) v2Resource {
+	r["id"] = V2ID(rtype, id)
+	r["id_v1"] = v1Path + id
+	r["type"] = rtype
+
+	return r
+}
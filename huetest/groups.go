@@ -0,0 +1,262 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package huetest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// updateGroupStates sets the any_on and all_on state of the groups from the
+// lights that are part of the group.
+func (bridge *Bridge) updateGroupStates() {
+	for _, group := range bridge.state.Groups {
+		group.State = bridge.groupState(group.Lights)
+	}
+}
+
+func (bridge *Bridge) groupState(lights []string) GroupState {
+	state := GroupState{}
+
+	on := 0
+	for _, id := range lights {
+		light, ok := bridge.state.Lights[id]
+		if ok && light.State.On {
+			on++
+		}
+	}
+
+	state.AnyOn = on > 0
+	state.AllOn = on > 0 && on == len(lights)
+
+	return state
+}
+
+// allLights is group 0, which contains all the lights of the bridge.
+func (bridge *Bridge) allLights() *Group {
+	lights := []string{}
+	for id := range bridge.state.Lights {
+		lights = append(lights, id)
+	}
+	sort.Strings(lights)
+
+	return &Group{
+		Name:   "Lightset 0",
+		Lights: lights,
+		Type:   "LightGroup",
+		State:  bridge.groupState(lights),
+	}
+}
+
+func (bridge *Bridge) nextID(resources map[string]bool) string {
+	for i := 1; ; i++ {
+		id := strconv.Itoa(i)
+		if !resources[id] {
+			return id
+		}
+	}
+}
+
+func (bridge *Bridge) handleGroups(w http.ResponseWriter, method, address string, resource []string, params map[string]interface{}) {
+	bridge.updateGroupStates()
+
+	if len(resource) == 0 {
+		switch method {
+		case "GET":
+			writeJSON(w, bridge.state.Groups)
+		case "POST":
+			bridge.newGroup(w, address, params)
+		default:
+			methodNotAvailable(w, method, address)
+		}
+		return
+	}
+
+	id := resource[0]
+	group, ok := bridge.state.Groups[id]
+	if id == "0" {
+		group, ok = bridge.allLights(), true
+	}
+	if !ok {
+		notAvailable(w, address)
+		return
+	}
+
+	switch {
+	case len(resource) == 1 && method == "GET":
+		writeJSON(w, group)
+	case len(resource) == 1 && method == "PUT" && id != "0":
+		results := []response{}
+		for _, key := range sortedKeys(params) {
+			value := params[key]
+			ok := true
+			switch key {
+			case "name":
+				ok = setString(&group.Name, value)
+			case "class":
+				ok = setString(&group.Class, value)
+			case "lights":
+				var lights []string
+				lights, ok = bridge.lightIDs(value)
+				if ok {
+					group.Lights = lights
+				}
+			default:
+				results = append(results, errorResponse(6, address+"/"+key, fmt.Sprintf("parameter, %s, not available", key)))
+				continue
+			}
+			if !ok {
+				results = append(results, invalidValue(address+"/"+key, key, value))
+				continue
+			}
+			results = append(results, successResponse(address+"/"+key, value))
+		}
+		writeJSON(w, results)
+	case len(resource) == 1 && method == "DELETE" && id != "0":
+		delete(bridge.state.Groups, id)
+		writeJSON(w, []response{{"success": fmt.Sprintf("/groups/%s deleted", id)}})
+	case len(resource) == 2 && resource[1] == "action" && method == "PUT":
+		writeJSON(w, bridge.groupAction(group, address, params))
+	default:
+		methodNotAvailable(w, method, address)
+	}
+}
+
+func (bridge *Bridge) newGroup(w http.ResponseWriter, address string, params map[string]interface{}) {
+	group := &Group{
+		Type: "LightGroup",
+	}
+
+	if !setString(&group.Name, params["name"]) {
+		writeError(w, 5, address, "invalid/missing parameters in body")
+		return
+	}
+
+	if value, ok := params["type"]; ok && !setString(&group.Type, value) {
+		writeJSON(w, []response{invalidValue(address+"/type", "type", value)})
+		return
+	}
+
+	if value, ok := params["class"]; ok && !setString(&group.Class, value) {
+		writeJSON(w, []response{invalidValue(address+"/class", "class", value)})
+		return
+	}
+	if group.Type == "Room" && group.Class == "" {
+		group.Class = "Other"
+	}
+
+	lights, ok := bridge.lightIDs(params["lights"])
+	if !ok {
+		writeJSON(w, []response{invalidValue(address+"/lights", "lights", params["lights"])})
+		return
+	}
+	group.Lights = lights
+
+	ids := map[string]bool{}
+	for id := range bridge.state.Groups {
+		ids[id] = true
+	}
+	id := bridge.nextID(ids)
+	group.State = bridge.groupState(group.Lights)
+	bridge.state.Groups[id] = group
+
+	writeJSON(w, []response{successResponse("id", id)})
+}
+
+// lightIDs converts a list of light indexes, and verifies that the lights
+// exist.
+func (bridge *Bridge) lightIDs(value interface{}) ([]string, bool) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	lights := []string{}
+	for _, item := range list {
+		id, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+
+		_, ok = bridge.state.Lights[id]
+		if !ok {
+			return nil, false
+		}
+
+		lights = append(lights, id)
+	}
+
+	return lights, true
+}
+
+// groupAction applies the parameters to all lights of the group. Recalling a
+// scene is done with the "scene" parameter.
+func (bridge *Bridge) groupAction(group *Group, address string, params map[string]interface{}) []response {
+	if sceneID, ok := params["scene"]; ok {
+		id, _ := sceneID.(string)
+		scene, ok := bridge.state.Scenes[id]
+		if !ok {
+			return []response{invalidValue(address+"/scene", "scene", sceneID)}
+		}
+
+		for lightID, state := range scene.LightStates {
+			light, ok := bridge.state.Lights[lightID]
+			if ok {
+				setLightState(light, "/lights/"+lightID+"/state", state)
+			}
+		}
+
+		return []response{successResponse(address+"/scene", id)}
+	}
+
+	for _, id := range group.Lights {
+		light, ok := bridge.state.Lights[id]
+		if ok {
+			setLightState(light, "/lights/"+id+"/state", params)
+		}
+	}
+
+	// the action of a group reflects the last command
+	action := &Light{State: group.Action}
+	action.State.Reachable = true
+	setLightState(action, address, params)
+	action.State.Reachable = group.Action.Reachable
+	group.Action = action.State
+
+	results := []response{}
+	for _, key := range sortedKeys(params) {
+		results = append(results, successResponse(address+"/"+key, params[key]))
+	}
+
+	return results
+}
+
+func setString(field *string, value interface{}) bool {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return false
+	}
+
+	*field = s
+	return true
+}
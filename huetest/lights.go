@@ -0,0 +1,265 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package huetest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+func (bridge *Bridge) handleLights(w http.ResponseWriter, method, address string, resource []string, params map[string]interface{}) {
+	if len(resource) == 0 {
+		switch method {
+		case "GET":
+			writeJSON(w, bridge.state.Lights)
+		case "POST":
+			// searching for new lights, nothing will be found
+			writeJSON(w, []response{successResponse("/lights", "Searching for new devices")})
+		default:
+			methodNotAvailable(w, method, address)
+		}
+		return
+	}
+
+	if resource[0] == "new" && len(resource) == 1 && method == "GET" {
+		writeJSON(w, map[string]interface{}{"lastscan": "none"})
+		return
+	}
+
+	id := resource[0]
+	light, ok := bridge.state.Lights[id]
+	if !ok {
+		notAvailable(w, address)
+		return
+	}
+
+	switch {
+	case len(resource) == 1 && method == "GET":
+		writeJSON(w, light)
+	case len(resource) == 1 && method == "PUT":
+		results := []response{}
+		for _, key := range sortedKeys(params) {
+			name, ok := params[key].(string)
+			if key != "name" {
+				results = append(results, errorResponse(6, address+"/"+key, fmt.Sprintf("parameter, %s, not available", key)))
+			} else if !ok || name == "" || len(name) > 32 {
+				results = append(results, invalidValue(address+"/"+key, key, params[key]))
+			} else {
+				light.Name = name
+				results = append(results, successResponse(address+"/"+key, name))
+			}
+		}
+		writeJSON(w, results)
+	case len(resource) == 1 && method == "DELETE":
+		delete(bridge.state.Lights, id)
+		for _, group := range bridge.state.Groups {
+			group.Lights = removeString(group.Lights, id)
+		}
+		writeJSON(w, []response{{"success": fmt.Sprintf("/lights/%s deleted", id)}})
+	case len(resource) == 2 && resource[1] == "state" && method == "PUT":
+		writeJSON(w, setLightState(light, address, params))
+	default:
+		methodNotAvailable(w, method, address)
+	}
+}
+
+// setLightState applies the parameters to the light, and returns the
+// responses for each parameter, like the bridge does.
+func setLightState(light *Light, address string, params map[string]interface{}) []response {
+	results := []response{}
+
+	// the bridge does not know that an unreachable light misses the change
+	if !light.State.Reachable {
+		for _, key := range sortedKeys(params) {
+			results = append(results, successResponse(address+"/"+key, params[key]))
+		}
+		return results
+	}
+
+	// "on" is applied first, other parameters can only be modified when
+	// the light is (or gets switched) on
+	on := light.State.On
+	if value, ok := params["on"].(bool); ok {
+		on = value
+	}
+
+	for _, key := range sortedKeys(params) {
+		value := params[key]
+		paramAddress := address + "/" + key
+
+		if !on && key != "on" && key != "transitiontime" {
+			results = append(results, errorResponse(201, paramAddress,
+				fmt.Sprintf("parameter, %s, is not modifiable. Device is set to off.", key)))
+			continue
+		}
+
+		if !supportsParameter(light, key) {
+			results = append(results, errorResponse(6, paramAddress, fmt.Sprintf("parameter, %s, not available", key)))
+			continue
+		}
+
+		ok := true
+		switch key {
+		case "on":
+			light.State.On, ok = value.(bool)
+		case "bri":
+			ok = setInt(&light.State.Bri, value, 1, 254)
+		case "bri_inc":
+			var inc int
+			ok = setInt(&inc, value, -254, 254)
+			light.State.Bri = clamp(light.State.Bri+inc, 1, 254)
+		case "hue":
+			ok = setInt(&light.State.Hue, value, 0, 65535)
+			light.State.ColorMode = "hs"
+		case "sat":
+			ok = setInt(&light.State.Sat, value, 0, 254)
+			light.State.ColorMode = "hs"
+		case "ct":
+			ok = setInt(&light.State.CT, value, 153, 500)
+			light.State.ColorMode = "ct"
+		case "xy":
+			ok = setXY(&light.State.XY, value)
+			light.State.ColorMode = "xy"
+		case "effect":
+			ok = setEnum(&light.State.Effect, value, "none", "colorloop")
+		case "alert":
+			ok = setEnum(&light.State.Alert, value, "none", "select", "lselect")
+		case "transitiontime":
+			var transition int
+			ok = setInt(&transition, value, 0, 65535)
+		default:
+			results = append(results, errorResponse(6, paramAddress, fmt.Sprintf("parameter, %s, not available", key)))
+			continue
+		}
+
+		if !ok {
+			results = append(results, invalidValue(paramAddress, key, value))
+			continue
+		}
+
+		results = append(results, successResponse(paramAddress, value))
+	}
+
+	return results
+}
+
+// supportsParameter checks if the type of light has the capabilities for the
+// parameter.
+func supportsParameter(light *Light, key string) bool {
+	switch light.Type {
+	case "On/Off plug-in unit":
+		return key == "on" || key == "alert" || key == "transitiontime"
+	case "Dimmable light":
+		return key != "hue" && key != "sat" && key != "xy" && key != "ct" && key != "effect"
+	case "Color temperature light":
+		return key != "hue" && key != "sat" && key != "xy" && key != "effect"
+	}
+
+	return true
+}
+
+func number(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+
+	return 0, false
+}
+
+func setInt(field *int, value interface{}, min, max int) bool {
+	n, ok := number(value)
+	if !ok || n != float64(int(n)) || int(n) < min || int(n) > max {
+		return false
+	}
+
+	*field = int(n)
+	return true
+}
+
+func setXY(field *[2]float64, value interface{}) bool {
+	list, ok := value.([]interface{})
+	if !ok || len(list) != 2 {
+		return false
+	}
+
+	xy := [2]float64{}
+	for i, v := range list {
+		n, ok := number(v)
+		if !ok || n < 0 || n > 1 {
+			return false
+		}
+		xy[i] = n
+	}
+
+	*field = xy
+	return true
+}
+
+func setEnum(field *string, value interface{}, allowed ...string) bool {
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+
+	for _, a := range allowed {
+		if s == a {
+			*field = s
+			return true
+		}
+	}
+
+	return false
+}
+
+func clamp(n, min, max int) int {
+	if n < min {
+		return min
+	} else if n > max {
+		return max
+	}
+
+	return n
+}
+
+func sortedKeys(params map[string]interface{}) []string {
+	keys := []string{}
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func removeString(list []string, s string) []string {
+	result := []string{}
+	for _, item := range list {
+		if item != s {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,48 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package huetest
+
+import (
+	"net/http"
+)
+
+func (bridge *Bridge) handleScenes(w http.ResponseWriter, method, address string, resource []string, params map[string]interface{}) {
+	switch {
+	case len(resource) == 0 && method == "GET":
+		// lightstates are only returned for a single scene
+		scenes := map[string]Scene{}
+		for id, scene := range bridge.state.Scenes {
+			s := *scene
+			s.LightStates = nil
+			scenes[id] = s
+		}
+		writeJSON(w, scenes)
+	case len(resource) == 1 && method == "GET":
+		scene, ok := bridge.state.Scenes[resource[0]]
+		if !ok {
+			notAvailable(w, address)
+			return
+		}
+		writeJSON(w, scene)
+	default:
+		methodNotAvailable(w, method, address)
+	}
+}
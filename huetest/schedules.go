@@ -0,0 +1,317 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package huetest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayout is the format of the absolute times that the bridge uses.
+const timeLayout = "2006-01-02T15:04:05"
+
+// initSchedules sets the start time of timers that do not have one yet, the
+// timers of a fixture start when the bridge starts.
+func (bridge *Bridge) initSchedules(now time.Time) {
+	for _, schedule := range bridge.state.Schedules {
+		if schedule.StartTime == "" && strings.Contains(schedule.LocalTime, "PT") {
+			schedule.StartTime = now.Format(timeLayout)
+		}
+	}
+}
+
+// Tick executes the commands of the enabled schedules that should have
+// fired between the previous tick and now. It should be called regularly,
+// at least every second.
+func (bridge *Bridge) Tick(now time.Time) {
+	bridge.mu.Lock()
+	defer bridge.mu.Unlock()
+
+	if bridge.lastTick.IsZero() {
+		bridge.lastTick = now
+		return
+	}
+
+	fired := false
+	for _, id := range scheduleIDs(bridge.state.Schedules) {
+		schedule := bridge.state.Schedules[id]
+		if schedule.Status != "enabled" {
+			continue
+		}
+
+		due, recurring, err := scheduleDue(schedule, bridge.lastTick, now)
+		if err != nil || !due {
+			continue
+		}
+
+		command := schedule.Command
+		bridge.route(httptest.NewRecorder(), command.Method, command.Address, command.Body)
+		fired = true
+
+		if !recurring {
+			if schedule.AutoDelete {
+				delete(bridge.state.Schedules, id)
+			} else {
+				schedule.Status = "disabled"
+			}
+		}
+	}
+
+	bridge.lastTick = now
+
+	if fired {
+		bridge.changed()
+	}
+}
+
+func scheduleIDs(schedules map[string]*Schedule) []string {
+	params := map[string]interface{}{}
+	for id := range schedules {
+		params[id] = nil
+	}
+
+	return sortedKeys(params)
+}
+
+// scheduleDue checks if the schedule should fire after from, until (and
+// including) to. Supported are absolute times, recurring times on weekdays
+// (W<bitmask>/T<time>), timers (PT<duration>) and recurring timers
+// (R/PT<duration>).
+func scheduleDue(schedule *Schedule, from, to time.Time) (due bool, recurring bool, err error) {
+	localtime := schedule.LocalTime
+
+	switch {
+	case strings.HasPrefix(localtime, "W"):
+		parts := strings.SplitN(localtime[1:], "/T", 2)
+		if len(parts) != 2 {
+			return false, true, errors.New(fmt.Sprintf("invalid localtime %s", localtime))
+		}
+
+		// bit 6 is Monday, bit 0 is Sunday
+		days, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return false, true, err
+		}
+
+		at, err := time.ParseInLocation("15:04:05", parts[1], to.Location())
+		if err != nil {
+			return false, true, err
+		}
+
+		candidate := time.Date(to.Year(), to.Month(), to.Day(), at.Hour(), at.Minute(), at.Second(), 0, to.Location())
+		bit := (7 - int(candidate.Weekday())) % 7
+		if days&(1<<uint(bit)) == 0 {
+			return false, true, nil
+		}
+
+		return candidate.After(from) && !candidate.After(to), true, nil
+	case strings.HasPrefix(localtime, "R/PT") || strings.HasPrefix(localtime, "PT"):
+		recurring = strings.HasPrefix(localtime, "R/")
+
+		duration, err := parseDuration(strings.TrimPrefix(strings.TrimPrefix(localtime, "R/"), "PT"))
+		if err != nil || duration <= 0 {
+			return false, recurring, errors.New(fmt.Sprintf("invalid localtime %s", localtime))
+		}
+
+		start, err := time.ParseInLocation(timeLayout, schedule.StartTime, to.Location())
+		if err != nil {
+			return false, recurring, err
+		}
+
+		if !recurring {
+			at := start.Add(duration)
+			return at.After(from) && !at.After(to), false, nil
+		}
+
+		// the number of periods that passed changes when it fires
+		return to.Sub(start)/duration > from.Sub(start)/duration && to.After(start), true, nil
+	default:
+		at, err := time.ParseInLocation(timeLayout, localtime, to.Location())
+		if err != nil {
+			return false, false, err
+		}
+
+		return at.After(from) && !at.After(to), false, nil
+	}
+}
+
+// parseDuration converts a hh:mm:ss duration.
+func parseDuration(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, errors.New(fmt.Sprintf("invalid duration %s", s))
+	}
+
+	duration := time.Duration(0)
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return 0, err
+		}
+		duration += time.Duration(n) * unit
+	}
+
+	return duration, nil
+}
+
+func (bridge *Bridge) handleSchedules(w http.ResponseWriter, method, address string, resource []string, params map[string]interface{}) {
+	if len(resource) == 0 {
+		switch method {
+		case "GET":
+			writeJSON(w, bridge.state.Schedules)
+		case "POST":
+			bridge.newSchedule(w, address, params)
+		default:
+			methodNotAvailable(w, method, address)
+		}
+		return
+	}
+
+	id := resource[0]
+	schedule, ok := bridge.state.Schedules[id]
+	if !ok || len(resource) != 1 {
+		notAvailable(w, address)
+		return
+	}
+
+	switch method {
+	case "GET":
+		writeJSON(w, schedule)
+	case "PUT":
+		results := []response{}
+		for _, key := range sortedKeys(params) {
+			value := params[key]
+			ok := true
+			switch key {
+			case "name":
+				ok = setString(&schedule.Name, value)
+			case "description":
+				schedule.Description, ok = value.(string)
+			case "status":
+				ok = setEnum(&schedule.Status, value, "enabled", "disabled")
+				if ok && schedule.Status == "enabled" && strings.Contains(schedule.LocalTime, "PT") {
+					// enabling a timer restarts it
+					schedule.StartTime = time.Now().Format(timeLayout)
+				}
+			case "localtime":
+				ok = setLocalTime(schedule, value)
+			case "autodelete":
+				schedule.AutoDelete, ok = value.(bool)
+			default:
+				results = append(results, errorResponse(6, address+"/"+key, fmt.Sprintf("parameter, %s, not available", key)))
+				continue
+			}
+			if !ok {
+				results = append(results, invalidValue(address+"/"+key, key, value))
+				continue
+			}
+			results = append(results, successResponse(address+"/"+key, value))
+		}
+		writeJSON(w, results)
+	case "DELETE":
+		delete(bridge.state.Schedules, id)
+		writeJSON(w, []response{{"success": fmt.Sprintf("/schedules/%s deleted", id)}})
+	default:
+		methodNotAvailable(w, method, address)
+	}
+}
+
+func (bridge *Bridge) newSchedule(w http.ResponseWriter, address string, params map[string]interface{}) {
+	now := time.Now()
+	schedule := &Schedule{
+		Name:       "schedule",
+		Status:     "enabled",
+		AutoDelete: true,
+		Created:    now.UTC().Format(timeLayout),
+	}
+
+	command, ok := params["command"].(map[string]interface{})
+	if !ok {
+		writeError(w, 5, address, "invalid/missing parameters in body")
+		return
+	}
+	schedule.Command.Address, _ = command["address"].(string)
+	schedule.Command.Method, _ = command["method"].(string)
+	schedule.Command.Body, _ = command["body"].(map[string]interface{})
+	if schedule.Command.Address == "" || schedule.Command.Method == "" {
+		writeJSON(w, []response{invalidValue(address+"/command", "command", params["command"])})
+		return
+	}
+
+	if !setLocalTime(schedule, params["localtime"]) {
+		writeJSON(w, []response{invalidValue(address+"/localtime", "localtime", params["localtime"])})
+		return
+	}
+
+	if value, ok := params["name"]; ok && !setString(&schedule.Name, value) {
+		writeJSON(w, []response{invalidValue(address+"/name", "name", value)})
+		return
+	}
+	if value, ok := params["description"].(string); ok {
+		schedule.Description = value
+	}
+	if value, ok := params["status"]; ok && !setEnum(&schedule.Status, value, "enabled", "disabled") {
+		writeJSON(w, []response{invalidValue(address+"/status", "status", value)})
+		return
+	}
+	if value, ok := params["autodelete"].(bool); ok {
+		schedule.AutoDelete = value
+	}
+
+	ids := map[string]bool{}
+	for id := range bridge.state.Schedules {
+		ids[id] = true
+	}
+	id := bridge.nextID(ids)
+	bridge.state.Schedules[id] = schedule
+
+	writeJSON(w, []response{successResponse("id", id)})
+}
+
+// setLocalTime validates the time of a schedule, timers start counting
+// when the time is set.
+func setLocalTime(schedule *Schedule, value interface{}) bool {
+	localtime, ok := value.(string)
+	if !ok {
+		return false
+	}
+
+	now := time.Now()
+	s := &Schedule{LocalTime: localtime, StartTime: now.Format(timeLayout)}
+	_, _, err := scheduleDue(s, now, now)
+	if err != nil {
+		return false
+	}
+
+	schedule.LocalTime = localtime
+	if strings.Contains(localtime, "PT") {
+		schedule.StartTime = s.StartTime
+	} else {
+		schedule.StartTime = ""
+	}
+
+	return true
+}
@@ -0,0 +1,102 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package huetest
+
+import (
+	"testing"
+	"time"
+)
+
+func scheduleState() *State {
+	state := &State{
+		Lights: map[string]*Light{
+			"1": {Name: "Desk Lamp", Type: "Dimmable light", State: LightState{Reachable: true}},
+		},
+		Schedules: map[string]*Schedule{
+			"1": {
+				Name:      "wake up",
+				LocalTime: "W124/T07:00:00",
+				Status:    "enabled",
+				Command: ScheduleCommand{
+					Address: "/api/testuser/lights/1/state",
+					Method:  "PUT",
+					Body:    map[string]interface{}{"on": true},
+				},
+			},
+			"2": {
+				Name:       "timer",
+				LocalTime:  "PT00:10:00",
+				StartTime:  "2018-10-01T06:00:00",
+				Status:     "enabled",
+				AutoDelete: true,
+				Command: ScheduleCommand{
+					Address: "/api/testuser/lights/1/state",
+					Method:  "PUT",
+					Body:    map[string]interface{}{"on": false},
+				},
+			},
+		},
+	}
+	state.Config.Whitelist = map[string]WhitelistEntry{"testuser": {}}
+	state.init()
+
+	return state
+}
+
+func TestScheduleWeekdays(t *testing.T) {
+	bridge := NewBridge(scheduleState())
+	bridge.state.Schedules["2"].Status = "disabled"
+
+	// 2018-10-06 is a Saturday, not part of W124 (Monday-Friday)
+	bridge.Tick(time.Date(2018, 10, 6, 6, 59, 59, 0, time.Local))
+	bridge.Tick(time.Date(2018, 10, 6, 7, 0, 0, 0, time.Local))
+	if bridge.State().Lights["1"].State.On {
+		t.Fatal("schedule fired on a Saturday")
+	}
+
+	// 2018-10-01 is a Monday
+	bridge.Tick(time.Date(2018, 10, 1, 6, 59, 59, 0, time.Local))
+	bridge.Tick(time.Date(2018, 10, 1, 7, 0, 0, 0, time.Local))
+	if !bridge.State().Lights["1"].State.On {
+		t.Fatal("schedule did not fire on a Monday")
+	}
+
+	if bridge.State().Schedules["1"].Status != "enabled" {
+		t.Error("recurring schedule got disabled")
+	}
+}
+
+func TestScheduleTimer(t *testing.T) {
+	state := scheduleState()
+	state.Lights["1"].State.On = true
+	state.Schedules["1"].Status = "disabled"
+	bridge := NewBridge(state)
+
+	bridge.Tick(time.Date(2018, 10, 1, 6, 9, 59, 0, time.Local))
+	bridge.Tick(time.Date(2018, 10, 1, 6, 10, 0, 0, time.Local))
+	if bridge.State().Lights["1"].State.On {
+		t.Fatal("timer did not fire")
+	}
+
+	if _, ok := bridge.State().Schedules["2"]; ok {
+		t.Error("timer was not deleted after firing")
+	}
+}
@@ -0,0 +1,104 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package huetest
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func (bridge *Bridge) handleSensors(w http.ResponseWriter, method, address string, resource []string, params map[string]interface{}) {
+	if len(resource) == 0 {
+		switch method {
+		case "GET":
+			writeJSON(w, bridge.state.Sensors)
+		case "POST":
+			// searching for new sensors, /sensors/new reports the
+			// sensors from the fixture
+			writeJSON(w, []response{successResponse("/sensors", "Searching for new devices")})
+		default:
+			methodNotAvailable(w, method, address)
+		}
+		return
+	}
+
+	if resource[0] == "new" && len(resource) == 1 && method == "GET" {
+		newSensors := map[string]interface{}{
+			"lastscan": "none",
+		}
+		for _, id := range bridge.state.NewSensors {
+			sensor, ok := bridge.state.Sensors[id]
+			if ok {
+				newSensors[id] = map[string]string{"name": sensor.Name}
+			}
+		}
+		writeJSON(w, newSensors)
+		return
+	}
+
+	id := resource[0]
+	sensor, ok := bridge.state.Sensors[id]
+	if !ok {
+		notAvailable(w, address)
+		return
+	}
+
+	switch {
+	case len(resource) == 1 && method == "GET":
+		writeJSON(w, sensor)
+	case len(resource) == 1 && method == "PUT":
+		results := []response{}
+		for _, key := range sortedKeys(params) {
+			value := params[key]
+			name, ok := value.(string)
+			if key != "name" {
+				results = append(results, errorResponse(6, address+"/"+key, fmt.Sprintf("parameter, %s, not available", key)))
+			} else if !ok || name == "" || len(name) > 32 {
+				results = append(results, invalidValue(address+"/"+key, key, value))
+			} else {
+				sensor.Name = name
+				results = append(results, successResponse(address+"/"+key, name))
+			}
+		}
+		writeJSON(w, results)
+	case len(resource) == 1 && method == "DELETE":
+		delete(bridge.state.Sensors, id)
+		writeJSON(w, []response{{"success": fmt.Sprintf("/sensors/%s deleted", id)}})
+	case len(resource) == 2 && (resource[1] == "state" || resource[1] == "config") && method == "PUT":
+		attributes := sensor.State
+		if resource[1] == "config" {
+			attributes = sensor.Config
+		}
+
+		results := []response{}
+		for _, key := range sortedKeys(params) {
+			if _, ok := attributes[key]; !ok {
+				results = append(results, errorResponse(6, address+"/"+key, fmt.Sprintf("parameter, %s, not available", key)))
+				continue
+			}
+			attributes[key] = params[key]
+			results = append(results, successResponse(address+"/"+key, params[key]))
+		}
+		writeJSON(w, results)
+	default:
+		methodNotAvailable(w, method, address)
+	}
+}
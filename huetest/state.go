@@ -0,0 +1,329 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package huetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	// for yaml conversion of the fixtures
+	"gopkg.in/yaml.v2"
+)
+
+// State is the complete datastore of a fake bridge. It can be loaded from a
+// YAML fixture with LoadState, the layout follows the JSON documents that the
+// Hue API returns.
+type State struct {
+	Config  Config             `json:"config" yaml:"config"`
+	Lights  map[string]*Light  `json:"lights" yaml:"lights"`
+	Groups  map[string]*Group  `json:"groups" yaml:"groups"`
+	Sensors map[string]*Sensor `json:"sensors" yaml:"sensors"`
+	Scenes  map[string]*Scene  `json:"scenes" yaml:"scenes"`
+
+	Schedules map[string]*Schedule `json:"schedules" yaml:"schedules"`
+
+	// NewSensors contains the indexes of the sensors that are reported
+	// by /sensors/new
+	NewSensors []string `json:"newsensors,omitempty" yaml:"newsensors,omitempty"`
+
+	// V2 contains the state that is only available through the CLIP v2
+	// API, /clip/v2 is not served when it is not set
+	V2 *V2State `json:"v2,omitempty" yaml:"v2,omitempty"`
+}
+
+// V2State contains the attributes of resources that the v1 API does not
+// expose. Lights and groups are referenced by their index in the v1 API.
+type V2State struct {
+	Lights map[string]*V2Light `json:"lights,omitempty" yaml:"lights,omitempty"`
+
+	// DynamicScenes contains the IDs of the scenes with a palette, these
+	// can be recalled dynamically
+	DynamicScenes []string `json:"dynamicscenes,omitempty" yaml:"dynamicscenes,omitempty"`
+
+	SmartScenes map[string]*SmartScene `json:"smartscenes,omitempty" yaml:"smartscenes,omitempty"`
+}
+
+// A V2Light has the effects and gradient of a light.
+type V2Light struct {
+	// Effects are the effects that the light supports, like candle
+	Effects []string `json:"effects,omitempty" yaml:"effects,omitempty"`
+	Effect  string   `json:"effect,omitempty" yaml:"effect,omitempty"`
+
+	// GradientPoints is the number of points a gradient lightstrip
+	// supports, it is 0 for lights without gradient
+	GradientPoints int          `json:"gradientpoints,omitempty" yaml:"gradientpoints,omitempty"`
+	Gradient       [][2]float64 `json:"gradient,omitempty" yaml:"gradient,omitempty"`
+}
+
+// A SmartScene activates scenes depending on the time of the day.
+type SmartScene struct {
+	Name  string `json:"name" yaml:"name"`
+	Group string `json:"group" yaml:"group"`
+	// State is active or inactive
+	State string `json:"state" yaml:"state"`
+}
+
+type Config struct {
+	Name       string                    `json:"name" yaml:"name"`
+	BridgeID   string                    `json:"bridgeid" yaml:"bridgeid"`
+	MAC        string                    `json:"mac" yaml:"mac"`
+	IPAddress  string                    `json:"ipaddress" yaml:"ipaddress"`
+	ModelID    string                    `json:"modelid" yaml:"modelid"`
+	SWVersion  string                    `json:"swversion" yaml:"swversion"`
+	APIVersion string                    `json:"apiversion" yaml:"apiversion"`
+	LinkButton bool                      `json:"linkbutton" yaml:"linkbutton"`
+	Whitelist  map[string]WhitelistEntry `json:"whitelist" yaml:"whitelist"`
+}
+
+type WhitelistEntry struct {
+	Name       string `json:"name" yaml:"name"`
+	CreateDate string `json:"create date" yaml:"create date"`
+	LastUse    string `json:"last use date" yaml:"last use date"`
+}
+
+type Light struct {
+	State            LightState `json:"state" yaml:"state"`
+	Type             string     `json:"type" yaml:"type"`
+	Name             string     `json:"name" yaml:"name"`
+	ModelID          string     `json:"modelid" yaml:"modelid"`
+	ManufacturerName string     `json:"manufacturername" yaml:"manufacturername"`
+	UniqueID         string     `json:"uniqueid" yaml:"uniqueid"`
+	SWVersion        string     `json:"swversion" yaml:"swversion"`
+}
+
+type LightState struct {
+	On        bool       `json:"on" yaml:"on"`
+	Bri       int        `json:"bri" yaml:"bri"`
+	Hue       int        `json:"hue" yaml:"hue"`
+	Sat       int        `json:"sat" yaml:"sat"`
+	Effect    string     `json:"effect" yaml:"effect"`
+	XY        [2]float64 `json:"xy" yaml:"xy"`
+	CT        int        `json:"ct" yaml:"ct"`
+	Alert     string     `json:"alert" yaml:"alert"`
+	ColorMode string     `json:"colormode" yaml:"colormode"`
+	Reachable bool       `json:"reachable" yaml:"reachable"`
+}
+
+type Group struct {
+	Name   string     `json:"name" yaml:"name"`
+	Lights []string   `json:"lights" yaml:"lights"`
+	Type   string     `json:"type" yaml:"type"`
+	Class  string     `json:"class,omitempty" yaml:"class,omitempty"`
+	State  GroupState `json:"state" yaml:"state"`
+	Action LightState `json:"action" yaml:"action"`
+}
+
+type GroupState struct {
+	AllOn bool `json:"all_on" yaml:"all_on"`
+	AnyOn bool `json:"any_on" yaml:"any_on"`
+}
+
+type Sensor struct {
+	State            map[string]interface{} `json:"state" yaml:"state"`
+	Config           map[string]interface{} `json:"config" yaml:"config"`
+	Name             string                 `json:"name" yaml:"name"`
+	Type             string                 `json:"type" yaml:"type"`
+	ModelID          string                 `json:"modelid" yaml:"modelid"`
+	ManufacturerName string                 `json:"manufacturername" yaml:"manufacturername"`
+	UniqueID         string                 `json:"uniqueid,omitempty" yaml:"uniqueid,omitempty"`
+	SWVersion        string                 `json:"swversion" yaml:"swversion"`
+}
+
+type Schedule struct {
+	Name        string          `json:"name" yaml:"name"`
+	Description string          `json:"description" yaml:"description"`
+	Command     ScheduleCommand `json:"command" yaml:"command"`
+	LocalTime   string          `json:"localtime" yaml:"localtime"`
+	Status      string          `json:"status" yaml:"status"`
+	AutoDelete  bool            `json:"autodelete" yaml:"autodelete"`
+	Created     string          `json:"created" yaml:"created"`
+	StartTime   string          `json:"starttime,omitempty" yaml:"starttime,omitempty"`
+}
+
+// A ScheduleCommand is the request that is executed when a schedule fires.
+type ScheduleCommand struct {
+	Address string                 `json:"address" yaml:"address"`
+	Method  string                 `json:"method" yaml:"method"`
+	Body    map[string]interface{} `json:"body" yaml:"body"`
+}
+
+type Scene struct {
+	Name        string                            `json:"name" yaml:"name"`
+	Type        string                            `json:"type" yaml:"type"`
+	Group       string                            `json:"group,omitempty" yaml:"group,omitempty"`
+	Lights      []string                          `json:"lights" yaml:"lights"`
+	Owner       string                            `json:"owner" yaml:"owner"`
+	Recycle     bool                              `json:"recycle" yaml:"recycle"`
+	Locked      bool                              `json:"locked" yaml:"locked"`
+	LightStates map[string]map[string]interface{} `json:"lightstates,omitempty" yaml:"lightstates,omitempty"`
+}
+
+// LoadState reads a YAML fixture with the state of a bridge.
+func LoadState(filename string) (*State, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &State{}
+	err = yaml.Unmarshal(data, state)
+	if err != nil {
+		return nil, err
+	}
+
+	state.init()
+	state.jsonMaps()
+
+	// the bridge copies the state through JSON
+	_, err = state.clone()
+	if err != nil {
+		return nil, fmt.Errorf("invalid state in %s: %w", filename, err)
+	}
+
+	return state, nil
+}
+
+// Save writes the state to a YAML file, that can be loaded with LoadState.
+func (state *State) Save(filename string) error {
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, data, 0600)
+}
+
+// init makes sure all maps are allocated, fixtures do not need to contain
+// every type of resource.
+func (state *State) init() {
+	if state.Config.Whitelist == nil {
+		state.Config.Whitelist = map[string]WhitelistEntry{}
+	}
+	if state.Lights == nil {
+		state.Lights = map[string]*Light{}
+	}
+	if state.Groups == nil {
+		state.Groups = map[string]*Group{}
+	}
+	if state.Sensors == nil {
+		state.Sensors = map[string]*Sensor{}
+	}
+	if state.Scenes == nil {
+		state.Scenes = map[string]*Scene{}
+	}
+	if state.Schedules == nil {
+		state.Schedules = map[string]*Schedule{}
+	}
+
+	if state.V2 != nil {
+		if state.V2.Lights == nil {
+			state.V2.Lights = map[string]*V2Light{}
+		}
+		if state.V2.SmartScenes == nil {
+			state.V2.SmartScenes = map[string]*SmartScene{}
+		}
+	}
+
+	for _, sensor := range state.Sensors {
+		if sensor.State == nil {
+			sensor.State = map[string]interface{}{}
+		}
+		if sensor.Config == nil {
+			sensor.Config = map[string]interface{}{}
+		}
+	}
+}
+
+// jsonMaps converts the nested maps that YAML decodes with interface{} keys
+// to maps with string keys, like JSON decodes them.
+func (state *State) jsonMaps() {
+	for _, sensor := range state.Sensors {
+		jsonMap(sensor.State)
+		jsonMap(sensor.Config)
+	}
+	for _, schedule := range state.Schedules {
+		jsonMap(schedule.Command.Body)
+	}
+	for _, scene := range state.Scenes {
+		for _, lightState := range scene.LightStates {
+			jsonMap(lightState)
+		}
+	}
+}
+
+// jsonMap converts the values of the map in place, see jsonValue.
+func jsonMap(m map[string]interface{}) {
+	for key, value := range m {
+		m[key] = jsonValue(value)
+	}
+}
+
+// jsonValue returns the value with all nested maps converted to maps with
+// string keys.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return m
+	case map[string]interface{}:
+		jsonMap(v)
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+	}
+
+	return value
+}
+
+// clone returns a deep copy of the state, or an error when the state can
+// not be converted to JSON.
+func (state *State) clone() (*State, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &State{}
+	err = json.Unmarshal(data, c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.init()
+
+	return c, nil
+}
+
+// copy returns a deep copy of the state. States from LoadState and changes
+// through the Hue API can always be copied.
+func (state *State) copy() *State {
+	c, err := state.clone()
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
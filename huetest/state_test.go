@@ -0,0 +1,77 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package huetest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeState writes a YAML fixture for LoadState.
+func writeState(t *testing.T, fixture string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "bridge.yaml")
+	err := os.WriteFile(file, []byte(fixture), 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", file, err)
+	}
+
+	return file
+}
+
+func TestLoadStateNested(t *testing.T) {
+	file := writeState(t, ""+
+		"sensors:\n"+
+		"  \"1\":\n"+
+		"    name: Hallway switch\n"+
+		"    config:\n"+
+		"      on: true\n"+
+		"      pending:\n"+
+		"        sensitivity: 2\n"+
+		"        alerts: [{select: true}]\n")
+
+	state, err := LoadState(file)
+	if err != nil {
+		t.Fatalf("failed to load the state: %s", err)
+	}
+
+	bridge := NewBridge(state)
+	pending, ok := bridge.State().Sensors["1"].Config["pending"].(map[string]interface{})
+	if !ok || pending["sensitivity"] != float64(2) {
+		t.Errorf("unexpected nested config: %#v", bridge.State().Sensors["1"].Config)
+	}
+}
+
+func TestLoadStateInvalid(t *testing.T) {
+	file := writeState(t, ""+
+		"sensors:\n"+
+		"  \"1\":\n"+
+		"    name: Daylight\n"+
+		"    state:\n"+
+		"      lightlevel: .nan\n")
+
+	_, err := LoadState(file)
+	if err == nil {
+		t.Error("expected an error for a state that can not be copied")
+	}
+}
@@ -0,0 +1,205 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package utils
+
+import (
+	"fmt"
+)
+
+// An Alias is a local name for a light or a group, that does not change the
+// name on the bridge. A light is referred to by its unique ID, a group by
+// its ID as groups do not have a unique ID. Bridge is the ID of the bridge
+// that the light or group is on, it is empty for aliases of older versions.
+type Alias struct {
+	Name   string `yaml:"name"`
+	Bridge string `yaml:"bridge,omitempty"`
+	Light  string `yaml:"light,omitempty"`
+	Group  string `yaml:"group,omitempty"`
+}
+
+// A Tag is a local name for a set of lights and groups, it can be used where
+// a list of lights is selected. All lights and groups of a tag are on the
+// bridge with the ID in Bridge.
+type Tag struct {
+	Name   string   `yaml:"name"`
+	Bridge string   `yaml:"bridge,omitempty"`
+	Lights []string `yaml:"lights,omitempty"`
+	Groups []string `yaml:"groups,omitempty"`
+}
+
+// Kind returns "light" or "group".
+func (alias *Alias) Kind() string {
+	if alias.Group != "" {
+		return "group"
+	}
+
+	return "light"
+}
+
+// Target returns the unique ID of the light, or the ID of the group.
+func (alias *Alias) Target() string {
+	if alias.Group != "" {
+		return alias.Group
+	}
+
+	return alias.Light
+}
+
+// Matches returns true when the item is the light or group of the alias.
+func (alias *Alias) Matches(kind string, item InventoryItem) bool {
+	switch kind {
+	case "light":
+		return alias.Light != "" && item.UniqueID == alias.Light
+	case "group":
+		return alias.Group != "" && item.ID == alias.Group
+	}
+
+	return false
+}
+
+// A LocalNameError is returned when the light or group that an alias or tag
+// refers to is not on the bridge.
+type LocalNameError struct {
+	Name   string
+	Kind   string
+	Target string
+}
+
+func (e *LocalNameError) Error() string {
+	return fmt.Sprintf("'%s' refers to %s %s, which is not on the bridge", e.Name, e.Kind, e.Target)
+}
+
+// A BridgeNameError is returned when an alias or tag refers to lights or
+// groups on another bridge than the one in use.
+type BridgeNameError struct {
+	Name   string
+	Bridge string
+	Active string
+}
+
+func (e *BridgeNameError) Error() string {
+	return fmt.Sprintf("'%s' refers to bridge %s, not to bridge %s", e.Name, e.Bridge, e.Active)
+}
+
+// checkBridge returns a BridgeNameError when the alias or tag is of another
+// bridge than active. Without a bridge it is used on any bridge.
+func checkBridge(name, bridge, active string) error {
+	if bridge != "" && bridge != active {
+		return &BridgeNameError{Name: name, Bridge: bridge, Active: active}
+	}
+
+	return nil
+}
+
+// FindAlias returns the alias with the name, or nil. Names are compared
+// after NormalizeName.
+func FindAlias(aliases []Alias, name string) *Alias {
+	normalized := NormalizeName(name)
+	for i := range aliases {
+		if NormalizeName(aliases[i].Name) == normalized {
+			return &aliases[i]
+		}
+	}
+
+	return nil
+}
+
+// FindTag returns the tag with the name, or nil. Names are compared after
+// NormalizeName.
+func FindTag(tags []Tag, name string) *Tag {
+	normalized := NormalizeName(name)
+	for i := range tags {
+		if NormalizeName(tags[i].Name) == normalized {
+			return &tags[i]
+		}
+	}
+
+	return nil
+}
+
+// ResolveAlias returns the item of the kind that the alias with the name
+// refers to. ok is false when there is no alias of the kind with the name,
+// the name should then be resolved with ResolveItem. A BridgeNameError is
+// returned when the alias is not of the bridge with the ID, and a
+// LocalNameError when the item is not in the items.
+func ResolveAlias(aliases []Alias, bridge, kind string, items []InventoryItem, name string) (item InventoryItem, ok bool, err error) {
+	alias := FindAlias(aliases, name)
+	if alias == nil || alias.Kind() != kind {
+		return InventoryItem{}, false, nil
+	}
+
+	err = checkBridge(alias.Name, alias.Bridge, bridge)
+	if err != nil {
+		return InventoryItem{}, true, err
+	}
+
+	for _, item := range items {
+		if alias.Matches(kind, item) {
+			return item, true, nil
+		}
+	}
+
+	return InventoryItem{}, true, &LocalNameError{Name: alias.Name, Kind: kind, Target: alias.Target()}
+}
+
+// ResolveTag returns the lights and groups of the tag with the name. ok is
+// false when there is no tag with the name. A BridgeNameError is returned
+// when the tag is not of the bridge with the ID, and a LocalNameError when
+// one of them is not in the inventory.
+func ResolveTag(tags []Tag, bridge string, inventory *Inventory, name string) (lights, groups []InventoryItem, ok bool, err error) {
+	tag := FindTag(tags, name)
+	if tag == nil {
+		return nil, nil, false, nil
+	}
+
+	err = checkBridge(tag.Name, tag.Bridge, bridge)
+	if err != nil {
+		return nil, nil, true, err
+	}
+
+	for _, uniqueID := range tag.Lights {
+		item, err := tagItem(tag.Name, Alias{Light: uniqueID}, "light", inventory.Lights)
+		if err != nil {
+			return nil, nil, true, err
+		}
+		lights = append(lights, item)
+	}
+
+	for _, id := range tag.Groups {
+		item, err := tagItem(tag.Name, Alias{Group: id}, "group", inventory.Groups)
+		if err != nil {
+			return nil, nil, true, err
+		}
+		groups = append(groups, item)
+	}
+
+	return lights, groups, true, nil
+}
+
+func tagItem(tag string, alias Alias, kind string, items []InventoryItem) (InventoryItem, error) {
+	for _, item := range items {
+		if alias.Matches(kind, item) {
+			return item, nil
+		}
+	}
+
+	return InventoryItem{}, &LocalNameError{Name: tag, Kind: kind, Target: alias.Target()}
+}
@@ -0,0 +1,113 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolveAlias(t *testing.T) {
+	aliases := []Alias{
+		{Name: "Standing Desk", Bridge: "001788fffe000001", Light: "00:17:88:01:00:00:00:01-0b"},
+		{Name: "work", Group: "1"},
+	}
+	lights := []InventoryItem{
+		{ID: "1", Name: "Desk Lamp", UniqueID: "00:17:88:01:00:00:00:01-0b"},
+		{ID: "2", Name: "Ceiling", UniqueID: "00:17:88:01:00:00:00:02-0b"},
+	}
+
+	item, ok, err := ResolveAlias(aliases, "001788fffe000001", "light", lights, "standing  desk")
+	if !ok || err != nil || item.ID != "1" {
+		t.Errorf("unexpected result: %+v %t %v", item, ok, err)
+	}
+
+	// the light of the alias is on another bridge
+	_, ok, err = ResolveAlias(aliases, "001788fffe000002", "light", lights, "standing desk")
+	var bridge *BridgeNameError
+	if !ok || !errors.As(err, &bridge) {
+		t.Errorf("expected a BridgeNameError, got %v", err)
+	}
+
+	// aliases of groups do not apply to lights
+	_, ok, _ = ResolveAlias(aliases, "001788fffe000001", "light", lights, "work")
+	if ok {
+		t.Errorf("the alias of a group resolved to a light")
+	}
+
+	_, ok, err = ResolveAlias(aliases, "001788fffe000002", "group", nil, "work")
+	var local *LocalNameError
+	if !ok || !errors.As(err, &local) {
+		t.Errorf("expected a LocalNameError, got %v", err)
+	}
+}
+
+func TestResolveTag(t *testing.T) {
+	tags := []Tag{{Name: "demo-area", Bridge: "001788fffe000001", Lights: []string{"00:17:88:01:00:00:00:02-0b"}, Groups: []string{"1"}}}
+	inventory := &Inventory{
+		Lights: []InventoryItem{{ID: "2", Name: "Ceiling", UniqueID: "00:17:88:01:00:00:00:02-0b"}},
+		Groups: []InventoryItem{{ID: "1", Name: "Office"}},
+	}
+
+	lights, groups, ok, err := ResolveTag(tags, "001788fffe000001", inventory, "Demo-Area")
+	if !ok || err != nil || len(lights) != 1 || len(groups) != 1 {
+		t.Errorf("unexpected result: %+v %+v %t %v", lights, groups, ok, err)
+	}
+
+	_, _, ok, err = ResolveTag(tags, "001788fffe000002", inventory, "demo-area")
+	var bridge *BridgeNameError
+	if !ok || !errors.As(err, &bridge) {
+		t.Errorf("expected a BridgeNameError, got %v", err)
+	}
+
+	_, _, ok, _ = ResolveTag(tags, "001788fffe000001", inventory, "Ceiling")
+	if ok {
+		t.Errorf("a light name resolved as a tag")
+	}
+}
+
+func TestValidateAliases(t *testing.T) {
+	config := ConfigFile{
+		Aliases: []Alias{
+			{Name: "desk", Light: "00:17:88:01:00:00:00:01-0b"},
+			{Name: "both", Light: "00:17:88:01:00:00:00:01-0b", Group: "1"},
+		},
+		Tags: []Tag{
+			{Name: "Desk", Lights: []string{"00:17:88:01:00:00:00:02-0b"}},
+			{Name: "3"},
+		},
+	}
+
+	var problems ValidationError
+	if !errors.As(config.Validate(), &problems) {
+		t.Fatalf("expected a ValidationError")
+	}
+
+	expected := []string{
+		`alias "both": set either light or group`,
+		`tag "Desk": the name is also used by alias "desk"`,
+		`tag "3": the name can not be a number, that is an index`,
+	}
+	if strings.Join(problems, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected problems:\n%s", strings.Join(problems, "\n"))
+	}
+}
@@ -0,0 +1,79 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	// for yaml conversion of the Cassette
+	"gopkg.in/yaml.v2"
+)
+
+// A Cassette contains the recorded HTTP exchanges with a bridge.
+type Cassette struct {
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `yaml:"request"`
+	Response RecordedResponse `yaml:"response"`
+}
+
+type RecordedRequest struct {
+	Method string `yaml:"method"`
+	Host   string `yaml:"host"`
+	Path   string `yaml:"path"`
+	Body   string `yaml:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status      int    `yaml:"status"`
+	ContentType string `yaml:"contenttype,omitempty"`
+	Body        string `yaml:"body"`
+}
+
+// Save writes the cassette to a file, an existing file is replaced.
+func (cassette *Cassette) Save(filename string) error {
+	data, err := yaml.Marshal(cassette)
+	if err != nil {
+		return errors.New(fmt.Sprintf("failed to convert to yaml (%s)", err))
+	}
+
+	return ioutil.WriteFile(filename, data, 0600)
+}
+
+// LoadCassette reads a cassette from a file.
+func LoadCassette(filename string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	cassette := Cassette{}
+	err = yaml.Unmarshal(data, &cassette)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cassette, nil
+}
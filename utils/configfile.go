@@ -24,23 +24,71 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	// for yaml conversion of the ConfigFile
 	"gopkg.in/yaml.v2"
 )
 
+// ConfigVersion is the version of the layout of the configuration file that
+// is written by this hue-cli. Older layouts are migrated when they are read.
+const ConfigVersion = 1
+
 type ConfigFile struct {
-	Bridges []BridgeConfig
+	Version int            `yaml:"version"`
+	Bridges []BridgeConfig `yaml:"bridges"`
+	Aliases []Alias        `yaml:"aliases,omitempty"`
+	Tags    []Tag          `yaml:"tags,omitempty"`
+	Macros  []Macro        `yaml:"macros,omitempty"`
 }
 
 // A BridgeConfig contains connection details for the
 type BridgeConfig struct {
+	// Name tells the bridges apart, it can be passed to --bridge
+	Name      string `yaml:"name,omitempty"`
 	IPAddress string `yaml:"ipaddress"`
-	User      string `yaml:"user"`
+	User      string `yaml:"user,omitempty"`
+	// UserSecret refers to where the user is stored instead of in User,
+	// see LookupSecret
+	UserSecret string `yaml:"usersecret,omitempty"`
+	// Backend is the type of gateway: hue (default), deconz or diyhue
+	Backend string `yaml:"backend,omitempty"`
+	// V2Address is the <ip-address>:<port> of the CLIP v2 API, when it is
+	// not served on the default HTTPS port of the bridge
+	V2Address string `yaml:"v2address,omitempty"`
+
+	// HTTPS sends all requests over HTTPS. BridgeID and Fingerprint pin
+	// the certificate of the bridge, they are set on first use.
+	HTTPS       bool   `yaml:"https,omitempty"`
+	BridgeID    string `yaml:"bridgeid,omitempty"`
+	Fingerprint string `yaml:"fingerprint,omitempty"`
+
+	// Timeout, Retries and Backoff override the defaults for requests to
+	// the bridge
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	Retries int           `yaml:"retries,omitempty"`
+	Backoff time.Duration `yaml:"backoff,omitempty"`
+}
+
+// Username returns the User, or the secret that UserSecret refers to.
+func (bc *BridgeConfig) Username() (string, error) {
+	if bc.User != "" || bc.UserSecret == "" {
+		return bc.User, nil
+	}
+
+	return LookupSecret(bc.UserSecret)
 }
 
 func (config *ConfigFile) String() ([]byte, error) {
+	if config.Version == 0 {
+		config.Version = ConfigVersion
+	}
+
 	s, err := yaml.Marshal(&config)
 	if err != nil {
 		return nil, errors.New(fmt.Sprintf("failed to convert to yaml (%s)", err))
@@ -49,6 +97,20 @@ func (config *ConfigFile) String() ([]byte, error) {
 	return s, nil
 }
 
+// Save writes the configuration to the file, which is only readable by the
+// user as it contains the username.
+func (config *ConfigFile) Save(filename string) error {
+	data, err := config.String()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, data, 0600)
+}
+
+// NewConfigFile reads the configuration from the file. Older layouts are
+// migrated to ConfigVersion, unknown fields and invalid values are reported
+// with a *ConfigError.
 func NewConfigFile(filename string) (*ConfigFile, error) {
 	fd, err := os.Open(filename)
 	if err != nil {
@@ -61,11 +123,256 @@ func NewConfigFile(filename string) (*ConfigFile, error) {
 		return nil, err
 	}
 
+	config, err := ParseConfig(conf)
+	if err != nil {
+		return nil, &ConfigError{Filename: filename, Err: err}
+	}
+
+	return config, nil
+}
+
+// A ConfigError is returned when the configuration file can not be parsed, or
+// contains invalid values.
+type ConfigError struct {
+	Filename string
+	Err      error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid configuration in %s: %s", e.Filename, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError lists all problems that Validate found.
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return strings.Join(e, "; ")
+}
+
+// ParseConfig decodes the yaml of a configuration file, migrates an older
+// layout and validates the result.
+func ParseConfig(data []byte) (*ConfigFile, error) {
+	var header struct {
+		Version int `yaml:"version"`
+	}
+	err := yaml.Unmarshal(data, &header)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.Version > ConfigVersion {
+		return nil, fmt.Errorf("version %d is newer than the supported version %d, upgrade hue-cli", header.Version, ConfigVersion)
+	} else if header.Version < 0 {
+		return nil, fmt.Errorf("invalid version %d", header.Version)
+	}
+
+	// the line numbers in errors are only correct for the original layout
+	if header.Version < ConfigVersion {
+		data, err = migrateConfig(data, header.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	config := ConfigFile{}
-	err = yaml.Unmarshal(conf, &config)
+	err = yaml.UnmarshalStrict(data, &config)
+	if err != nil {
+		return nil, err
+	}
+	config.Version = ConfigVersion
+
+	err = config.Validate()
 	if err != nil {
 		return nil, err
 	}
 
 	return &config, nil
 }
+
+// configMigrations upgrade the layout of version i to version i+1.
+var configMigrations = []func(map[string]interface{}) error{
+	migrateConfigV0,
+}
+
+// migrateConfig converts the layout of the given version to ConfigVersion.
+func migrateConfig(data []byte, version int) ([]byte, error) {
+	layout := map[string]interface{}{}
+	err := yaml.Unmarshal(data, &layout)
+	if err != nil {
+		return nil, err
+	}
+
+	for ; version < ConfigVersion; version++ {
+		err = configMigrations[version](layout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate from version %d: %s", version, err)
+		}
+	}
+	layout["version"] = ConfigVersion
+
+	return yaml.Marshal(layout)
+}
+
+// migrateConfigV0 upgrades the files without a version, which only contain
+// the bridges list. The list did not change in version 1.
+func migrateConfigV0(layout map[string]interface{}) error {
+	return nil
+}
+
+var (
+	hostnameLabel = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+	numericLabel  = regexp.MustCompile(`^[0-9]+$`)
+	fingerprint   = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+)
+
+// validAddress checks that the address is an IP-address or hostname, with
+// an optional port.
+func validAddress(address string) bool {
+	host := address
+	if h, port, err := net.SplitHostPort(address); err == nil {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return false
+		}
+		host = h
+	}
+
+	if net.ParseIP(host) != nil {
+		return true
+	} else if host == "" || len(host) > 253 {
+		return false
+	}
+
+	labels := strings.Split(strings.TrimSuffix(host, "."), ".")
+	numeric := true
+	for _, label := range labels {
+		if !hostnameLabel.MatchString(label) {
+			return false
+		}
+		numeric = numeric && numericLabel.MatchString(label)
+	}
+
+	// 192.168.1 is a mistyped IP-address, not a hostname
+	return !numeric
+}
+
+// Validate checks the values in the configuration, it returns a
+// ValidationError with all the problems that were found.
+func (config *ConfigFile) Validate() error {
+	var problems ValidationError
+	names := map[string]int{}
+
+	for i, bc := range config.Bridges {
+		bridge := fmt.Sprintf("bridge %d", i+1)
+		if bc.Name != "" {
+			bridge = fmt.Sprintf("bridge %q", bc.Name)
+
+			if first, ok := names[strings.ToLower(bc.Name)]; ok {
+				problems = append(problems, fmt.Sprintf("%s: the name is also used by bridge %d", bridge, first+1))
+			} else {
+				names[strings.ToLower(bc.Name)] = i
+			}
+		}
+
+		if bc.IPAddress == "" {
+			problems = append(problems, fmt.Sprintf("%s: ipaddress is required", bridge))
+		} else if !validAddress(bc.IPAddress) {
+			problems = append(problems, fmt.Sprintf("%s: ipaddress %q is not an IP-address or hostname", bridge, bc.IPAddress))
+		}
+		if bc.V2Address != "" && !validAddress(bc.V2Address) {
+			problems = append(problems, fmt.Sprintf("%s: v2address %q is not an IP-address or hostname", bridge, bc.V2Address))
+		}
+
+		if bc.User != "" && bc.UserSecret != "" {
+			problems = append(problems, fmt.Sprintf("%s: user and usersecret can not both be set", bridge))
+		}
+		if bc.UserSecret != "" && !validSecretRef(bc.UserSecret) {
+			problems = append(problems, fmt.Sprintf("%s: usersecret %q is not a keyring:, file: or command: reference", bridge, bc.UserSecret))
+		}
+
+		if bc.Fingerprint != "" && !fingerprint.MatchString(bc.Fingerprint) {
+			problems = append(problems, fmt.Sprintf("%s: fingerprint is not a SHA-256 checksum", bridge))
+		}
+
+		if bc.Timeout < 0 {
+			problems = append(problems, fmt.Sprintf("%s: timeout can not be negative", bridge))
+		}
+		if bc.Retries < 0 {
+			problems = append(problems, fmt.Sprintf("%s: retries can not be negative", bridge))
+		}
+		if bc.Backoff < 0 {
+			problems = append(problems, fmt.Sprintf("%s: backoff can not be negative", bridge))
+		}
+	}
+
+	// aliases and tags are used in the same places, their names are unique
+	local := map[string]string{}
+	for _, alias := range config.Aliases {
+		name := fmt.Sprintf("alias %q", alias.Name)
+		if alias.Name == "" {
+			problems = append(problems, "an alias without a name")
+		} else if first, ok := local[NormalizeName(alias.Name)]; ok {
+			problems = append(problems, fmt.Sprintf("%s: the name is also used by %s", name, first))
+		} else {
+			local[NormalizeName(alias.Name)] = name
+		}
+
+		if numericLabel.MatchString(alias.Name) {
+			problems = append(problems, fmt.Sprintf("%s: the name can not be a number, that is an index", name))
+		}
+		if (alias.Light == "") == (alias.Group == "") {
+			problems = append(problems, fmt.Sprintf("%s: set either light or group", name))
+		}
+	}
+	for _, tag := range config.Tags {
+		name := fmt.Sprintf("tag %q", tag.Name)
+		if tag.Name == "" {
+			problems = append(problems, "a tag without a name")
+		} else if first, ok := local[NormalizeName(tag.Name)]; ok {
+			problems = append(problems, fmt.Sprintf("%s: the name is also used by %s", name, first))
+		} else {
+			local[NormalizeName(tag.Name)] = name
+		}
+
+		if numericLabel.MatchString(tag.Name) {
+			problems = append(problems, fmt.Sprintf("%s: the name can not be a number, that is an index", name))
+		}
+	}
+
+	macros := map[string]bool{}
+	for _, macro := range config.Macros {
+		name := fmt.Sprintf("macro %q", macro.Name)
+		if macro.Name == "" {
+			problems = append(problems, "a macro without a name")
+		} else if macros[NormalizeName(macro.Name)] {
+			problems = append(problems, fmt.Sprintf("%s: the name is used more than once", name))
+		}
+		macros[NormalizeName(macro.Name)] = true
+
+		err := macro.validate()
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+
+	if len(problems) != 0 {
+		return problems
+	}
+
+	return nil
+}
+
+// Bridge returns the bridge with the name, or nil.
+func (config *ConfigFile) Bridge(name string) *BridgeConfig {
+	for i := range config.Bridges {
+		if config.Bridges[i].Name != "" && strings.EqualFold(config.Bridges[i].Name, name) {
+			return &config.Bridges[i]
+		}
+	}
+
+	return nil
+}
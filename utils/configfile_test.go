@@ -0,0 +1,138 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig([]byte("version: 1\n" +
+		"bridges:\n" +
+		"- name: office\n" +
+		"  ipaddress: 192.168.1.10\n" +
+		"  user: abc\n" +
+		"- name: home\n" +
+		"  ipaddress: bridge.example.com:8080\n" +
+		"  usersecret: keyring:home\n"))
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+
+	if len(config.Bridges) != 2 {
+		t.Fatalf("expected 2 bridges, got %d", len(config.Bridges))
+	}
+	if bc := config.Bridge("HOME"); bc == nil || bc.IPAddress != "bridge.example.com:8080" {
+		t.Errorf("bridge home not found: %+v", bc)
+	}
+}
+
+func TestParseConfigUnknownField(t *testing.T) {
+	_, err := ParseConfig([]byte("version: 1\n" +
+		"bridges:\n" +
+		"- ipadress: 192.168.1.10\n"))
+	if err == nil || !strings.Contains(err.Error(), "ipadress") {
+		t.Errorf("expected an error for the unknown field, got %v", err)
+	}
+}
+
+func TestParseConfigNewerVersion(t *testing.T) {
+	_, err := ParseConfig([]byte("version: 99\nbridges: []\n"))
+	if err == nil {
+		t.Errorf("expected an error for a newer version")
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	_, err := ParseConfig([]byte("bridges:\n" +
+		"- name: office\n" +
+		"  ipaddress: 192.168.1\n" +
+		"- name: Office\n" +
+		"  ipaddress: 192.168.1.11:99999\n" +
+		"  user: abc\n" +
+		"  usersecret: vault:abc\n" +
+		"  retries: -1\n"))
+
+	var problems ValidationError
+	if !errors.As(err, &problems) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+
+	expected := []string{
+		`bridge "office": ipaddress "192.168.1" is not an IP-address or hostname`,
+		`bridge "Office": the name is also used by bridge 1`,
+		`bridge "Office": ipaddress "192.168.1.11:99999" is not an IP-address or hostname`,
+		`bridge "Office": user and usersecret can not both be set`,
+		`bridge "Office": usersecret "vault:abc" is not a keyring:, file: or command: reference`,
+		`bridge "Office": retries can not be negative`,
+	}
+	if strings.Join(problems, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected problems:\n%s", strings.Join(problems, "\n"))
+	}
+}
+
+func TestValidAddress(t *testing.T) {
+	for address, valid := range map[string]bool{
+		"192.168.1.10":      true,
+		"192.168.1.10:443":  true,
+		"[fe80::1]:443":     true,
+		"fe80::1":           true,
+		"hue-bridge":        true,
+		"hue.example.com.":  true,
+		"192.168.1":         false,
+		"192.168.1.10:0":    false,
+		"hue_bridge":        false,
+		"-hue.example.com":  false,
+		"hue.example.com:x": false,
+	} {
+		if validAddress(address) != valid {
+			t.Errorf("validAddress(%q) should be %t", address, valid)
+		}
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	// the layout that "hue-cli create-user" wrote before there was a version
+	config, err := ParseConfig([]byte("bridges:\n" +
+		"- ipaddress: 192.168.1.10\n" +
+		"  user: abc\n" +
+		"- ipaddress: 192.168.1.11\n" +
+		"  user: def\n"))
+	if err != nil {
+		t.Fatalf("failed to migrate: %s", err)
+	}
+
+	if config.Version != ConfigVersion {
+		t.Errorf("expected version %d, got %d", ConfigVersion, config.Version)
+	}
+	if len(config.Bridges) != 2 || config.Bridges[0].IPAddress != "192.168.1.10" || config.Bridges[0].User != "abc" ||
+		config.Bridges[1].IPAddress != "192.168.1.11" || config.Bridges[1].User != "def" {
+		t.Errorf("unexpected bridges after migration: %+v", config.Bridges)
+	}
+
+	// a bridge at the top level was never a valid layout
+	_, err = ParseConfig([]byte("ipaddress: 192.168.1.10\nuser: abc\n"))
+	if err == nil {
+		t.Error("expected an error for a bridge at the top level")
+	}
+}
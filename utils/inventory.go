@@ -0,0 +1,182 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// for yaml conversion of the Inventory
+	"gopkg.in/yaml.v2"
+)
+
+// An Inventory contains the lights, groups, sensors and scenes of a bridge,
+// so that names can be mapped to IDs without asking the bridge.
+type Inventory struct {
+	Bridge string `yaml:"bridge"`
+	// Address is where the bridge was reached, so that the inventory can
+	// be found without asking the bridge for its ID
+	Address string          `yaml:"address,omitempty"`
+	Updated time.Time       `yaml:"updated"`
+	Lights  []InventoryItem `yaml:"lights"`
+	Groups  []InventoryItem `yaml:"groups"`
+	Sensors []InventoryItem `yaml:"sensors"`
+	Scenes  []InventoryItem `yaml:"scenes"`
+}
+
+// An InventoryItem is a single light, group, sensor or scene.
+type InventoryItem struct {
+	ID       string `yaml:"id"`
+	Name     string `yaml:"name"`
+	UniqueID string `yaml:"uniqueid,omitempty"`
+}
+
+// InventoryDir returns the directory where the inventories are cached.
+func InventoryDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "hue-cli", "inventory"), nil
+}
+
+func inventoryFile(bridge string) (string, error) {
+	if bridge == "" || strings.ContainsAny(bridge, `/\`) || strings.HasPrefix(bridge, ".") {
+		return "", errors.New(fmt.Sprintf("invalid bridge ID '%s'", bridge))
+	}
+
+	dir, err := InventoryDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, bridge+".yaml"), nil
+}
+
+// Expired returns true when the inventory is older than the ttl.
+func (inventory *Inventory) Expired(ttl time.Duration) bool {
+	return time.Since(inventory.Updated) > ttl
+}
+
+// Save writes the inventory to the cache, the previous inventory of the
+// bridge is replaced.
+func (inventory *Inventory) Save() error {
+	filename, err := inventoryFile(inventory.Bridge)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(inventory)
+	if err != nil {
+		return errors.New(fmt.Sprintf("failed to convert to yaml (%s)", err))
+	}
+
+	err = os.MkdirAll(filepath.Dir(filename), 0700)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, data, 0600)
+}
+
+// LoadInventory reads the cached inventory of the bridge.
+func LoadInventory(bridge string) (*Inventory, error) {
+	filename, err := inventoryFile(bridge)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := Inventory{}
+	err = yaml.Unmarshal(data, &inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	return &inventory, nil
+}
+
+// FindInventory returns the most recent cached inventory of the bridge at
+// the address.
+func FindInventory(address string) (*Inventory, error) {
+	dir, err := InventoryDir()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var found *Inventory
+	for _, file := range files {
+		inventory, err := LoadInventory(strings.TrimSuffix(filepath.Base(file), ".yaml"))
+		if err != nil || inventory.Address != address {
+			continue
+		}
+
+		if found == nil || inventory.Updated.After(found.Updated) {
+			found = inventory
+		}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("there is no cached inventory of bridge %s", address)
+	}
+
+	return found, nil
+}
+
+// RemoveInventory deletes the cached inventory of the bridge.
+func RemoveInventory(bridge string) error {
+	filename, err := inventoryFile(bridge)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// ClearInventories deletes the cached inventories of all bridges.
+func ClearInventories() error {
+	dir, err := InventoryDir()
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(dir)
+}
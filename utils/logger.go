@@ -0,0 +1,94 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+type LogLevel int
+
+const (
+	// LevelQuiet only reports errors
+	LevelQuiet LogLevel = iota - 1
+	// LevelNormal reports the progress of commands
+	LevelNormal
+	// LevelVerbose adds details about what is done
+	LevelVerbose
+	// LevelDebug adds everything, including the traffic with the bridge
+	LevelDebug
+)
+
+// A Logger writes messages depending on the LogLevel. Progress messages go
+// to Out, verbose and debug messages to Err. When Out or Err are not set,
+// os.Stdout and os.Stderr are used.
+type Logger struct {
+	Level LogLevel
+	Out   io.Writer
+	Err   io.Writer
+}
+
+func (logger *Logger) out() io.Writer {
+	if logger.Out != nil {
+		return logger.Out
+	}
+
+	return os.Stdout
+}
+
+func (logger *Logger) err() io.Writer {
+	if logger.Err != nil {
+		return logger.Err
+	}
+
+	return os.Stderr
+}
+
+// Infof reports the progress of a command, unless the logger is quiet.
+func (logger *Logger) Infof(format string, a ...interface{}) {
+	if logger.Level >= LevelNormal {
+		fmt.Fprintf(logger.out(), format, a...)
+	}
+}
+
+// Verbosef reports details that help understanding what is done.
+func (logger *Logger) Verbosef(format string, a ...interface{}) {
+	if logger.Level >= LevelVerbose {
+		fmt.Fprintf(logger.err(), format, a...)
+	}
+}
+
+// Debugf reports everything else.
+func (logger *Logger) Debugf(format string, a ...interface{}) {
+	if logger.Level >= LevelDebug {
+		fmt.Fprintf(logger.err(), format, a...)
+	}
+}
+
+// Warnf reports problems that did not cause the command to fail, unless the
+// logger is quiet.
+func (logger *Logger) Warnf(format string, a ...interface{}) {
+	if logger.Level >= LevelNormal {
+		fmt.Fprintf(logger.err(), format, a...)
+	}
+}
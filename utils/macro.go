@@ -0,0 +1,332 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Macro is a named sequence of steps with lights, groups and scenes, it
+// is run with "hue-cli run <name>".
+type Macro struct {
+	Name        string       `yaml:"name"`
+	Description string       `yaml:"description,omitempty"`
+	Params      []MacroParam `yaml:"params,omitempty"`
+	Steps       []MacroStep  `yaml:"steps"`
+}
+
+// A MacroParam is passed to the macro as --<name>=<value>, the steps use it
+// as {{name}}. A parameter without a default is required.
+type MacroParam struct {
+	Name        string `yaml:"name" json:"name"`
+	Default     string `yaml:"default,omitempty" json:"default,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+}
+
+// A MacroStep changes the lights of a selection (Light) or a group, recalls
+// a scene (optionally for Group), or waits for the Delay. The values are
+// strings so that they can contain parameters.
+type MacroStep struct {
+	Light string `yaml:"light,omitempty"`
+	Group string `yaml:"group,omitempty"`
+	Scene string `yaml:"scene,omitempty"`
+
+	On         string `yaml:"on,omitempty"`
+	Brightness string `yaml:"brightness,omitempty"`
+	Kelvin     string `yaml:"kelvin,omitempty"`
+	Transition string `yaml:"transition,omitempty"`
+
+	Delay string `yaml:"delay,omitempty"`
+}
+
+// A MacroAction is a step of a macro with the parameters filled in. State
+// contains the parameters of the Hue API, like "on" and "bri".
+type MacroAction struct {
+	Light string
+	Group string
+	Scene string
+	State map[string]interface{}
+	Delay time.Duration
+}
+
+var (
+	// macroParam matches {{name}} in the values of the steps
+	macroParam = regexp.MustCompile(`{{\s*([^{}\s]*)\s*}}`)
+	// macroParamName is a valid name of a parameter, it is used as option
+	macroParamName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+)
+
+// FindMacro returns the macro with the name, or nil. Names are compared
+// after NormalizeName.
+func FindMacro(macros []Macro, name string) *Macro {
+	normalized := NormalizeName(name)
+	for i := range macros {
+		if NormalizeName(macros[i].Name) == normalized {
+			return &macros[i]
+		}
+	}
+
+	return nil
+}
+
+// Expand fills in the parameters of the steps. Parameters that are not in
+// the values get their default.
+func (macro *Macro) Expand(values map[string]string) ([]MacroAction, error) {
+	params := map[string]string{}
+	for _, param := range macro.Params {
+		if value, ok := values[param.Name]; ok {
+			params[param.Name] = value
+		} else if param.Default != "" {
+			params[param.Name] = param.Default
+		} else {
+			return nil, fmt.Errorf("the parameter --%s is required", param.Name)
+		}
+	}
+
+	for name := range values {
+		if _, ok := params[name]; !ok {
+			return nil, fmt.Errorf("macro %s does not have a parameter %s", macro.Name, name)
+		}
+	}
+
+	return macro.expand(params, false)
+}
+
+// expand replaces the parameters with the values, and converts the steps to
+// actions. When partial is set, values that use a parameter without a value
+// are not checked (and not set), so that the macro can be validated.
+func (macro *Macro) expand(params map[string]string, partial bool) ([]MacroAction, error) {
+	defined := map[string]bool{}
+	for _, param := range macro.Params {
+		defined[param.Name] = true
+	}
+
+	actions := []MacroAction{}
+	for i, step := range macro.Steps {
+		var err error
+		fill := func(value string) (string, bool) {
+			complete := true
+			value = macroParam.ReplaceAllStringFunc(value, func(match string) string {
+				name := macroParam.FindStringSubmatch(match)[1]
+				if !defined[name] {
+					err = fmt.Errorf("unknown parameter {{%s}}", name)
+				} else if v, ok := params[name]; ok {
+					return v
+				}
+				complete = false
+				return match
+			})
+
+			return value, complete
+		}
+
+		action, stepErr := step.action(fill, partial)
+		if err == nil {
+			err = stepErr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %s", i+1, err)
+		}
+
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
+
+// action converts the step, fill replaces the parameters in a value and
+// returns false when not all of them have a value.
+func (step *MacroStep) action(fill func(string) (string, bool), partial bool) (MacroAction, error) {
+	action := MacroAction{State: map[string]interface{}{}}
+
+	targets := 0
+	if step.Light != "" {
+		action.Light, _ = fill(step.Light)
+		targets++
+	}
+	if step.Scene != "" {
+		action.Scene, _ = fill(step.Scene)
+		targets++
+	}
+	// a scene is recalled for all lights, or for the group
+	if step.Group != "" {
+		action.Group, _ = fill(step.Group)
+		if step.Scene == "" {
+			targets++
+		}
+	}
+
+	if step.Delay != "" {
+		if targets != 0 || step.On != "" || step.Brightness != "" || step.Kelvin != "" || step.Transition != "" {
+			return action, fmt.Errorf("a delay is a step on its own")
+		}
+
+		value, complete := fill(step.Delay)
+		if complete {
+			delay, err := time.ParseDuration(value)
+			if err != nil || delay <= 0 {
+				return action, fmt.Errorf("delay %q is not a duration like 2s", value)
+			}
+			action.Delay = delay
+		} else if !partial {
+			return action, fmt.Errorf("delay %q has no value", value)
+		}
+
+		return action, nil
+	}
+
+	if targets != 1 {
+		return action, fmt.Errorf("set one of light, group, scene or delay")
+	} else if step.Scene != "" {
+		if step.On != "" || step.Brightness != "" || step.Kelvin != "" || step.Transition != "" {
+			return action, fmt.Errorf("a scene sets the state of the lights itself")
+		}
+
+		return action, nil
+	} else if step.On == "" && step.Brightness == "" && step.Kelvin == "" {
+		return action, fmt.Errorf("set at least one of on, brightness or kelvin")
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		parse func(string, map[string]interface{}) error
+	}{
+		{"on", step.On, parseMacroOn},
+		{"brightness", step.Brightness, parseMacroBrightness},
+		{"kelvin", step.Kelvin, parseMacroKelvin},
+		{"transition", step.Transition, parseMacroTransition},
+	} {
+		if field.value == "" {
+			continue
+		}
+
+		value, complete := fill(field.value)
+		if !complete {
+			if partial {
+				continue
+			}
+			return action, fmt.Errorf("%s %q has no value", field.name, value)
+		}
+
+		err := field.parse(value, action.State)
+		if err != nil {
+			return action, fmt.Errorf("%s: %s", field.name, err)
+		}
+	}
+
+	return action, nil
+}
+
+func parseMacroOn(value string, state map[string]interface{}) error {
+	switch strings.ToLower(value) {
+	case "true", "on", "yes":
+		state["on"] = true
+	case "false", "off", "no":
+		state["on"] = false
+	default:
+		return fmt.Errorf("%q is not on or off", value)
+	}
+
+	return nil
+}
+
+// parseMacroBrightness converts a percentage to the brightness of the Hue
+// API (1-254), the light is switched on as well.
+func parseMacroBrightness(value string, state map[string]interface{}) error {
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || percent < 1 || percent > 100 {
+		return fmt.Errorf("%q is not a percentage from 1 to 100", value)
+	}
+
+	state["bri"] = 1 + int(math.Round((percent-1)*253/99))
+	if _, ok := state["on"]; !ok {
+		state["on"] = true
+	}
+
+	return nil
+}
+
+// parseMacroKelvin converts a color temperature to mired (153-500).
+func parseMacroKelvin(value string, state map[string]interface{}) error {
+	kelvin, err := strconv.Atoi(strings.TrimSuffix(strings.ToUpper(value), "K"))
+	if err != nil || kelvin < 2000 || kelvin > 6500 {
+		return fmt.Errorf("%q is not a color temperature from 2000 to 6500", value)
+	}
+
+	ct := int(math.Round(1e6 / float64(kelvin)))
+	if ct < 153 {
+		ct = 153
+	} else if ct > 500 {
+		ct = 500
+	}
+	state["ct"] = ct
+	if _, ok := state["on"]; !ok {
+		state["on"] = true
+	}
+
+	return nil
+}
+
+// parseMacroTransition converts a duration to the transitiontime of the Hue
+// API, in steps of 100ms.
+func parseMacroTransition(value string, state map[string]interface{}) error {
+	transition, err := time.ParseDuration(value)
+	if err != nil || transition < 0 {
+		return fmt.Errorf("%q is not a duration like 400ms", value)
+	}
+
+	state["transitiontime"] = int(transition / (100 * time.Millisecond))
+
+	return nil
+}
+
+// validate checks the steps of the macro, values that use a parameter are
+// only checked when the parameter has a default.
+func (macro *Macro) validate() error {
+	defined := map[string]bool{}
+	defaults := map[string]string{}
+	for _, param := range macro.Params {
+		if !macroParamName.MatchString(param.Name) {
+			return fmt.Errorf("invalid parameter name %q", param.Name)
+		} else if defined[param.Name] {
+			return fmt.Errorf("parameter %s is defined twice", param.Name)
+		}
+
+		defined[param.Name] = true
+		if param.Default != "" {
+			defaults[param.Name] = param.Default
+		}
+	}
+
+	if len(macro.Steps) == 0 {
+		return fmt.Errorf("it does not have steps")
+	}
+
+	_, err := macro.expand(defaults, true)
+
+	return err
+}
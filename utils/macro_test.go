@@ -0,0 +1,118 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMacroExpand(t *testing.T) {
+	config, err := ParseConfig([]byte("version: 1\n" +
+		"bridges: []\n" +
+		"macros:\n" +
+		"- name: dim\n" +
+		"  params:\n" +
+		"  - name: level\n" +
+		"    default: \"50\"\n" +
+		"  steps:\n" +
+		"  - light: desk lamp\n" +
+		"    on: true\n" +
+		"    brightness: \"{{level}}%\"\n" +
+		"    kelvin: 5000K\n" +
+		"    transition: 400ms\n" +
+		"  - delay: 2s\n" +
+		"  - group: Office\n" +
+		"    on: off\n" +
+		"  - scene: Relax\n" +
+		"    group: Office\n"))
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+
+	macro := FindMacro(config.Macros, "Dim")
+	if macro == nil {
+		t.Fatalf("macro dim not found")
+	}
+
+	actions, err := macro.Expand(map[string]string{"level": "100"})
+	if err != nil {
+		t.Fatalf("failed to expand: %s", err)
+	}
+
+	expected := []MacroAction{
+		{Light: "desk lamp", State: map[string]interface{}{"on": true, "bri": 254, "ct": 200, "transitiontime": 4}},
+		{State: map[string]interface{}{}, Delay: 2 * time.Second},
+		{Group: "Office", State: map[string]interface{}{"on": false}},
+		{Scene: "Relax", Group: "Office", State: map[string]interface{}{}},
+	}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Errorf("unexpected actions:\n%+v", actions)
+	}
+
+	// the default is used when the parameter is not passed
+	actions, err = macro.Expand(nil)
+	if err != nil || actions[0].State["bri"] != 126 {
+		t.Errorf("unexpected brightness for the default: %+v (%v)", actions[0].State, err)
+	}
+
+	_, err = macro.Expand(map[string]string{"level": "200"})
+	if err == nil || err.Error() != `step 1: brightness: "200%" is not a percentage from 1 to 100` {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	_, err = macro.Expand(map[string]string{"color": "red"})
+	if err == nil {
+		t.Errorf("expected an error for an unknown parameter")
+	}
+}
+
+func TestValidateMacros(t *testing.T) {
+	config := ConfigFile{
+		Macros: []Macro{
+			{Name: "focus", Steps: []MacroStep{{Light: "desk", Brightness: "100%"}}},
+			{Name: "Focus"},
+			{Name: "level", Params: []MacroParam{{Name: "level"}}, Steps: []MacroStep{
+				{Group: "Office", Brightness: "{{level}}"},
+				{Light: "desk", Kelvin: "{{color}}"},
+			}},
+			{Name: "mixed", Steps: []MacroStep{{Light: "desk", Delay: "1s"}, {Group: "Office"}}},
+		},
+	}
+
+	var problems ValidationError
+	if !errors.As(config.Validate(), &problems) {
+		t.Fatalf("expected a ValidationError")
+	}
+
+	expected := []string{
+		`macro "Focus": the name is used more than once`,
+		`macro "Focus": it does not have steps`,
+		`macro "level": step 2: unknown parameter {{color}}`,
+		`macro "mixed": step 1: a delay is a step on its own`,
+	}
+	if strings.Join(problems, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected problems:\n%s", strings.Join(problems, "\n"))
+	}
+}
@@ -0,0 +1,181 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// An AmbiguousNameError is returned when more than one item matches a name.
+type AmbiguousNameError struct {
+	Name       string
+	Candidates []InventoryItem
+}
+
+func (e *AmbiguousNameError) Error() string {
+	names := []string{}
+	for _, item := range e.Candidates {
+		names = append(names, fmt.Sprintf("'%s' (%s)", item.Name, item.ID))
+	}
+
+	return fmt.Sprintf("'%s' is ambiguous, it matches %s", e.Name, strings.Join(names, ", "))
+}
+
+// An UnknownNameError is returned when no item matches a name, it contains
+// the names that are the closest.
+type UnknownNameError struct {
+	Name        string
+	Suggestions []string
+}
+
+func (e *UnknownNameError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("nothing matches '%s'", e.Name)
+	}
+
+	return fmt.Sprintf("nothing matches '%s', did you mean '%s'?", e.Name,
+		strings.Join(e.Suggestions, "' or '"))
+}
+
+// maxSuggestions is the number of names that an UnknownNameError suggests.
+const maxSuggestions = 3
+
+// NormalizeName returns the name in lower case, without accents and with
+// single spaces, so that "Küche  Lamp" matches "kuche lamp".
+func NormalizeName(name string) string {
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	normalized, _, err := transform.String(t, name)
+	if err != nil {
+		normalized = name
+	}
+
+	return strings.ToLower(strings.Join(strings.Fields(normalized), " "))
+}
+
+// ResolveItem returns the item that matches the name. An exact match of the
+// ID or name is preferred, otherwise the normalized names are compared. It
+// returns an AmbiguousNameError or UnknownNameError when there is no single
+// match.
+func ResolveItem(items []InventoryItem, name string) (InventoryItem, error) {
+	for _, item := range items {
+		if item.ID == name {
+			return item, nil
+		}
+	}
+
+	normalized := NormalizeName(name)
+	for _, match := range []func(InventoryItem) bool{
+		func(item InventoryItem) bool { return item.Name == name },
+		func(item InventoryItem) bool { return NormalizeName(item.Name) == normalized },
+	} {
+		candidates := []InventoryItem{}
+		for _, item := range items {
+			if match(item) {
+				candidates = append(candidates, item)
+			}
+		}
+
+		if len(candidates) == 1 {
+			return candidates[0], nil
+		} else if len(candidates) > 1 {
+			return InventoryItem{}, &AmbiguousNameError{Name: name, Candidates: candidates}
+		}
+	}
+
+	return InventoryItem{}, &UnknownNameError{Name: name, Suggestions: suggestNames(items, normalized)}
+}
+
+// suggestNames returns the names that are close to the normalized name, the
+// closest first.
+func suggestNames(items []InventoryItem, normalized string) []string {
+	type suggestion struct {
+		name     string
+		distance int
+	}
+
+	// allow a typo for every 3 characters, but at least 2
+	limit := len([]rune(normalized)) / 3
+	if limit < 2 {
+		limit = 2
+	}
+
+	suggestions := []suggestion{}
+	for _, item := range items {
+		distance := EditDistance(normalized, NormalizeName(item.Name))
+		if distance <= limit {
+			suggestions = append(suggestions, suggestion{item.Name, distance})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+
+	names := []string{}
+	for i := 0; i < len(suggestions) && i < maxSuggestions; i++ {
+		names = append(names, suggestions[i].name)
+	}
+
+	return names
+}
+
+// EditDistance returns the Levenshtein distance between two strings.
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	previous := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current := make([]int, len(rb)+1)
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+
+	return previous[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+
+	return a
+}
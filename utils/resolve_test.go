@@ -0,0 +1,103 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var testItems = []InventoryItem{
+	{ID: "1", Name: "Desk Lamp"},
+	{ID: "2", Name: "Küche"},
+	{ID: "3", Name: "Floor lamp"},
+	{ID: "4", Name: "floor Lamp"},
+}
+
+func TestNormalizeName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Desk Lamp":     "desk lamp",
+		"  Desk   Lamp": "desk lamp",
+		"Küche":         "kuche",
+		"Ｄｅｓｋ":          "desk",
+	} {
+		if normalized := NormalizeName(name); normalized != expected {
+			t.Errorf("NormalizeName(%q) = %q, expected %q", name, normalized, expected)
+		}
+	}
+}
+
+func TestResolveItem(t *testing.T) {
+	for name, id := range map[string]string{
+		"1":          "1",
+		"desk lamp":  "1",
+		"kuche":      "2",
+		"Floor lamp": "3",
+	} {
+		item, err := ResolveItem(testItems, name)
+		if err != nil || item.ID != id {
+			t.Errorf("ResolveItem(%q) = %v (%v), expected %s", name, item, err, id)
+		}
+	}
+}
+
+func TestResolveItemAmbiguous(t *testing.T) {
+	_, err := ResolveItem(testItems, "FLOOR LAMP")
+
+	var ambiguous *AmbiguousNameError
+	if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+		t.Errorf("expected an ambiguous match, got %v", err)
+	}
+}
+
+func TestResolveItemSuggestions(t *testing.T) {
+	_, err := ResolveItem(testItems, "desk lmp")
+
+	var unknown *UnknownNameError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("expected an unknown name, got %v", err)
+	}
+	if !reflect.DeepEqual(unknown.Suggestions, []string{"Desk Lamp"}) {
+		t.Errorf("unexpected suggestions: %v", unknown.Suggestions)
+	}
+
+	_, err = ResolveItem(testItems, "garage")
+	if !errors.As(err, &unknown) || len(unknown.Suggestions) != 0 {
+		t.Errorf("expected no suggestions, got %v", err)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"desk lamp", "desk lmp", 1},
+		{"küche", "kuche", 1},
+	} {
+		if d := EditDistance(test.a, test.b); d != test.distance {
+			t.Errorf("EditDistance(%q, %q) = %d, expected %d", test.a, test.b, d, test.distance)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package utils
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// PassphraseEnv is the environment variable with the passphrase for the
+// encrypted secrets file.
+const PassphraseEnv = "HUE_CLI_PASSPHRASE"
+
+// keyringService is the service that the secrets are stored under in the
+// keyring.
+const keyringService = "hue-cli"
+
+// secretFileIterations is the number of PBKDF2 iterations for the key of
+// new secrets files, files with less than minSecretFileIterations are not
+// accepted.
+var (
+	secretFileIterations    = 600000
+	minSecretFileIterations = 100000
+)
+
+// LookupSecret returns the secret that the reference points to, which is
+// one of:
+//
+//	keyring:<key>        the keyring of the user (Secret Service or Keychain)
+//	file:<path>#<key>    a file that is encrypted with the passphrase
+//	command:<command>    the output of a command, like "pass show hue/office"
+func LookupSecret(ref string) (string, error) {
+	scheme, arg := splitSecretRef(ref)
+
+	var secret string
+	var err error
+	switch scheme {
+	case "keyring":
+		secret, err = keyringGet(arg)
+	case "file":
+		path, key := splitSecretFileRef(arg)
+		secret, err = secretFileGet(path, key)
+	case "command":
+		secret, err = runSecretCommand(arg, "")
+	default:
+		return "", fmt.Errorf("unknown secret reference %q, use keyring:, file: or command:", ref)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %w", ref, err)
+	}
+
+	return secret, nil
+}
+
+// StoreSecret stores the secret where the reference points to. Secrets can
+// not be stored through a command.
+func StoreSecret(ref, secret string) error {
+	scheme, arg := splitSecretRef(ref)
+
+	var err error
+	switch scheme {
+	case "keyring":
+		err = keyringSet(arg, secret)
+	case "file":
+		path, key := splitSecretFileRef(arg)
+		err = secretFileSet(path, key, secret)
+	case "command":
+		return fmt.Errorf("secrets can not be stored with a command, store it yourself and use %s", ref)
+	default:
+		return fmt.Errorf("unknown secret reference %q, use keyring: or file:", ref)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store secret %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+// DefaultSecretsFile returns the path of the encrypted file with secrets.
+func DefaultSecretsFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "hue-cli", "secrets.json"), nil
+}
+
+func splitSecretRef(ref string) (string, string) {
+	i := strings.Index(ref, ":")
+	if i == -1 {
+		return "", ref
+	}
+
+	return ref[:i], ref[i+1:]
+}
+
+// validSecretRef checks that the reference has a known scheme and an argument.
+func validSecretRef(ref string) bool {
+	scheme, arg := splitSecretRef(ref)
+	switch scheme {
+	case "keyring", "file", "command":
+		return arg != ""
+	}
+
+	return false
+}
+
+func splitSecretFileRef(arg string) (string, string) {
+	i := strings.LastIndex(arg, "#")
+	if i == -1 {
+		return arg, ""
+	}
+
+	return arg[:i], arg[i+1:]
+}
+
+// runSecretCommand runs the command with the shell, with the input on stdin,
+// and returns the first line of its output.
+func runSecretCommand(command, input string) (string, error) {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/c"
+	}
+
+	return runCommand(exec.Command(shell, flag, command), input)
+}
+
+func runCommand(cmd *exec.Cmd, input string) (string, error) {
+	var stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w (%s)", cmd.Path, err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", cmd.Path, err)
+	}
+
+	return strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0]), nil
+}
+
+// keyringGet reads the secret from the keyring with secret-tool (Secret
+// Service on Linux), or security (Keychain on macOS).
+func keyringGet(key string) (string, error) {
+	var secret string
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		secret, err = runCommand(exec.Command("security", "find-generic-password", "-s", keyringService, "-a", key, "-w"), "")
+	case "windows":
+		return "", errors.New("the keyring is not supported on Windows, use file: or command:")
+	default:
+		secret, err = runCommand(exec.Command("secret-tool", "lookup", "service", keyringService, "username", key), "")
+	}
+	if err == nil && secret == "" {
+		err = fmt.Errorf("the keyring does not contain %s", key)
+	}
+
+	return secret, err
+}
+
+func keyringSet(key, secret string) error {
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		// the secret would be visible in the process list when passed
+		// as argument, interactive mode reads the command from stdin
+		input := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+			securityQuote(keyringService), securityQuote(key), securityQuote(secret))
+		_, err = runCommand(exec.Command("security", "-i"), input)
+	case "windows":
+		return errors.New("the keyring is not supported on Windows, use file:")
+	default:
+		_, err = runCommand(exec.Command("secret-tool", "store", "--label", "hue-cli "+key, "service", keyringService, "username", key), secret)
+	}
+
+	return err
+}
+
+// securityQuote quotes an argument for a command that is passed to
+// "security -i" on stdin.
+func securityQuote(arg string) string {
+	arg = strings.ReplaceAll(arg, `\`, `\\`)
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
+
+// A secretsFile contains secrets that are encrypted with AES-GCM, the key is
+// derived from the passphrase with PBKDF2-SHA256.
+type secretsFile struct {
+	Salt       string            `json:"salt"`
+	Iterations int               `json:"iterations"`
+	Secrets    map[string]string `json:"secrets"`
+}
+
+func secretFileGet(path, key string) (string, error) {
+	file, err := loadSecretsFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := file.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	return file.open(aead, path, key)
+}
+
+func secretFileSet(path, key, secret string) error {
+	file, err := loadSecretsFile(path)
+	if os.IsNotExist(err) {
+		salt := make([]byte, 16)
+		_, err = rand.Read(salt)
+		if err != nil {
+			return err
+		}
+		file = &secretsFile{
+			Salt:       base64.StdEncoding.EncodeToString(salt),
+			Iterations: secretFileIterations,
+			Secrets:    map[string]string{},
+		}
+	} else if err != nil {
+		return err
+	}
+
+	aead, err := file.cipher()
+	if err != nil {
+		return err
+	}
+
+	// all secrets in the file have the same passphrase
+	for k := range file.Secrets {
+		_, err = file.open(aead, path, k)
+		if err != nil {
+			return err
+		}
+		break
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return err
+	}
+	file.Secrets[key] = base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(secret), []byte(key)))
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func loadSecretsFile(path string) (*secretsFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &secretsFile{}
+	err = json.Unmarshal(data, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if file.Secrets == nil {
+		file.Secrets = map[string]string{}
+	}
+
+	return file, nil
+}
+
+func (file *secretsFile) cipher() (cipher.AEAD, error) {
+	passphrase := os.Getenv(PassphraseEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("the passphrase for the secrets file is not set in %s", PassphraseEnv)
+	}
+
+	// a damaged or changed file could make the key easy to guess
+	if file.Iterations < minSecretFileIterations {
+		return nil, fmt.Errorf("the key of the secrets file uses %d PBKDF2 iterations, at least %d are required",
+			file.Iterations, minSecretFileIterations)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(file.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, file.Iterations, 32, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// open decrypts the secret with the key, the key is authenticated as well.
+func (file *secretsFile) open(aead cipher.AEAD, path, key string) (string, error) {
+	sealed, ok := file.Secrets[key]
+	if !ok {
+		return "", fmt.Errorf("%s does not contain %q", path, key)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("the secret %q in %s is corrupt", key, path)
+	}
+
+	secret, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(key))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %q in %s, is the passphrase correct?", key, path)
+	}
+
+	return string(secret), nil
+}
@@ -0,0 +1,178 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fastSecretFile lowers the PBKDF2 iterations, so that the tests run fast.
+func fastSecretFile(t *testing.T) {
+	iterations, minimum := secretFileIterations, minSecretFileIterations
+	secretFileIterations, minSecretFileIterations = 1000, 1000
+	t.Cleanup(func() {
+		secretFileIterations, minSecretFileIterations = iterations, minimum
+	})
+}
+
+func TestSecretFile(t *testing.T) {
+	fastSecretFile(t)
+
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	t.Setenv(PassphraseEnv, "correct horse")
+
+	for key, secret := range map[string]string{"office": "user-office", "home": "user-home"} {
+		err := StoreSecret("file:"+path+"#"+key, secret)
+		if err != nil {
+			t.Fatalf("failed to store %s: %s", key, err)
+		}
+	}
+
+	secret, err := LookupSecret("file:" + path + "#office")
+	if err != nil || secret != "user-office" {
+		t.Errorf("unexpected secret %q (%v)", secret, err)
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	if strings.Contains(string(data), "user-office") {
+		t.Errorf("the secret is not encrypted:\n%s", data)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("unexpected permissions of %s: %v", path, info.Mode())
+	}
+
+	// all secrets in the file use the same passphrase
+	t.Setenv(PassphraseEnv, "wrong")
+	_, err = LookupSecret("file:" + path + "#office")
+	if err == nil || !strings.Contains(err.Error(), "is the passphrase correct?") {
+		t.Errorf("expected a wrong passphrase, got %v", err)
+	}
+	err = StoreSecret("file:"+path+"#other", "user-other")
+	if err == nil {
+		t.Error("stored a secret with another passphrase")
+	}
+}
+
+func TestSecretFileIterations(t *testing.T) {
+	fastSecretFile(t)
+
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	t.Setenv(PassphraseEnv, "correct horse")
+
+	err := StoreSecret("file:"+path+"#office", "user-office")
+	if err != nil {
+		t.Fatalf("failed to store the secret: %s", err)
+	}
+
+	// a changed file can not lower the iterations
+	data, _ := ioutil.ReadFile(path)
+	data = []byte(strings.Replace(string(data), `"iterations": 1000`, `"iterations": 1`, 1))
+	err = ioutil.WriteFile(path, data, 0600)
+	if err != nil {
+		t.Fatalf("failed to write %s: %s", path, err)
+	}
+
+	_, err = LookupSecret("file:" + path + "#office")
+	if err == nil || !strings.Contains(err.Error(), "at least 1000 are required") {
+		t.Errorf("expected an error for too few iterations, got %v", err)
+	}
+}
+
+func TestSecretCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test uses a POSIX shell")
+	}
+
+	secret, err := LookupSecret("command:echo user-office")
+	if err != nil || secret != "user-office" {
+		t.Errorf("unexpected secret %q (%v)", secret, err)
+	}
+
+	_, err = LookupSecret("command:exit 1")
+	if err == nil {
+		t.Error("expected an error for a failing command")
+	}
+
+	err = StoreSecret("command:echo user-office", "user-office")
+	if err == nil {
+		t.Error("expected an error for storing with a command")
+	}
+}
+
+func TestSecretKeyring(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the test replaces secret-tool of Linux")
+	}
+
+	// a secret-tool that keeps the secrets in files
+	dir := t.TempDir()
+	script := "#!/bin/sh\n" +
+		"case \"$1\" in\n" +
+		"store) cat > \"" + dir + "/$7\" ;;\n" +
+		"lookup) cat \"" + dir + "/$5\" 2>/dev/null ;;\n" +
+		"esac\n"
+	err := ioutil.WriteFile(filepath.Join(dir, "secret-tool"), []byte(script), 0700)
+	if err != nil {
+		t.Fatalf("failed to write secret-tool: %s", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	err = StoreSecret("keyring:office", "user-office")
+	if err != nil {
+		t.Fatalf("failed to store the secret: %s", err)
+	}
+
+	bc := BridgeConfig{UserSecret: "keyring:office"}
+	user, err := bc.Username()
+	if err != nil || user != "user-office" {
+		t.Errorf("unexpected user %q (%v)", user, err)
+	}
+
+	_, err = LookupSecret("keyring:home")
+	if err == nil {
+		t.Error("expected an error for a missing secret")
+	}
+}
+
+func TestSecurityQuote(t *testing.T) {
+	for arg, quoted := range map[string]string{
+		"1028d66426293e821ecfd9ef1a0731df": `"1028d66426293e821ecfd9ef1a0731df"`,
+		"two words":                        `"two words"`,
+		`say "hi"`:                         `"say \"hi\""`,
+		`back\slash`:                       `"back\\slash"`,
+	} {
+		if got := securityQuote(arg); got != quoted {
+			t.Errorf("securityQuote(%q) = %s, expected %s", arg, got, quoted)
+		}
+	}
+}
+
+func TestUnknownSecret(t *testing.T) {
+	_, err := LookupSecret("vault:office")
+	if err == nil || !strings.Contains(err.Error(), "unknown secret reference") {
+		t.Errorf("expected an unknown reference, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+//
+// This file is part of hue-cli
+// A program written in the Go Programming Language for the Philips Hue API.
+// Copyright (C) 2018 Niels de Vos
+//
+// This program is free software; you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation; either version 2 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License along
+// with this program; if not, write to the Free Software Foundation, Inc.,
+// 51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+//
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	// for yaml conversion of the Snapshot
+	"gopkg.in/yaml.v2"
+)
+
+// A Snapshot contains the state of a set of lights at the moment it was
+// taken, so that the lights can be put back in that state later on.
+type Snapshot struct {
+	Bridge string `yaml:"bridge"`
+	// BridgeID identifies the bridge that the snapshot was taken of, the
+	// light indexes only apply to that bridge
+	BridgeID string          `yaml:"bridgeid,omitempty"`
+	Lights   []LightSnapshot `yaml:"lights"`
+}
+
+// A LightSnapshot is the state of a single light. Only the color attributes
+// that belong to the ColorMode of the light are relevant when restoring.
+type LightSnapshot struct {
+	Index     int       `yaml:"index"`
+	UniqueID  string    `yaml:"uniqueid"`
+	Name      string    `yaml:"name"`
+	On        bool      `yaml:"on"`
+	Bri       int       `yaml:"bri"`
+	Effect    string    `yaml:"effect,omitempty"`
+	ColorMode string    `yaml:"colormode,omitempty"`
+	XY        []float64 `yaml:"xy,omitempty"`
+	CT        int       `yaml:"ct,omitempty"`
+	Hue       int       `yaml:"hue,omitempty"`
+	Sat       int       `yaml:"sat,omitempty"`
+}
+
+// SnapshotDir returns the directory where snapshots are stored.
+func SnapshotDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "hue-cli", "snapshots"), nil
+}
+
+func snapshotFile(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", errors.New(fmt.Sprintf("invalid snapshot name '%s'", name))
+	}
+
+	dir, err := SnapshotDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, name+".yaml"), nil
+}
+
+// Save writes the snapshot to the snapshot directory under the given name,
+// an existing snapshot with the same name is replaced.
+func (snapshot *Snapshot) Save(name string) error {
+	filename, err := snapshotFile(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return errors.New(fmt.Sprintf("failed to convert to yaml (%s)", err))
+	}
+
+	err = os.MkdirAll(filepath.Dir(filename), 0700)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, data, 0600)
+}
+
+// LoadSnapshot reads the snapshot with the given name.
+func LoadSnapshot(name string) (*Snapshot, error) {
+	filename, err := snapshotFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := Snapshot{}
+	err = yaml.Unmarshal(data, &snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// RemoveSnapshot deletes the snapshot with the given name.
+func RemoveSnapshot(name string) error {
+	filename, err := snapshotFile(name)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(filename)
+}